@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/types"
+	"github.com/drgolem/musictools/internal/decoders"
+
+	"github.com/spf13/cobra"
+)
+
+var benchChunkSamples int
+
+var benchCmd = &cobra.Command{
+	Use:   "bench <audio_file> [audio_file...]",
+	Short: "Measure decode throughput",
+	Long: `Decode each file as fast as possible, discarding the audio, and report wall
+time, samples per second, decoded MB per second and the speed relative to
+real time. With several files a per-format summary is printed at the end.
+
+Examples:
+  musictools bench song.flac
+  musictools bench song.wav song.flac song.mp3
+  musictools bench --chunk 1024 song.mp3`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runBench,
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().IntVar(&benchChunkSamples, "chunk", 4096, "Sample frames requested per decode call")
+}
+
+// benchResult is the decode throughput for one file.
+type benchResult struct {
+	format  string // lower-case file extension without the dot
+	samples int
+	bytes   int64
+	audio   time.Duration // duration of the decoded audio
+	open    time.Duration
+	decode  time.Duration
+}
+
+func runBench(cmd *cobra.Command, args []string) {
+	if benchChunkSamples <= 0 {
+		slog.Error("Chunk size must be positive", "chunk", benchChunkSamples)
+		os.Exit(1)
+	}
+
+	totals := make(map[string]*benchResult)
+	var formats []string
+	failed := 0
+
+	for _, fileName := range args {
+		res, err := benchFile(fileName, benchChunkSamples)
+		if err != nil {
+			slog.Error("Benchmark failed", "file", fileName, "error", err)
+			failed++
+			continue
+		}
+		logBenchResult("Decoded", "file", fileName, res)
+
+		total, ok := totals[res.format]
+		if !ok {
+			total = &benchResult{format: res.format}
+			totals[res.format] = total
+			formats = append(formats, res.format)
+		}
+		total.samples += res.samples
+		total.bytes += res.bytes
+		total.audio += res.audio
+		total.open += res.open
+		total.decode += res.decode
+	}
+
+	if len(args) > 1 {
+		for _, format := range formats {
+			logBenchResult("Format summary", "format", format, *totals[format])
+		}
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// benchFile opens fileName with the decoder factory and decodes it to the
+// end, timing the open and the decode separately.
+func benchFile(fileName string, chunkSamples int) (benchResult, error) {
+	res := benchResult{format: strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")}
+
+	start := time.Now()
+	dec, err := decoders.NewDecoder(fileName)
+	if err != nil {
+		return res, err
+	}
+	defer dec.Close()
+	res.open = time.Since(start)
+
+	rate, channels, bps := dec.GetFormat()
+	format := types.FrameFormat{SampleRate: rate, Channels: channels, BitsPerSample: bps}
+
+	start = time.Now()
+	res.samples, err = decodeChunks(dec, format, chunkSamples, func(chunk []byte) error {
+		res.bytes += int64(len(chunk))
+		return nil
+	})
+	res.decode = time.Since(start)
+	if err != nil {
+		return res, err
+	}
+
+	if rate > 0 {
+		res.audio = time.Duration(int64(res.samples) * int64(time.Second) / int64(rate))
+	}
+	return res, nil
+}
+
+// logBenchResult logs the throughput figures of res under msg.
+func logBenchResult(msg, key, value string, res benchResult) {
+	secs := res.decode.Seconds()
+	var samplesPerSec, mbPerSec, realtime float64
+	if secs > 0 {
+		samplesPerSec = float64(res.samples) / secs
+		mbPerSec = float64(res.bytes) / (1 << 20) / secs
+		realtime = res.audio.Seconds() / secs
+	}
+
+	slog.Info(msg,
+		key, value,
+		"samples", res.samples,
+		"audio", res.audio.Round(time.Millisecond),
+		"open_time", res.open.Round(time.Microsecond),
+		"decode_time", res.decode.Round(time.Microsecond),
+		"samples_per_sec", fmt.Sprintf("%.0f", samplesPerSec),
+		"mb_per_sec", fmt.Sprintf("%.1f", mbPerSec),
+		"realtime", fmt.Sprintf("%.0fx", realtime))
+}
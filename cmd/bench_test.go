@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestBenchFile(t *testing.T) {
+	res, err := benchFile(audiotest.WriteFile(t, "in.wav", testWAV(16000)), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.format != "wav" {
+		t.Errorf("format = %q, want wav", res.format)
+	}
+	if res.samples != 16000 || res.bytes != 4*16000 || res.audio != 2*time.Second {
+		t.Errorf("benchFile = %d samples, %d bytes, %v of audio, want 16000, %d, 2s",
+			res.samples, res.bytes, res.audio, 4*16000)
+	}
+	if res.decode <= 0 {
+		t.Fatalf("decode time = %v, want a positive duration", res.decode)
+	}
+	if rate := float64(res.samples) / res.decode.Seconds(); rate <= 0 {
+		t.Errorf("throughput = %g samples/sec, want positive", rate)
+	}
+}
+
+func TestBenchFileMissing(t *testing.T) {
+	if _, err := benchFile("does-not-exist.wav", 1000); err == nil {
+		t.Fatal("benchFile succeeded on a missing file")
+	}
+}
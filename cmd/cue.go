@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/cuesheet"
+	"github.com/drgolem/musictools/internal/decoders"
+)
+
+// playlistEntry is one item of a playlist: a whole file, or one track of a
+// cue sheet played from its file.
+type playlistEntry struct {
+	file  string
+	track *cueTrack // nil for a whole file
+}
+
+// cueTrack is the part of a file covered by one track of a cue sheet.
+type cueTrack struct {
+	number     int
+	start, end time.Duration // end 0 is the end of the file
+	title      string
+}
+
+// fileEntries returns a playlist entry for each of files.
+func fileEntries(files []string) []playlistEntry {
+	entries := make([]playlistEntry, len(files))
+	for i, f := range files {
+		entries[i] = playlistEntry{file: f}
+	}
+	return entries
+}
+
+// loadCueSheet parses cueFile and returns one playlist entry per track.
+// audioFile, if set, replaces the sheet's FILE, which must then be a single
+// file; otherwise FILE names are resolved relative to the cue sheet.
+func loadCueSheet(cueFile, audioFile string) ([]playlistEntry, error) {
+	sheet, err := cuesheet.ParseFile(cueFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if audioFile != "" {
+		for _, t := range sheet.Tracks[1:] {
+			if t.File != sheet.Tracks[0].File {
+				return nil, fmt.Errorf("cue sheet references several files, cannot play it from %s", audioFile)
+			}
+		}
+	}
+
+	entries := make([]playlistEntry, 0, len(sheet.Tracks))
+	for i, t := range sheet.Tracks {
+		fileName := audioFile
+		if fileName == "" {
+			fileName = t.File
+			if !filepath.IsAbs(fileName) {
+				fileName = filepath.Join(filepath.Dir(cueFile), fileName)
+			}
+		}
+
+		entries = append(entries, playlistEntry{
+			file: fileName,
+			track: &cueTrack{
+				number: t.Number,
+				start:  t.Start,
+				end:    sheet.End(i),
+				title:  t.Title,
+			},
+		})
+
+		slog.Debug("Cue track",
+			"track", t.Number,
+			"title", t.Title,
+			"performer", t.Performer,
+			"file", fileName,
+			"start", t.Start)
+	}
+
+	slog.Info("Loaded cue sheet",
+		"cue", cueFile,
+		"title", sheet.Title,
+		"performer", sheet.Performer,
+		"tracks", len(entries))
+	return entries, nil
+}
+
+// open opens the entry's decoder, limited to the track for a cue track.
+func (e playlistEntry) open() (decoder.AudioDecoder, error) {
+	dec, err := decoders.NewDecoder(e.file)
+	if err != nil || e.track == nil {
+		return dec, err
+	}
+	seg, err := decoders.NewSegmentDecoder(dec, e.track.start, e.track.end)
+	if err != nil {
+		dec.Close()
+		return nil, err
+	}
+	return seg, nil
+}
+
+// name returns the name shown in playback status for the entry: the file's
+// base name, or the track number and title for a cue track.
+func (e playlistEntry) name() string {
+	if e.track != nil {
+		return fmt.Sprintf("%02d %s", e.track.number, e.track.title)
+	}
+	return filepath.Base(e.file)
+}
+
+// String identifies the entry in logs: the file, followed by the track
+// number for a cue track.
+func (e playlistEntry) String() string {
+	if e.track != nil {
+		return fmt.Sprintf("%s (track %02d)", e.file, e.track.number)
+	}
+	return e.file
+}
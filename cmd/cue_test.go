@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testCueSheet = `TITLE "Live Album"
+FILE "album.flac" WAVE
+  TRACK 01 AUDIO
+    TITLE "Opener"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Second"
+    INDEX 01 04:00:00
+`
+
+// writeCueSheet writes sheet to a cue file in a temporary directory.
+func writeCueSheet(t *testing.T, sheet string) string {
+	t.Helper()
+	cueFile := filepath.Join(t.TempDir(), "album.cue")
+	if err := os.WriteFile(cueFile, []byte(sheet), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return cueFile
+}
+
+func TestLoadCueSheet(t *testing.T) {
+	cueFile := writeCueSheet(t, testCueSheet)
+	albumFile := filepath.Join(filepath.Dir(cueFile), "album.flac")
+
+	for _, tt := range []struct {
+		audioFile, want string
+	}{
+		{"", albumFile},
+		{"other.flac", "other.flac"},
+	} {
+		entries, err := loadCueSheet(cueFile, tt.audioFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("loadCueSheet returned %d entries, want 2", len(entries))
+		}
+
+		want := []cueTrack{
+			{number: 1, start: 0, end: 4 * time.Minute, title: "Opener"},
+			{number: 2, start: 4 * time.Minute, end: 0, title: "Second"},
+		}
+		for i, e := range entries {
+			if e.file != tt.want {
+				t.Errorf("entry %d file = %q, want %q", i, e.file, tt.want)
+			}
+			if e.track == nil || *e.track != want[i] {
+				t.Errorf("entry %d track = %+v, want %+v", i, e.track, want[i])
+			}
+		}
+		if name := entries[1].name(); name != "02 Second" {
+			t.Errorf("name = %q, want %q", name, "02 Second")
+		}
+	}
+}
+
+func TestLoadCueSheetSeveralFiles(t *testing.T) {
+	cueFile := writeCueSheet(t, testCueSheet+`FILE "bonus.wav" WAVE
+  TRACK 03 AUDIO
+    INDEX 01 00:00:00
+`)
+	if _, err := loadCueSheet(cueFile, "album.flac"); err == nil {
+		t.Fatal("loadCueSheet played a multi-file sheet from one file")
+	}
+	entries, err := loadCueSheet(cueFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filepath.Base(entries[2].file); got != "bonus.wav" {
+		t.Errorf("track 3 file = %q, want bonus.wav", got)
+	}
+}
+
+func TestPlaylistEntryNames(t *testing.T) {
+	file := playlistEntry{file: "music/song.flac"}
+	track := playlistEntry{file: "music/album.flac", track: &cueTrack{number: 3, title: "Title"}}
+	tests := []struct {
+		entry      playlistEntry
+		name, text string
+	}{
+		{file, "song.flac", "music/song.flac"},
+		{track, "03 Title", "music/album.flac (track 03)"},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.name(); got != tt.name {
+			t.Errorf("name() = %q, want %q", got, tt.name)
+		}
+		if got := tt.entry.String(); got != tt.text {
+			t.Errorf("String() = %q, want %q", got, tt.text)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/drgolem/go-portaudio/portaudio"
+	"github.com/spf13/cobra"
+)
+
+var devicesCmd = &cobra.Command{
+	Use:   "devices",
+	Short: "List audio host APIs and output devices",
+	Long: `List PortAudio host APIs (ALSA, PulseAudio, CoreAudio, WASAPI, ...) and
+their output devices.
+
+The "index" of a device is the global index accepted by "play -d". With
+"play --host-api", "-d" is instead the device's position within that host API
+("api_device" below).
+
+Examples:
+  musictools devices
+  musictools play --host-api ALSA -d 0 music.flac`,
+	Args: cobra.NoArgs,
+	Run:  runDevices,
+}
+
+func init() {
+	rootCmd.AddCommand(devicesCmd)
+}
+
+func runDevices(cmd *cobra.Command, args []string) {
+	if err := portaudio.Initialize(); err != nil {
+		slog.Error("Failed to initialize PortAudio", "error", err)
+		os.Exit(1)
+	}
+	defer portaudio.Terminate()
+
+	apis, err := portaudio.HostApis()
+	if err != nil {
+		slog.Error("Failed to list host APIs", "error", err)
+		os.Exit(1)
+	}
+	devices, err := portaudio.Devices()
+	if err != nil {
+		slog.Error("Failed to list devices", "error", err)
+		os.Exit(1)
+	}
+
+	for apiIdx, api := range apis {
+		slog.Info("Host API",
+			"host_api", apiIdx,
+			"name", api.Name,
+			"devices", api.DeviceCount,
+			"default_output", api.DefaultOutputDevice)
+
+		for apiDevIdx, dev := range hostAPIDevices(devices, apiIdx) {
+			if dev.MaxOutputChannels == 0 {
+				continue
+			}
+			slog.Info("  Output device",
+				"index", dev.Index,
+				"api_device", apiDevIdx,
+				"name", dev.Name,
+				"max_channels", dev.MaxOutputChannels,
+				"default_sample_rate", dev.DefaultSampleRate)
+		}
+	}
+}
+
+// hostAPIDevices returns the devices belonging to a host API, in PortAudio
+// order. A device's position in the result is its index within the host API.
+func hostAPIDevices(devices []*portaudio.DeviceInfo, hostAPIIdx int) []*portaudio.DeviceInfo {
+	var result []*portaudio.DeviceInfo
+	for _, dev := range devices {
+		if dev.HostApiIndex == hostAPIIdx {
+			result = append(result, dev)
+		}
+	}
+	return result
+}
+
+// findHostAPI returns the index of the host API selected by name or index.
+// Names match case-insensitively on a substring, e.g. "alsa" or "pulse".
+func findHostAPI(apis []*portaudio.HostApiInfo, hostAPI string) (int, error) {
+	if idx, err := strconv.Atoi(hostAPI); err == nil {
+		if idx < 0 || idx >= len(apis) {
+			return 0, fmt.Errorf("host API index %d out of range (0-%d)", idx, len(apis)-1)
+		}
+		return idx, nil
+	}
+
+	for idx, api := range apis {
+		if strings.Contains(strings.ToLower(api.Name), strings.ToLower(hostAPI)) {
+			return idx, nil
+		}
+	}
+	return 0, fmt.Errorf("no host API matching %q (see 'musictools devices')", hostAPI)
+}
+
+// resolveHostAPIDevice maps a device within a host API to a global PortAudio
+// device index. When apiDeviceIdx is negative the host API's default output
+// device is used.
+func resolveHostAPIDevice(hostAPI string, apiDeviceIdx int) (int, error) {
+	apis, err := portaudio.HostApis()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list host APIs: %w", err)
+	}
+	apiIdx, err := findHostAPI(apis, hostAPI)
+	if err != nil {
+		return 0, err
+	}
+
+	if apiDeviceIdx < 0 {
+		def := apis[apiIdx].DefaultOutputDevice
+		if def < 0 {
+			return 0, fmt.Errorf("host API %q has no default output device", apis[apiIdx].Name)
+		}
+		return def, nil
+	}
+
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list devices: %w", err)
+	}
+	apiDevices := hostAPIDevices(devices, apiIdx)
+	if apiDeviceIdx >= len(apiDevices) {
+		return 0, fmt.Errorf("host API %q has %d devices, got device %d",
+			apis[apiIdx].Name, len(apiDevices), apiDeviceIdx)
+	}
+	return apiDevices[apiDeviceIdx].Index, nil
+}
+
+// validateDevice opens and closes an output stream on deviceIdx with the
+// given format, so a bad device index, a busy device or an unsupported
+// format is reported before playback starts instead of from inside Play.
+func validateDevice(deviceIdx, sampleRate, channels, bitsPerSample, framesPerBuffer int) error {
+	var sampleFormat portaudio.PaSampleFormat
+	switch bitsPerSample {
+	case 16:
+		sampleFormat = portaudio.SampleFmtInt16
+	case 24:
+		sampleFormat = portaudio.SampleFmtInt24
+	case 32:
+		sampleFormat = portaudio.SampleFmtInt32
+	default:
+		return fmt.Errorf("unsupported bit depth: %d", bitsPerSample)
+	}
+
+	dev, err := portaudio.GetDeviceInfo(deviceIdx)
+	if err != nil {
+		return fmt.Errorf("invalid device index %d (see 'musictools devices'): %w", deviceIdx, err)
+	}
+	if dev.MaxOutputChannels < channels {
+		return fmt.Errorf("device %d (%s) supports %d output channels, need %d",
+			deviceIdx, dev.Name, dev.MaxOutputChannels, channels)
+	}
+
+	stream, err := portaudio.NewOutputStream(deviceIdx, channels, sampleFormat, float64(sampleRate))
+	if err != nil {
+		return fmt.Errorf("device %d (%s): %w", deviceIdx, dev.Name, err)
+	}
+	if err := stream.Open(framesPerBuffer); err != nil {
+		return fmt.Errorf("device %d (%s) cannot play %d Hz, %d channels, %d-bit: %w",
+			deviceIdx, dev.Name, sampleRate, channels, bitsPerSample, err)
+	}
+	if err := stream.Close(); err != nil {
+		return fmt.Errorf("device %d (%s): failed to close stream: %w", deviceIdx, dev.Name, err)
+	}
+	return nil
+}
+
+// selectDevice validates deviceIdx for the given format. If that fails and
+// fallback is set, it warns and tries the default output device instead,
+// e.g. when a USB DAC has been unplugged. Returns the device to play on.
+func selectDevice(deviceIdx, sampleRate, channels, bitsPerSample, framesPerBuffer int, fallback bool) (int, error) {
+	err := validateDevice(deviceIdx, sampleRate, channels, bitsPerSample, framesPerBuffer)
+	if err == nil || !fallback {
+		return deviceIdx, err
+	}
+
+	def, defErr := portaudio.DefaultOutputDevice()
+	if defErr != nil {
+		return deviceIdx, fmt.Errorf("%w; no default output device to fall back to: %v", err, defErr)
+	}
+	if def.Index == deviceIdx {
+		return deviceIdx, err
+	}
+
+	slog.Warn("Preferred device failed, falling back to default output device",
+		"device_index", deviceIdx,
+		"error", err,
+		"fallback_index", def.Index,
+		"fallback_name", def.Name)
+	if fbErr := validateDevice(def.Index, sampleRate, channels, bitsPerSample, framesPerBuffer); fbErr != nil {
+		return deviceIdx, fmt.Errorf("%w; fallback to default device %d failed: %v", err, def.Index, fbErr)
+	}
+	return def.Index, nil
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/drgolem/musictools/internal/selftest"
+
+	"github.com/drgolem/go-portaudio/portaudio"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that PortAudio and the decoders work",
+	Long: `Run a self-test of the audio stack and report pass/fail per component.
+
+Checks performed:
+  - PortAudio initializes; lists its version and output devices
+  - The default output device can be opened (16-bit stereo)
+  - Built-in WAV, FLAC and MP3 samples decode with the expected format
+
+Exits with a non-zero status if any check fails.
+
+Examples:
+  musictools doctor`,
+	Args: cobra.NoArgs,
+	Run:  runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) {
+	var results []selftest.Result
+	results = append(results, checkPortAudio()...)
+	results = append(results, selftest.CheckDecoders()...)
+
+	failed := 0
+	for _, res := range results {
+		if res.Passed() {
+			slog.Info("PASS", "check", res.Name, "detail", res.Detail)
+		} else {
+			slog.Error("FAIL", "check", res.Name, "error", res.Err)
+			failed++
+		}
+	}
+
+	slog.Info("Self-test complete", "checks", len(results), "failed", failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkPortAudio initializes PortAudio, lists output devices and tries to
+// open the default output device.
+func checkPortAudio() []selftest.Result {
+	initRes := selftest.Result{Name: "portaudio/init"}
+	if err := portaudio.Initialize(); err != nil {
+		initRes.Err = err
+		return []selftest.Result{initRes}
+	}
+	defer portaudio.Terminate()
+	initRes.Detail = portaudio.GetVersionText()
+
+	devRes := selftest.Result{Name: "portaudio/devices"}
+	devices, err := portaudio.Devices()
+	if err != nil {
+		devRes.Err = err
+		return []selftest.Result{initRes, devRes}
+	}
+	outputs := 0
+	for _, dev := range devices {
+		if dev.MaxOutputChannels > 0 {
+			slog.Info("Output device",
+				"index", dev.Index,
+				"name", dev.Name,
+				"max_channels", dev.MaxOutputChannels,
+				"default_sample_rate", dev.DefaultSampleRate)
+			outputs++
+		}
+	}
+	if outputs == 0 {
+		devRes.Err = fmt.Errorf("no output devices found")
+	} else {
+		devRes.Detail = fmt.Sprintf("%d output devices", outputs)
+	}
+
+	return []selftest.Result{initRes, devRes, checkDefaultDevice()}
+}
+
+// checkDefaultDevice opens and closes a 16-bit stereo stream on the default
+// output device at its default sample rate.
+func checkDefaultDevice() selftest.Result {
+	res := selftest.Result{Name: "portaudio/default-device"}
+
+	dev, err := portaudio.DefaultOutputDevice()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	stream, err := portaudio.NewOutputStream(dev.Index, 2, portaudio.SampleFmtInt16, dev.DefaultSampleRate)
+	if err != nil {
+		res.Err = fmt.Errorf("%s: %w", dev.Name, err)
+		return res
+	}
+	if err := stream.Open(512); err != nil {
+		res.Err = fmt.Errorf("%s: failed to open stream: %w", dev.Name, err)
+		return res
+	}
+	if err := stream.Close(); err != nil {
+		res.Err = fmt.Errorf("%s: failed to close stream: %w", dev.Name, err)
+		return res
+	}
+
+	res.Detail = fmt.Sprintf("%s (index %d, %.0f Hz)", dev.Name, dev.Index, dev.DefaultSampleRate)
+	return res
+}
@@ -1,17 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/drgolem/audiokit/pkg/audioplayer"
 	"github.com/drgolem/audiokit/pkg/types"
 	"github.com/drgolem/musictools/internal/decoders"
+	"github.com/drgolem/musictools/internal/metadata"
 
 	"github.com/drgolem/go-portaudio/portaudio"
 	"github.com/spf13/cobra"
@@ -24,6 +27,19 @@ var (
 	playlistPAFrames        int
 	playlistSamplesPerFrame int
 	playlistVerbose         bool
+	playlistStopOnError     bool
+	playlistShuffle         bool
+	playlistSeed            uint64
+	playlistRepeat          bool
+	playlistGapless         bool
+	playlistStartupSilence  time.Duration
+	playlistCue             string
+	playlistStatusInterval  time.Duration
+	playlistBufferSmoothing float64
+	playlistReplayGain      string
+
+	// playlistReplayGainMode is the parsed --replaygain.
+	playlistReplayGainMode metadata.ReplayGainMode
 )
 
 // playlistCmd represents the playlist command
@@ -36,6 +52,10 @@ This command plays a list of audio files sequentially, closing and reinitializin
 the audio stream between files. It uses the AudioFrameRingBuffer for efficient
 frame-based audio streaming with the SPSC (Single-Producer Single-Consumer) pattern.
 
+With --gapless, consecutive files that share a sample rate, channel count and
+bit depth are joined into one stream, and the stream is only reinitialized
+where the format changes.
+
 Examples:
   # Play multiple files
   musictools playlist song1.mp3 song2.flac song3.wav
@@ -49,12 +69,39 @@ Examples:
   # Adjust buffer parameters
   musictools playlist -c 512 -s 2048 *.wav
 
+  # Abort on the first file that fails to open or decode
+  musictools playlist --stop-on-error album/*.flac
+
+  # Play an album without gaps between tracks
+  musictools playlist --gapless album/*.flac
+
+  # Shuffle and loop until interrupted (reshuffled every pass)
+  musictools playlist --shuffle --repeat *.mp3
+
+  # Reproduce a shuffled order from an earlier run's logged seed
+  musictools playlist --shuffle --seed 42 *.mp3
+
+  # Play the tracks of a single-file album described by a cue sheet
+  musictools playlist --cue album.cue
+  musictools playlist --gapless --cue album.cue album.flac
+
+With --cue each track of the sheet is a playlist entry, played by seeking
+to its start in the album file. The audio file named in the sheet is used
+unless one is given as an argument.
+
+A summary of played and failed files is logged when the playlist ends.
+
 Supported Formats:
   MP3:  .mp3 (16-bit lossy)
   FLAC: .flac, .fla (16/24/32-bit lossless)
-  WAV:  .wav (8/16/24/32-bit PCM)`,
-	Args: cobra.MinimumNArgs(1),
-	Run:  runPlaylist,
+  WAV:  .wav (8/16/24/32-bit PCM, 32/64-bit float)`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if playlistCue != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	Run: runPlaylist,
 }
 
 func init() {
@@ -65,6 +112,16 @@ func init() {
 	playlistCmd.Flags().IntVarP(&playlistPAFrames, "paframes", "p", 512, "PortAudio frames per buffer")
 	playlistCmd.Flags().IntVarP(&playlistSamplesPerFrame, "samples", "s", 4096, "Samples per AudioFrame")
 	playlistCmd.Flags().BoolVarP(&playlistVerbose, "verbose", "v", false, "Verbose output (debug logging)")
+	playlistCmd.Flags().BoolVar(&playlistStopOnError, "stop-on-error", false, "Abort the playlist on the first file that fails")
+	playlistCmd.Flags().BoolVar(&playlistShuffle, "shuffle", false, "Play files in random order")
+	playlistCmd.Flags().Uint64Var(&playlistSeed, "seed", 0, "Random seed for --shuffle (default: random, logged for reproducing an order)")
+	playlistCmd.Flags().BoolVar(&playlistRepeat, "repeat", false, "Loop the playlist until interrupted")
+	playlistCmd.Flags().BoolVar(&playlistGapless, "gapless", false, "Keep the stream open between consecutive files with the same format")
+	playlistCmd.Flags().StringVar(&playlistCue, "cue", "", "Cue sheet whose tracks to play from a single album file")
+	playlistCmd.Flags().StringVar(&playlistReplayGain, "replaygain", "off", replayGainUsage)
+	playlistCmd.Flags().DurationVar(&playlistStatusInterval, "status-interval", defaultStatusInterval, "How often to log playback status (0 disables)")
+	playlistCmd.Flags().Float64Var(&playlistBufferSmoothing, "buffer-smoothing", defaultBufferSmoothing, bufferSmoothingUsage)
+	playlistCmd.Flags().DurationVar(&playlistStartupSilence, "startup-silence", 0, "Silence to play each time the stream starts, e.g. 200ms, for devices that glitch on start")
 }
 
 func runPlaylist(cmd *cobra.Command, args []string) {
@@ -77,7 +134,35 @@ func runPlaylist(cmd *cobra.Command, args []string) {
 	}))
 	slog.SetDefault(logger)
 
-	files := args
+	if err := validatePlayerFlags(playlistDeviceIdx, playlistBufferCapacity, playlistPAFrames, playlistSamplesPerFrame); err != nil {
+		slog.Error("Invalid player configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := validateBufferSmoothing(playlistBufferSmoothing); err != nil {
+		slog.Error("Invalid player configuration", "error", err)
+		os.Exit(1)
+	}
+
+	mode, err := metadata.ParseReplayGainMode(playlistReplayGain)
+	if err != nil {
+		slog.Error("Invalid ReplayGain mode", "error", err)
+		os.Exit(1)
+	}
+	playlistReplayGainMode = mode
+
+	entries := fileEntries(args)
+	if playlistCue != "" {
+		audioFile := ""
+		if len(args) == 1 {
+			audioFile = args[0]
+		}
+		cueEntries, err := loadCueSheet(playlistCue, audioFile)
+		if err != nil {
+			slog.Error("Failed to load cue sheet", "error", err)
+			os.Exit(1)
+		}
+		entries = cueEntries
+	}
 
 	slog.Info("Initializing PortAudio")
 	if err := portaudio.Initialize(); err != nil {
@@ -92,75 +177,291 @@ func runPlaylist(cmd *cobra.Command, args []string) {
 		"frame_capacity", playlistBufferCapacity,
 		"pa_frames_per_buffer", playlistPAFrames,
 		"samples_per_audioframe", playlistSamplesPerFrame,
-		"file_count", len(files))
+		"file_count", len(entries))
 
 	player := audioplayer.New(playlistDeviceIdx, playlistBufferCapacity, playlistPAFrames, playlistSamplesPerFrame)
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
 
-	interrupted := false
+	seed := playlistSeed
+	if playlistShuffle && !cmd.Flags().Changed("seed") {
+		seed = rand.Uint64()
+	}
+	if playlistShuffle {
+		slog.Info("Shuffling playlist", "seed", seed)
+	}
+	rng := rand.New(rand.NewPCG(seed, seed))
 
-	for i, fileName := range files {
-		if interrupted {
+	var results []playlistResult
+	scheduled := 0
+
+	for pass := 1; ; pass++ {
+		order := playlistOrder(entries, playlistShuffle, rng)
+		scheduled += len(order)
+
+		var passResults []playlistResult
+		var stop bool
+		if playlistGapless {
+			passResults, stop = playGaplessPass(ctx, player, order)
+		} else {
+			passResults, stop = playSequentialPass(ctx, player, order)
+		}
+		results = append(results, passResults...)
+
+		if stop || !playlistRepeat {
+			break
+		}
+		if countPlaylistResults(passResults)[playlistPlayed] == 0 {
+			slog.Warn("No file in the playlist played, not repeating")
 			break
 		}
+		slog.Info("Repeating playlist", "pass", pass+1)
+	}
 
-		slog.Info("Playing file", "index", i+1, "total", len(files), "file", fileName)
+	logPlaylistSummary(results, scheduled)
 
-		dec, err := decoders.NewDecoder(fileName)
-		if err != nil {
-			slog.Error("Failed to open file", "file", fileName, "error", err)
-			continue
-		}
+	slog.Info("Exiting")
+}
 
-		player.SetDecoder(dec, filepath.Base(fileName))
+// playlistOrder returns the entries in playback order for one pass. With
+// shuffle set the order is a permutation drawn from rng, so a fixed seed
+// gives the same sequence of orders. entries is not modified.
+func playlistOrder(entries []playlistEntry, shuffle bool, rng *rand.Rand) []playlistEntry {
+	order := slices.Clone(entries)
+	if shuffle {
+		rng.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+	}
+	return order
+}
+
+// playSequentialPass plays entries one at a time, reinitializing the stream
+// for each. stop reports that the playlist should not continue, because it
+// was interrupted or a file failed with --stop-on-error.
+func playSequentialPass(ctx context.Context, player *audioplayer.AudioPlayer, entries []playlistEntry) (results []playlistResult, stop bool) {
+	for i, entry := range entries {
+		slog.Info("Playing file", "index", i+1, "total", len(entries), "file", entry)
 
-		if err := player.Play(); err != nil {
-			slog.Error("Failed to start playback", "file", fileName, "error", err)
-			continue
+		res := playPlaylistFile(ctx, player, entry)
+		results = append(results, res)
+
+		if res.Status == playlistInterrupted {
+			return results, true
+		}
+		if playlistStopOnError && res.failed() {
+			slog.Error("Stopping playlist on error", "file", entry, "status", res.Status)
+			return results, true
 		}
+	}
+	return results, false
+}
 
-		statusDone := make(chan struct{})
-		go monitorPlayback(player, statusDone)
+// playlistStatus is the outcome of playing one playlist entry.
+type playlistStatus int
 
-		done := make(chan struct{})
-		go func() {
-			player.Wait()
-			close(done)
-		}()
+const (
+	playlistPlayed playlistStatus = iota
+	playlistOpenFailed
+	playlistStartFailed
+	playlistDecodeError
+	playlistInterrupted
+)
 
-		select {
-		case <-done:
-			slog.Info("File completed", "file", fileName)
-			close(statusDone)
-			if err := player.Stop(); err != nil {
-				slog.Error("Failed to stop player", "error", err)
-			}
-		case sig := <-sigChan:
-			slog.Info("Signal received, stopping", "signal", sig)
-			interrupted = true
-			close(statusDone)
-			if err := player.Stop(); err != nil {
-				slog.Error("Failed to stop player", "error", err)
-			}
+func (s playlistStatus) String() string {
+	switch s {
+	case playlistPlayed:
+		return "played"
+	case playlistOpenFailed:
+		return "failed-to-open"
+	case playlistStartFailed:
+		return "failed-to-start"
+	case playlistDecodeError:
+		return "decode-error"
+	case playlistInterrupted:
+		return "interrupted"
+	default:
+		return fmt.Sprintf("playlistStatus(%d)", int(s))
+	}
+}
+
+// playlistResult records what happened to one playlist entry.
+type playlistResult struct {
+	Entry  playlistEntry
+	Status playlistStatus
+	Err    error
+}
+
+// failed reports whether the entry did not play through. Interruption by
+// the user is not a failure.
+func (r playlistResult) failed() bool {
+	return r.Status != playlistPlayed && r.Status != playlistInterrupted
+}
+
+// playPlaylistFile plays one entry to completion on player, or until ctx is
+// cancelled, and reports the outcome.
+func playPlaylistFile(ctx context.Context, player *audioplayer.AudioPlayer, entry playlistEntry) playlistResult {
+	res := playlistResult{Entry: entry}
+
+	dec, err := entry.open()
+	if err != nil {
+		slog.Error("Failed to open file", "file", entry, "error", err)
+		res.Status, res.Err = playlistOpenFailed, err
+		return res
+	}
+
+	rate, channels, bps := dec.GetFormat()
+	if err := validateDevice(playlistDeviceIdx, rate, channels, bps, playlistPAFrames); err != nil {
+		slog.Error("Audio device check failed", "file", entry, "error", err)
+		dec.Close()
+		res.Status, res.Err = playlistStartFailed, err
+		return res
+	}
+
+	totalSamples, _ := decoders.TotalSamples(dec)
+	dec = applyReplayGain(dec, entry.file, playlistReplayGainMode)
+	tracker := decoders.NewErrorTracker(dec)
+	playDec, err := applyStartupSilence(tracker, playlistStartupSilence)
+	if err != nil {
+		slog.Error("Invalid playback options", "file", entry, "error", err)
+		tracker.Close()
+		res.Status, res.Err = playlistStartFailed, err
+		return res
+	}
+	player.SetDecoder(playDec, entry.name())
+
+	if err := player.Play(); err != nil {
+		slog.Error("Failed to start playback", "file", entry, "error", err)
+		// Stop closes playDec and drops it from the player, which would
+		// otherwise close it again when the next file is set.
+		if err := player.Stop(); err != nil {
+			slog.Error("Failed to stop player", "error", err)
 		}
+		res.Status, res.Err = playlistStartFailed, err
+		return res
 	}
 
-	if interrupted {
-		slog.Info("Playback interrupted")
+	statusDone := make(chan struct{})
+	go monitorPlayback(player, playlistStatusInterval, playlistBufferSmoothing, totalSamples, statusDone)
+
+	if err := waitPlayback(ctx, player); err != nil {
+		slog.Info("Signal received, stopping")
+		res.Status = playlistInterrupted
+	} else if err := tracker.Err(); err != nil {
+		slog.Warn("File ended early, may be truncated or corrupt",
+			"file", entry,
+			"decoded_samples", tracker.DecodedSamples(),
+			"error", err)
+		res.Status, res.Err = playlistDecodeError, err
 	} else {
-		slog.Info("All files completed", "total", len(files))
+		slog.Info("File completed", "file", entry)
 	}
 
-	slog.Info("Exiting")
+	close(statusDone)
+	if err := player.Stop(); err != nil {
+		slog.Error("Failed to stop player", "error", err)
+	}
+
+	return res
+}
+
+// countPlaylistResults tallies results by status.
+func countPlaylistResults(results []playlistResult) map[playlistStatus]int {
+	counts := make(map[playlistStatus]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	return counts
+}
+
+// logPlaylistSummary logs the totals for the playlist followed by one line
+// per file that failed.
+func logPlaylistSummary(results []playlistResult, total int) {
+	counts := countPlaylistResults(results)
+
+	if counts[playlistInterrupted] > 0 {
+		slog.Info("Playback interrupted")
+	}
+	slog.Info("Playlist summary",
+		"total", total,
+		"played", counts[playlistPlayed],
+		"failed_to_open", counts[playlistOpenFailed],
+		"failed_to_start", counts[playlistStartFailed],
+		"decode_errors", counts[playlistDecodeError],
+		"not_played", total-len(results)+counts[playlistInterrupted])
+
+	for _, r := range results {
+		if r.failed() {
+			slog.Warn("  Failed", "file", r.Entry, "status", r.Status, "error", r.Err)
+		}
+	}
+}
+
+// playbackWaiter is the blocking half of audioplayer.AudioPlayer.
+type playbackWaiter interface {
+	Wait()
+}
+
+// waitPlayback blocks until p finishes playing or ctx is done, whichever comes
+// first. It returns ctx.Err() if the context fired before playback completed;
+// the caller should then Stop the player, which also releases the goroutine
+// blocked in p.Wait.
+func waitPlayback(ctx context.Context, p playbackWaiter) error {
+	done := make(chan struct{})
+	go func() {
+		p.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// monitorPlayback monitors and logs playback status every 2 seconds
-func monitorPlayback(monitor types.PlaybackMonitor, done chan struct{}) {
-	ticker := time.NewTicker(2 * time.Second)
+// defaultBufferSmoothing is the EMA weight given to the newest buffered-time
+// sample in the status log unless overridden with --buffer-smoothing (higher
+// reacts faster, lower is smoother).
+const defaultBufferSmoothing = 0.3
+
+// ema is an exponential moving average. The first update seeds the value.
+type ema struct {
+	alpha  float64
+	value  float64
+	seeded bool
+}
+
+// update folds x into the average and returns the new smoothed value.
+func (e *ema) update(x float64) float64 {
+	if !e.seeded {
+		e.value = x
+		e.seeded = true
+	} else {
+		e.value += e.alpha * (x - e.value)
+	}
+	return e.value
+}
+
+// defaultStatusInterval is how often playback status is logged unless
+// overridden with --status-interval.
+const defaultStatusInterval = 2 * time.Second
+
+// monitorPlayback logs playback status every interval until done is closed.
+// A zero or negative interval disables the log. smoothing is the EMA weight
+// for the averaged buffered time. A positive totalSamples, the length of the
+// stream, adds the progress through it.
+func monitorPlayback(monitor types.PlaybackMonitor, interval time.Duration, smoothing float64, totalSamples int64, done chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	bufferedAvg := ema{alpha: smoothing}
+
 	for {
 		select {
 		case <-ticker.C:
@@ -184,6 +485,7 @@ func monitorPlayback(monitor types.PlaybackMonitor, done chan struct{}) {
 			playedTimeStr := fmt.Sprintf("%02d:%02d:%02d.%03d", playedHours, playedMinutes, playedSeconds, playedMsec)
 
 			bufferedTimeStr := fmt.Sprintf("%.3fs", bufferedTimeSeconds)
+			bufferedAvgStr := fmt.Sprintf("%.3fs", bufferedAvg.update(bufferedTimeSeconds))
 
 			formatStr := fmt.Sprintf("%d:%d:%d",
 				status.SampleRate, status.BitsPerSample, status.Channels)
@@ -191,13 +493,20 @@ func monitorPlayback(monitor types.PlaybackMonitor, done chan struct{}) {
 			portAudioStr := fmt.Sprintf("%dHz:%dbit:%dch:%dframes",
 				status.SampleRate, status.BitsPerSample, status.Channels, status.FramesPerBuffer)
 
-			slog.Info("Playback status",
+			attrs := []any{
 				"file", status.FileName,
 				"format", formatStr,
 				"portaudio", portAudioStr,
 				"played", playedTimeStr,
 				"buffered", bufferedTimeStr,
-				"elapsed", elapsedStr)
+				"buffered_avg", bufferedAvgStr,
+				"elapsed", elapsedStr,
+			}
+			if totalSamples > 0 {
+				progress := min(100, 100*float64(status.PlayedSamples)/float64(totalSamples))
+				attrs = append(attrs, "progress", fmt.Sprintf("%.1f%%", progress))
+			}
+			slog.Info("Playback status", attrs...)
 		case <-done:
 			return
 		}
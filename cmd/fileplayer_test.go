@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestPlaylistOrder(t *testing.T) {
+	files := []string{"a.flac", "b.flac", "c.flac", "d.flac", "e.flac", "f.flac"}
+	entries := fileEntries(files)
+
+	t.Run("in order", func(t *testing.T) {
+		rng := rand.New(rand.NewPCG(1, 1))
+		for pass := range 3 {
+			order := playlistOrder(entries, false, rng)
+			if !slices.Equal(order, entries) {
+				t.Fatalf("pass %d order = %v, want %v", pass, order, entries)
+			}
+		}
+		order := playlistOrder(entries, false, rng)
+		order[0] = playlistEntry{file: "changed"}
+		if entries[0].file != "a.flac" {
+			t.Fatal("playlistOrder returned the caller's slice")
+		}
+	})
+
+	t.Run("shuffled", func(t *testing.T) {
+		// The same seed gives the same passes, each a permutation of the
+		// playlist, reshuffled from one pass to the next.
+		const seed = 42
+		rng1 := rand.New(rand.NewPCG(seed, seed))
+		rng2 := rand.New(rand.NewPCG(seed, seed))
+		var passes [][]playlistEntry
+		for pass := range 4 {
+			order := playlistOrder(entries, true, rng1)
+			if again := playlistOrder(entries, true, rng2); !slices.Equal(order, again) {
+				t.Fatalf("pass %d: %v and %v from the same seed", pass, order, again)
+			}
+			var sorted []string
+			for _, e := range order {
+				sorted = append(sorted, e.file)
+			}
+			slices.Sort(sorted)
+			if !slices.Equal(sorted, files) {
+				t.Fatalf("pass %d order %v is not a permutation of %v", pass, order, files)
+			}
+			passes = append(passes, order)
+		}
+		if slices.EqualFunc(passes[1:], passes[:len(passes)-1], slices.Equal) {
+			t.Errorf("every pass has the order %v, want a new shuffle per pass", passes[0])
+		}
+	})
+}
+
+func TestCountPlaylistResults(t *testing.T) {
+	errFail := errors.New("failed")
+	results := []playlistResult{
+		{Entry: playlistEntry{file: "a.flac"}, Status: playlistPlayed},
+		{Entry: playlistEntry{file: "b.flac"}, Status: playlistOpenFailed, Err: errFail},
+		{Entry: playlistEntry{file: "c.flac"}, Status: playlistPlayed},
+		{Entry: playlistEntry{file: "d.flac"}, Status: playlistDecodeError, Err: errFail},
+		{Entry: playlistEntry{file: "e.flac"}, Status: playlistStartFailed, Err: errFail},
+		{Entry: playlistEntry{file: "f.flac"}, Status: playlistDecodeError, Err: errFail},
+		{Entry: playlistEntry{file: "g.flac"}, Status: playlistInterrupted},
+	}
+	want := map[playlistStatus]int{
+		playlistPlayed:      2,
+		playlistOpenFailed:  1,
+		playlistStartFailed: 1,
+		playlistDecodeError: 2,
+		playlistInterrupted: 1,
+	}
+	if got := countPlaylistResults(results); !maps.Equal(got, want) {
+		t.Errorf("countPlaylistResults = %v, want %v", got, want)
+	}
+	if got := countPlaylistResults(nil); len(got) != 0 {
+		t.Errorf("countPlaylistResults(nil) = %v, want empty", got)
+	}
+
+	var failed []string
+	for _, r := range results {
+		if r.failed() {
+			failed = append(failed, r.Entry.file)
+		}
+	}
+	if want := []string{"b.flac", "d.flac", "e.flac", "f.flac"}; !slices.Equal(failed, want) {
+		t.Errorf("failed entries = %v, want %v", failed, want)
+	}
+}
+
+func TestEMA(t *testing.T) {
+	tests := []struct {
+		alpha float64
+		in    []float64
+		want  []float64
+	}{
+		// The first sample seeds the average; each later one moves it
+		// alpha of the way towards the sample.
+		{0.5, []float64{1, 3, 3, 7}, []float64{1, 2, 2.5, 4.75}},
+		{0.25, []float64{8, 0, 0, 4}, []float64{8, 6, 4.5, 4.375}},
+		{1, []float64{2, 9, 5}, []float64{2, 9, 5}},
+	}
+	for _, tt := range tests {
+		e := ema{alpha: tt.alpha}
+		for i, x := range tt.in {
+			if got := e.update(x); got != tt.want[i] {
+				t.Errorf("alpha %g: update %d (%g) = %g, want %g", tt.alpha, i, x, got, tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/drgolem/audiokit/pkg/audioplayer"
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/decoders"
+)
+
+// gaplessEntry is a playlist entry opened for a gapless group.
+type gaplessEntry struct {
+	entry   playlistEntry
+	index   int // 1-based position in the playlist
+	tracker *decoders.ErrorTracker
+}
+
+// gaplessPass walks a playlist for the SequenceDecoder of the group
+// currently playing. The file after the one playing is opened in the
+// background, so switching to it doesn't wait on file I/O. Its state is
+// shared with the player's decoding goroutine and guarded by mu.
+type gaplessPass struct {
+	entries []playlistEntry
+
+	mu      sync.Mutex
+	pos     int
+	group   []gaplessEntry
+	results []playlistResult
+	stop    bool
+	ahead   chan *gaplessEntry // the file being opened ahead, nil if none
+}
+
+// open opens the next playable file, recording files that fail to open.
+// Returns nil when the playlist is exhausted or must stop.
+func (g *gaplessPass) open() *gaplessEntry {
+	for !g.stop && g.pos < len(g.entries) {
+		entry := g.entries[g.pos]
+		g.pos++
+
+		dec, err := entry.open()
+		if err != nil {
+			slog.Error("Failed to open file", "file", entry, "error", err)
+			g.results = append(g.results, playlistResult{Entry: entry, Status: playlistOpenFailed, Err: err})
+			if playlistStopOnError {
+				slog.Error("Stopping playlist on error", "file", entry, "status", playlistOpenFailed)
+				g.stop = true
+			}
+			continue
+		}
+
+		dec = applyReplayGain(dec, entry.file, playlistReplayGainMode)
+		return &gaplessEntry{entry: entry, index: g.pos, tracker: decoders.NewErrorTracker(dec)}
+	}
+	return nil
+}
+
+// openAhead starts opening the next playable file in the background, unless
+// that is already under way. g.mu must be held.
+func (g *gaplessPass) openAhead() {
+	if g.ahead != nil {
+		return
+	}
+	ahead := make(chan *gaplessEntry, 1)
+	g.ahead = ahead
+	go func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		ahead <- g.open()
+	}()
+}
+
+// takeAhead waits for the file opened by openAhead, or nil if there is none.
+// g.mu must not be held.
+func (g *gaplessPass) takeAhead() *gaplessEntry {
+	g.mu.Lock()
+	ahead := g.ahead
+	g.ahead = nil
+	g.mu.Unlock()
+	if ahead == nil {
+		return nil
+	}
+	return <-ahead
+}
+
+// discardAhead closes the file opened ahead when the pass ends before it
+// plays.
+func (g *gaplessPass) discardAhead() {
+	if e := g.takeAhead(); e != nil {
+		e.tracker.Close()
+	}
+}
+
+// next is the SequenceDecoder source for the current group. It returns the
+// file opened ahead and starts opening the one after it.
+func (g *gaplessPass) next() decoder.AudioDecoder {
+	g.mu.Lock()
+	if n := len(g.group); n > 0 && playlistStopOnError {
+		if err := g.group[n-1].tracker.Err(); err != nil {
+			slog.Error("Stopping playlist on error", "file", g.group[n-1].entry, "status", playlistDecodeError)
+			g.stop = true
+			g.mu.Unlock()
+			g.discardAhead()
+			return nil
+		}
+	}
+	g.mu.Unlock()
+
+	e := g.takeAhead()
+	if e == nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.group = append(g.group, *e)
+	g.openAhead()
+	slog.Info("Queued file", "index", e.index, "total", len(g.entries), "file", e.entry)
+	return e.tracker
+}
+
+// finishGroup records the results of the files played in the current group.
+// The last file is recorded as interrupted if playback was interrupted.
+func (g *gaplessPass) finishGroup(interrupted bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, e := range g.group {
+		res := playlistResult{Entry: e.entry}
+		switch {
+		case interrupted && i == len(g.group)-1:
+			res.Status = playlistInterrupted
+		case e.tracker.Err() != nil:
+			res.Status, res.Err = playlistDecodeError, e.tracker.Err()
+		}
+		g.results = append(g.results, res)
+	}
+	g.group = nil
+}
+
+// playGaplessPass plays entries with consecutive same-format entries joined
+// into one stream. The stream is stopped and reinitialized only where the
+// format changes. stop has the same meaning as for playSequentialPass.
+func playGaplessPass(ctx context.Context, player *audioplayer.AudioPlayer, entries []playlistEntry) (results []playlistResult, stop bool) {
+	g := &gaplessPass{entries: entries}
+
+	var carried *gaplessEntry
+	for {
+		first := carried
+		carried = nil
+		if first == nil {
+			g.mu.Lock()
+			stop := g.stop
+			if !stop {
+				g.openAhead()
+			}
+			g.mu.Unlock()
+			if stop {
+				break
+			}
+			first = g.takeAhead()
+		}
+		if first == nil {
+			break
+		}
+
+		g.mu.Lock()
+		g.group = []gaplessEntry{*first}
+		g.openAhead()
+		g.mu.Unlock()
+
+		seq := decoders.NewSequenceDecoder(first.tracker, g.next)
+		rate, channels, bps := seq.GetFormat()
+		slog.Info("Starting stream",
+			"file", first.entry,
+			"sample_rate", rate,
+			"channels", channels,
+			"bits_per_sample", bps)
+
+		if err := startGaplessStream(player, seq, first.entry); err != nil {
+			slog.Error("Failed to start playback", "file", first.entry, "error", err)
+			seq.Close()
+			g.mu.Lock()
+			g.group = nil
+			g.results = append(g.results, playlistResult{Entry: first.entry, Status: playlistStartFailed, Err: err})
+			g.stop = g.stop || playlistStopOnError
+			g.mu.Unlock()
+			continue
+		}
+
+		statusDone := make(chan struct{})
+		go monitorPlayback(player, playlistStatusInterval, playlistBufferSmoothing, 0, statusDone)
+
+		interrupted := waitPlayback(ctx, player) != nil
+		if interrupted {
+			slog.Info("Signal received, stopping")
+		}
+
+		close(statusDone)
+		if err := player.Stop(); err != nil {
+			slog.Error("Failed to stop player", "error", err)
+		}
+
+		// The decoder that ended the group was opened but not played; it
+		// starts the next group.
+		if pending := seq.Pending(); pending != nil {
+			g.mu.Lock()
+			last := g.group[len(g.group)-1]
+			g.group = g.group[:len(g.group)-1]
+			g.mu.Unlock()
+
+			if interrupted {
+				pending.Close()
+			} else {
+				pendRate, pendChannels, pendBPS := pending.GetFormat()
+				slog.Info("Format change, reinitializing stream",
+					"file", last.entry,
+					"sample_rate", pendRate,
+					"channels", pendChannels,
+					"bits_per_sample", pendBPS)
+				carried = &last
+			}
+		}
+
+		g.finishGroup(interrupted)
+		if interrupted {
+			g.discardAhead()
+			return g.results, true
+		}
+	}
+
+	g.discardAhead()
+	return g.results, g.stop
+}
+
+// startGaplessStream checks the device can play the sequence's format and
+// starts playing it.
+func startGaplessStream(player *audioplayer.AudioPlayer, seq *decoders.SequenceDecoder, entry playlistEntry) error {
+	rate, channels, bps := seq.GetFormat()
+	if err := validateDevice(playlistDeviceIdx, rate, channels, bps, playlistPAFrames); err != nil {
+		return err
+	}
+
+	playDec, err := applyStartupSilence(seq, playlistStartupSilence)
+	if err != nil {
+		return err
+	}
+	player.SetDecoder(playDec, entry.name())
+	return player.Play()
+}
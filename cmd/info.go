@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/drgolem/musictools/internal/decoders"
+
+	"github.com/spf13/cobra"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info <audio_file> [audio_file...]",
+	Short: "Show the format, duration and tags of audio files",
+	Long: `Print each file's format, duration and tags without playing it.
+
+Tags are read from WAV (LIST/INFO), FLAC (Vorbis comments) and MP3 (ID3v2)
+files. The duration is shown for formats that store their length (WAV, AIFF,
+FLAC, MP3).
+
+Examples:
+  musictools info song.flac
+  musictools info music/*.mp3`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runInfo,
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+}
+
+func runInfo(cmd *cobra.Command, args []string) {
+	failed := 0
+	for _, fileName := range args {
+		if err := printInfo(fileName); err != nil {
+			slog.Error("Failed to read file", "file", fileName, "error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printInfo logs the format, duration and tags of fileName.
+func printInfo(fileName string) error {
+	dec, err := decoders.NewDecoder(fileName)
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+
+	rate, channels, bps := dec.GetFormat()
+	attrs := []any{
+		"file", fileName,
+		"sample_rate", rate,
+		"channels", channels,
+		"bits_per_sample", bps,
+	}
+	if d, err := decoders.Duration(dec); err == nil {
+		attrs = append(attrs, "duration", d.Round(time.Millisecond))
+	} else {
+		attrs = append(attrs, "duration", "unknown")
+	}
+	slog.Info("File", attrs...)
+
+	tags, err := decoders.Metadata(dec)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		slog.Info("No tags", "file", fileName)
+		return nil
+	}
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		slog.Info("Tag", "key", key, "value", tags[key])
+	}
+	return nil
+}
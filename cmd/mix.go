@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/audioplayer"
+	"github.com/drgolem/musictools/internal/decoders"
+
+	"github.com/drgolem/go-portaudio/portaudio"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mixDeviceIdx       int
+	mixBufferCapacity  uint64
+	mixPAFrames        int
+	mixSamplesPerFrame int
+	mixVerbose         bool
+	mixVolumes         []float64
+	mixStatusInterval  time.Duration
+	mixBufferSmoothing float64
+)
+
+var mixCmd = &cobra.Command{
+	Use:   "mix <audio_file> <audio_file> [audio_file...]",
+	Short: "Play several audio files at once, mixed together",
+	Long: `Play several audio files simultaneously by summing their samples, e.g. a
+backing track with a voice-over. Playback ends when the longest file ends.
+
+All files must have the same sample rate, channel count and bit depth; use
+"transform" to convert them first if they differ.
+
+Examples:
+  # Mix two files at full volume
+  musictools mix backing.wav vocals.wav
+
+  # Lower the backing track to half volume
+  musictools mix --volumes 0.5,1.0 backing.flac vocals.flac`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  runMix,
+}
+
+func init() {
+	rootCmd.AddCommand(mixCmd)
+
+	mixCmd.Flags().IntVarP(&mixDeviceIdx, "device", "d", 1, "Audio output device index")
+	mixCmd.Flags().Uint64VarP(&mixBufferCapacity, "capacity", "c", 256, "Ringbuffer capacity (number of frames)")
+	mixCmd.Flags().IntVarP(&mixPAFrames, "paframes", "p", 512, "PortAudio frames per buffer")
+	mixCmd.Flags().IntVarP(&mixSamplesPerFrame, "samples", "s", 4096, "Samples per AudioFrame")
+	mixCmd.Flags().BoolVarP(&mixVerbose, "verbose", "v", false, "Verbose output (debug logging)")
+	mixCmd.Flags().DurationVar(&mixStatusInterval, "status-interval", defaultStatusInterval, "How often to log playback status (0 disables)")
+	mixCmd.Flags().Float64Var(&mixBufferSmoothing, "buffer-smoothing", defaultBufferSmoothing, bufferSmoothingUsage)
+	mixCmd.Flags().Float64SliceVar(&mixVolumes, "volumes", nil, "Per-file linear volumes, e.g. 0.5,1.0 (default: 1.0 each)")
+}
+
+func runMix(cmd *cobra.Command, args []string) {
+	logLevel := slog.LevelInfo
+	if mixVerbose {
+		logLevel = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: logLevel,
+	}))
+	slog.SetDefault(logger)
+
+	if err := validatePlayerFlags(mixDeviceIdx, mixBufferCapacity, mixPAFrames, mixSamplesPerFrame); err != nil {
+		slog.Error("Invalid player configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := validateBufferSmoothing(mixBufferSmoothing); err != nil {
+		slog.Error("Invalid player configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if len(mixVolumes) > 0 && len(mixVolumes) != len(args) {
+		slog.Error("Number of volumes must match number of files", "volumes", len(mixVolumes), "files", len(args))
+		os.Exit(1)
+	}
+
+	mixer, err := openMix(args)
+	if err != nil {
+		slog.Error("Failed to set up mix", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Initializing PortAudio")
+	if err := portaudio.Initialize(); err != nil {
+		slog.Error("Failed to initialize PortAudio", "error", err)
+		mixer.Close()
+		os.Exit(1)
+	}
+	defer portaudio.Terminate()
+
+	rate, channels, bps := mixer.GetFormat()
+	if err := validateDevice(mixDeviceIdx, rate, channels, bps, mixPAFrames); err != nil {
+		slog.Error("Audio device check failed", "error", err)
+		mixer.Close()
+		os.Exit(1)
+	}
+
+	player := audioplayer.New(mixDeviceIdx, mixBufferCapacity, mixPAFrames, mixSamplesPerFrame)
+	player.SetDecoder(mixer, "mix")
+
+	if err := player.Play(); err != nil {
+		slog.Error("Failed to start playback", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	statusDone := make(chan struct{})
+	go monitorPlayback(player, mixStatusInterval, mixBufferSmoothing, 0, statusDone)
+
+	if err := waitPlayback(ctx, player); err != nil {
+		slog.Info("Signal received, stopping")
+	} else {
+		slog.Info("Playback completed")
+	}
+
+	close(statusDone)
+	if err := player.Stop(); err != nil {
+		slog.Error("Failed to stop player", "error", err)
+	}
+
+	slog.Info("Exiting")
+}
+
+// openMix opens every file and adds it to a mixer with the format of the
+// first file.
+func openMix(files []string) (*decoders.MixingDecoder, error) {
+	var mixer *decoders.MixingDecoder
+	for i, fileName := range files {
+		dec, err := decoders.NewDecoder(fileName)
+		if err != nil {
+			if mixer != nil {
+				mixer.Close()
+			}
+			return nil, err
+		}
+
+		if mixer == nil {
+			rate, channels, bps := dec.GetFormat()
+			mixer, err = decoders.NewMixingDecoder(rate, channels, bps)
+			if err != nil {
+				dec.Close()
+				return nil, err
+			}
+		}
+
+		volume := 1.0
+		if len(mixVolumes) > 0 {
+			volume = mixVolumes[i]
+		}
+		if _, err := mixer.AddSource(dec, volume); err != nil {
+			dec.Close()
+			mixer.Close()
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+
+		rate, channels, bps := dec.GetFormat()
+		slog.Info("Added source",
+			"file", fileName,
+			"volume", volume,
+			"sample_rate", rate,
+			"channels", channels,
+			"bits_per_sample", bps)
+	}
+	return mixer, nil
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
@@ -8,10 +9,13 @@ import (
 	"os/signal"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/drgolem/audiokit/pkg/audioplayer"
 	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/audioproc"
 	"github.com/drgolem/musictools/internal/decoders"
+	"github.com/drgolem/musictools/internal/metadata"
 
 	"github.com/drgolem/go-portaudio/portaudio"
 	"github.com/spf13/cobra"
@@ -23,6 +27,20 @@ var (
 	playPAFrames        int
 	playSamplesPerFrame int
 	playVerbose         bool
+	playBalance         float64
+	playChannelGains    []float64
+	playHostAPI         string
+	playSampleRate      int
+	playStartupSilence  time.Duration
+	playGainDB          float64
+	playFallback        bool
+	playMono            bool
+	playStatusInterval  time.Duration
+	playBufferSmoothing float64
+	playRawFormat       string
+	playBits            int
+	playReplayGain      string
+	playLoop            bool
 )
 
 // playerCmd represents the play command
@@ -40,18 +58,62 @@ Examples:
   # Play a FLAC file with specific device
   musictools play -d 0 music.flac
 
+  # Use the default device of a host API, or its second device
+  musictools play --host-api pulse music.flac
+  musictools play --host-api ALSA -d 1 music.flac
+
   # Play from stdin (piped WAV)
   musiclab doremi --score scores/greensleeves.csv --stdout | musictools play -
 
+  # Play a file streamed from another machine by 'musictools serve'
+  musictools play tcp://server:7878
+
+  # Play headerless PCM (16-bit stereo at 44.1 kHz)
+  musictools play --raw-format 44100:2:16 capture.raw
+
   # Adjust buffer parameters
   musictools play -c 512 -s 2048 music.wav
 
+  # Play a WAV whose header has the wrong sample rate
+  musictools play --samplerate 48000 mislabeled.wav
+
+  # Shift stereo balance to the right, or trim channels individually
+  musictools play --balance 0.3 music.flac
+  musictools play --gains 1.0,0.5 music.flac
+
+  # Downmix to mono for a single speaker
+  musictools play --mono music.flac
+
+  # Open the device at 24 bits whatever the file's depth
+  musictools play --bits 24 music.mp3
+
+  # Level the file with its ReplayGain album tags
+  musictools play --replaygain album music.flac
+
+  # Play 6 dB quieter
+  musictools play --gain -6 music.flac
+
+  # Use the default output device if device 3 (e.g. a USB DAC) is gone
+  musictools play -d 3 --fallback music.flac
+
+  # Play 200ms of silence first for a device that clicks on start
+  musictools play --startup-silence 200ms music.flac
+
+  # Loop a file until interrupted
+  musictools play --loop music.wav
+
+Sending SIGUSR1 pauses decoding (e.g. to stop pulling a network stream)
+while the buffered audio keeps playing; SIGUSR2 resumes it:
+  kill -USR1 <pid>
+  kill -USR2 <pid>
+
 Supported Formats:
   MP3:    .mp3 (16-bit lossy)
   FLAC:   .flac, .fla (16/24/32-bit lossless)
-  WAV:    .wav (8/16/24/32-bit PCM)
+  WAV:    .wav (8/16/24/32-bit PCM, 32/64-bit float)
   OGG:    .ogg, .oga (Vorbis)
-  Opus:   .opus`,
+  Opus:   .opus
+  AIFF:   .aiff, .aif, .aifc (8/16/24/32-bit PCM)`,
 	Args: cobra.ExactArgs(1),
 	Run:  runPlayer,
 }
@@ -64,6 +126,20 @@ func init() {
 	playerCmd.Flags().IntVarP(&playPAFrames, "paframes", "p", 512, "PortAudio frames per buffer")
 	playerCmd.Flags().IntVarP(&playSamplesPerFrame, "samples", "s", 4096, "Samples per AudioFrame")
 	playerCmd.Flags().BoolVarP(&playVerbose, "verbose", "v", false, "Verbose output (debug logging)")
+	playerCmd.Flags().StringVar(&playHostAPI, "host-api", "", "Host API name or index (see 'devices'); -d then selects a device within it")
+	playerCmd.Flags().StringVar(&playRawFormat, "raw-format", "", rawFormatUsage)
+	playerCmd.Flags().IntVar(&playBits, "bits", 0, "Output bit depth (8, 16, 24 or 32), converting if the decoder can't produce it (0 = file's depth)")
+	playerCmd.Flags().IntVar(&playSampleRate, "samplerate", 0, "Override the sample rate reported by the file header (0 = use header)")
+	playerCmd.Flags().Float64Var(&playBalance, "balance", 0, "Stereo balance from -1 (left) to 1 (right)")
+	playerCmd.Flags().Float64SliceVar(&playChannelGains, "gains", nil, "Per-channel linear gains, e.g. 1.0,0.5")
+	playerCmd.Flags().BoolVar(&playMono, "mono", false, "Downmix to a single channel and open the device in mono")
+	playerCmd.Flags().StringVar(&playReplayGain, "replaygain", "off", replayGainUsage)
+	playerCmd.Flags().Float64Var(&playGainDB, "gain", 0, "Overall gain in dB, e.g. -6 or 3.5 (clipped samples are clamped)")
+	playerCmd.Flags().BoolVar(&playFallback, "fallback", false, "Fall back to the default output device if the selected one fails to open")
+	playerCmd.Flags().DurationVar(&playStatusInterval, "status-interval", defaultStatusInterval, "How often to log playback status (0 disables)")
+	playerCmd.Flags().Float64Var(&playBufferSmoothing, "buffer-smoothing", defaultBufferSmoothing, bufferSmoothingUsage)
+	playerCmd.Flags().BoolVar(&playLoop, "loop", false, "Play the file over and over until interrupted")
+	playerCmd.Flags().DurationVar(&playStartupSilence, "startup-silence", 0, "Silence to play before the audio, e.g. 200ms, for devices that glitch on start")
 }
 
 func runPlayer(cmd *cobra.Command, args []string) {
@@ -76,10 +152,30 @@ func runPlayer(cmd *cobra.Command, args []string) {
 	}))
 	slog.SetDefault(logger)
 
+	if err := validatePlayerFlags(playDeviceIdx, playBufferCapacity, playPAFrames, playSamplesPerFrame); err != nil {
+		slog.Error("Invalid player configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := validateBufferSmoothing(playBufferSmoothing); err != nil {
+		slog.Error("Invalid player configuration", "error", err)
+		os.Exit(1)
+	}
+
+	replayGainMode, err := metadata.ParseReplayGainMode(playReplayGain)
+	if err != nil {
+		slog.Error("Invalid ReplayGain mode", "error", err)
+		os.Exit(1)
+	}
+
 	fileName := args[0]
+	if playLoop && (fileName == "-" || isRemoteInput(fileName)) {
+		slog.Error("--loop needs a file to rewind", "input", fileName)
+		os.Exit(1)
+	}
 
-	// Support reading from stdin via "-"
-	if fileName == "-" {
+	// Support reading from stdin via "-". WAV is decoded as it arrives; raw
+	// PCM is buffered to a temp file first.
+	if fileName == "-" && playRawFormat != "" {
 		tmpFile, err := os.CreateTemp("", "musictools-stdin-*.wav")
 		if err != nil {
 			slog.Error("Failed to create temp file for stdin", "error", err)
@@ -95,7 +191,7 @@ func runPlayer(cmd *cobra.Command, args []string) {
 		tmpFile.Close()
 		fileName = tmpFile.Name()
 		slog.Info("Buffered stdin to temp file", "path", fileName)
-	} else if _, err := os.Stat(fileName); os.IsNotExist(err) {
+	} else if _, err := os.Stat(fileName); os.IsNotExist(err) && fileName != "-" && !isRemoteInput(fileName) {
 		slog.Error("File not found", "path", fileName)
 		os.Exit(1)
 	}
@@ -108,14 +204,28 @@ func runPlayer(cmd *cobra.Command, args []string) {
 	defer portaudio.Terminate()
 
 	slog.Info("PortAudio initialized", "version", portaudio.GetVersion())
+
+	deviceIdx := playDeviceIdx
+	if playHostAPI != "" {
+		apiDeviceIdx := -1
+		if cmd.Flags().Changed("device") {
+			apiDeviceIdx = playDeviceIdx
+		}
+		idx, err := resolveHostAPIDevice(playHostAPI, apiDeviceIdx)
+		if err != nil {
+			slog.Error("Failed to select host API device", "error", err)
+			os.Exit(1)
+		}
+		deviceIdx = idx
+	}
+
 	slog.Info("Configuration",
-		"device_index", playDeviceIdx,
+		"device_index", deviceIdx,
+		"host_api", playHostAPI,
 		"frame_capacity", playBufferCapacity,
 		"pa_frames_per_buffer", playPAFrames,
 		"samples_per_audioframe", playSamplesPerFrame)
 
-	player := audioplayer.New(playDeviceIdx, playBufferCapacity, playPAFrames, playSamplesPerFrame)
-
 	slog.Info("Opening audio file", "path", fileName)
 	dec, err := safeNewDecoder(fileName)
 	if err != nil {
@@ -123,33 +233,70 @@ func runPlayer(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	player.SetDecoder(dec, filepath.Base(fileName))
+	// Read before the play options wrap dec, which hides the length.
+	totalSamples, err := decoders.TotalSamples(dec)
+	if err == nil {
+		duration, _ := decoders.Duration(dec)
+		slog.Info("File length", "samples", totalSamples, "duration", duration)
+	}
+
+	var loop *decoders.LoopDecoder
+	if playLoop {
+		// Progress through one pass means nothing once it repeats.
+		totalSamples = 0
+		loop = decoders.NewLoopDecoder(dec, fileName)
+		dec = loop
+	}
+
+	playDec, err := applyPlayOptions(applyReplayGain(dec, fileName, replayGainMode))
+	if err != nil {
+		slog.Error("Invalid playback options", "error", err)
+		dec.Close()
+		os.Exit(1)
+	}
+
+	rate, channels, bps := playDec.GetFormat()
+	deviceIdx, err = selectDevice(deviceIdx, rate, channels, bps, playPAFrames, playFallback)
+	if err != nil {
+		slog.Error("Audio device check failed", "error", err)
+		playDec.Close()
+		os.Exit(1)
+	}
+
+	player := audioplayer.New(deviceIdx, playBufferCapacity, playPAFrames, playSamplesPerFrame)
+
+	pausable := decoders.NewPausableDecoder(playDec)
+	tracker := decoders.NewErrorTracker(pausable)
+	player.SetDecoder(tracker, filepath.Base(fileName))
 
 	if err := player.Play(); err != nil {
 		slog.Error("Failed to start playback", "error", err)
 		os.Exit(1)
 	}
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	stopPause := notifyProducerPause(pausable)
 
 	statusDone := make(chan struct{})
-	go monitorPlayback(player, statusDone)
-
-	done := make(chan struct{})
-	go func() {
-		player.Wait()
-		close(done)
-	}()
+	go monitorPlayback(player, playStatusInterval, playBufferSmoothing, totalSamples, statusDone)
 
-	select {
-	case <-done:
+	if err := waitPlayback(ctx, player); err != nil {
+		slog.Info("Signal received, stopping")
+	} else if err := tracker.Err(); err != nil {
+		slog.Warn("Playback ended early, file may be truncated or corrupt",
+			"decoded_samples", tracker.DecodedSamples(),
+			"error", err)
+	} else {
 		slog.Info("Playback completed")
-	case sig := <-sigChan:
-		slog.Info("Signal received, stopping", "signal", sig)
+	}
+	if loop != nil {
+		slog.Info("Loop passes completed", "passes", loop.Passes())
 	}
 
 	close(statusDone)
+	// Stop waits for the producer, which a paused decoder would block.
+	stopPause()
 	if err := player.Stop(); err != nil {
 		slog.Error("Failed to stop player", "error", err)
 	}
@@ -157,7 +304,87 @@ func runPlayer(cmd *cobra.Command, args []string) {
 	slog.Info("Exiting")
 }
 
-// safeNewDecoder wraps decoders.NewDecoder with panic recovery.
+// applyPlayOptions wraps dec with the processing selected by the play flags.
+func applyPlayOptions(dec decoder.AudioDecoder) (decoder.AudioDecoder, error) {
+	if playSampleRate > 0 {
+		rateDec, err := decoders.NewSampleRateOverride(dec, playSampleRate)
+		if err != nil {
+			return nil, err
+		}
+		origRate, _, _ := dec.GetFormat()
+		slog.Info("Overriding sample rate", "header", origRate, "override", playSampleRate)
+		dec = rateDec
+	}
+
+	dec, err := applyChannelGains(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if playMono {
+		if _, channels, _ := dec.GetFormat(); channels > 1 {
+			monoDec, err := decoders.NewMonoDownmix(dec)
+			if err != nil {
+				return nil, err
+			}
+			slog.Info("Downmixing to mono", "input_channels", channels)
+			dec = monoDec
+		}
+	}
+
+	if playGainDB != 0 {
+		gainDec, err := decoders.NewGainDecoderDB(dec, playGainDB)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Applying gain", "db", playGainDB, "linear", gainDec.Gain())
+		dec = gainDec
+	}
+
+	return applyStartupSilence(dec, playStartupSilence)
+}
+
+// applyStartupSilence prefixes dec with the given duration of silence.
+// Returns dec unchanged for a zero duration.
+func applyStartupSilence(dec decoder.AudioDecoder, d time.Duration) (decoder.AudioDecoder, error) {
+	if d == 0 {
+		return dec, nil
+	}
+	silenceDec, err := decoders.NewSilencePrefix(dec, d)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Adding startup silence", "duration", d)
+	return silenceDec, nil
+}
+
+// applyChannelGains wraps dec with the gains selected by --gains or --balance.
+// Returns dec unchanged when neither flag is set, and an error when both are,
+// since each sets the gain of every channel.
+func applyChannelGains(dec decoder.AudioDecoder) (decoder.AudioDecoder, error) {
+	if len(playChannelGains) > 0 && playBalance != 0 {
+		return nil, fmt.Errorf("--gains and --balance cannot be used together")
+	}
+	gains := playChannelGains
+	if len(gains) == 0 && playBalance != 0 {
+		if _, channels, _ := dec.GetFormat(); channels != 2 {
+			return nil, fmt.Errorf("--balance requires a stereo file, got %d channels", channels)
+		}
+		gains = audioproc.BalanceGains(playBalance)
+	}
+	if len(gains) == 0 {
+		return dec, nil
+	}
+
+	gainDec, err := decoders.NewChannelGainDecoder(dec, gains)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Applying channel gains", "gains", gains)
+	return gainDec, nil
+}
+
+// safeNewDecoder wraps openInput with panic recovery.
 // go-riff panics on truncated/invalid WAV files instead of returning an error.
 func safeNewDecoder(fileName string) (dec decoder.AudioDecoder, err error) {
 	defer func() {
@@ -166,5 +393,5 @@ func safeNewDecoder(fileName string) (dec decoder.AudioDecoder, err error) {
 			err = fmt.Errorf("failed to decode file (possibly corrupt or truncated): %v", r)
 		}
 	}()
-	return decoders.NewDecoder(fileName)
+	return openInput(fileName, playRawFormat, playBits)
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyChannelGains(t *testing.T) {
+	tests := []struct {
+		name    string
+		gains   []float64
+		balance float64
+		wantErr string // "" for success
+	}{
+		{"neither", nil, 0, ""},
+		{"gains", []float64{0.5}, 0, ""},
+		{"both", []float64{0.5}, 0.3, "--gains and --balance"},
+		{"balance on mono", nil, 0.3, "stereo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldGains, oldBalance := playChannelGains, playBalance
+			t.Cleanup(func() { playChannelGains, playBalance = oldGains, oldBalance })
+			playChannelGains, playBalance = tt.gains, tt.balance
+
+			dec, err := applyChannelGains(&lengthDecoder{samples: 10})
+			if tt.wantErr == "" {
+				if err != nil || dec == nil {
+					t.Fatalf("applyChannelGains = %v, %v", dec, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("applyChannelGains error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
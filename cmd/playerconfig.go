@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+)
+
+// Limits on the player buffer flags. The ring buffer's capacity is rounded up
+// to a power of two, so maxBufferCapacity is one too.
+const (
+	maxBufferCapacity = 1 << 16
+	minPAFrames       = 16
+	maxPAFrames       = 16384
+)
+
+// validatePlayerFlags checks the device and buffer flags shared by the
+// playback commands, so a bad value fails with a message naming the flag
+// rather than deep inside playback.
+func validatePlayerFlags(deviceIdx int, bufferCapacity uint64, paFrames, samplesPerFrame int) error {
+	if deviceIdx < 0 {
+		return fmt.Errorf("--device must not be negative, got %d (see 'musictools devices')", deviceIdx)
+	}
+	if bufferCapacity < 2 || bufferCapacity > maxBufferCapacity {
+		return fmt.Errorf("--capacity must be between 2 and %d frames, got %d", maxBufferCapacity, bufferCapacity)
+	}
+	if paFrames < minPAFrames || paFrames > maxPAFrames {
+		return fmt.Errorf("--paframes must be between %d and %d, got %d", minPAFrames, maxPAFrames, paFrames)
+	}
+	// AudioFrame.SamplesCount is a uint16.
+	if samplesPerFrame < 1 || samplesPerFrame > math.MaxUint16 {
+		return fmt.Errorf("--samples must be between 1 and %d, got %d", math.MaxUint16, samplesPerFrame)
+	}
+	return nil
+}
+
+// bufferSmoothingUsage is the help text for the --buffer-smoothing flag.
+const bufferSmoothingUsage = "Weight of the newest sample in the averaged buffered time of the status log, in (0, 1] (1 = no smoothing)"
+
+// validateBufferSmoothing checks --buffer-smoothing. A weight of zero would
+// freeze the average at its first sample.
+func validateBufferSmoothing(alpha float64) error {
+	if !(alpha > 0 && alpha <= 1) {
+		return fmt.Errorf("--buffer-smoothing must be greater than 0 and at most 1, got %g", alpha)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidatePlayerFlags(t *testing.T) {
+	tests := []struct {
+		name            string
+		deviceIdx       int
+		bufferCapacity  uint64
+		paFrames        int
+		samplesPerFrame int
+		wantFlag        string // flag named in the error, "" for none
+	}{
+		{"defaults", 1, 16, 512, 4096, ""},
+		{"smallest values", 0, 2, minPAFrames, 1, ""},
+		{"largest values", 99, maxBufferCapacity, maxPAFrames, math.MaxUint16, ""},
+		{"negative device", -1, 16, 512, 4096, "--device"},
+		{"capacity too small", 1, 1, 512, 4096, "--capacity"},
+		{"capacity too large", 1, maxBufferCapacity + 1, 512, 4096, "--capacity"},
+		{"paframes too small", 1, 16, minPAFrames - 1, 4096, "--paframes"},
+		{"paframes too large", 1, 16, maxPAFrames + 1, 4096, "--paframes"},
+		{"no samples", 1, 16, 512, 0, "--samples"},
+		{"samples overflow a frame", 1, 16, 512, math.MaxUint16 + 1, "--samples"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePlayerFlags(tt.deviceIdx, tt.bufferCapacity, tt.paFrames, tt.samplesPerFrame)
+			if tt.wantFlag == "" {
+				if err != nil {
+					t.Fatalf("validatePlayerFlags: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantFlag) {
+				t.Fatalf("validatePlayerFlags error = %v, want one naming %s", err, tt.wantFlag)
+			}
+		})
+	}
+}
+
+func TestValidateBufferSmoothing(t *testing.T) {
+	for _, alpha := range []float64{defaultBufferSmoothing, 0.01, 1} {
+		if err := validateBufferSmoothing(alpha); err != nil {
+			t.Errorf("validateBufferSmoothing(%g): %v", alpha, err)
+		}
+	}
+	for _, alpha := range []float64{0, -0.5, 1.5, math.NaN()} {
+		if err := validateBufferSmoothing(alpha); err == nil {
+			t.Errorf("validateBufferSmoothing(%g) succeeded, want error", alpha)
+		}
+	}
+}
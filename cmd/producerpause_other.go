@@ -0,0 +1,10 @@
+//go:build !unix
+
+package cmd
+
+import "github.com/drgolem/musictools/internal/decoders"
+
+// notifyProducerPause does nothing on platforms without SIGUSR1/SIGUSR2.
+func notifyProducerPause(dec *decoders.PausableDecoder) (stop func()) {
+	return func() {}
+}
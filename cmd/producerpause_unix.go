@@ -0,0 +1,42 @@
+//go:build unix
+
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/drgolem/musictools/internal/decoders"
+)
+
+// notifyProducerPause pauses decoding of dec on SIGUSR1 and resumes it on
+// SIGUSR2, while the output keeps playing what is buffered. The returned
+// func stops listening and resumes decoding, so the player can stop.
+func notifyProducerPause(dec *decoders.PausableDecoder) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if sig == syscall.SIGUSR1 {
+					dec.Pause()
+					slog.Info("Decoding paused, playing buffered audio")
+				} else {
+					dec.Resume()
+					slog.Info("Decoding resumed")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+		dec.Resume()
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/audiokit/pkg/types"
+	"github.com/drgolem/musictools/internal/decoders"
+)
+
+// rawFormatUsage is the flag help for the --raw-format flags.
+const rawFormatUsage = "Decode the input as headerless PCM of this format, rate:channels:bits (e.g. 44100:2:16)"
+
+// parseFrameFormat parses a format in the rate:channels:bits notation of
+// types.FrameFormat.String.
+func parseFrameFormat(s string) (types.FrameFormat, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return types.FrameFormat{}, fmt.Errorf("invalid format %q, want rate:channels:bits", s)
+	}
+	var v [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 {
+			return types.FrameFormat{}, fmt.Errorf("invalid format %q, want rate:channels:bits", s)
+		}
+		v[i] = n
+	}
+	return types.FrameFormat{SampleRate: v[0], Channels: v[1], BitsPerSample: v[2]}, nil
+}
+
+// openInput opens fileName with the decoder for its extension or, when
+// rawFormat is set, as raw PCM of that format. A tcp://host:port name
+// connects to a frame server instead, and "-" decodes WAV from stdin. A
+// non-zero bitsPerSample sets the output bit depth, converting if the
+// decoder can't produce it.
+func openInput(fileName, rawFormat string, bitsPerSample int) (decoder.AudioDecoder, error) {
+	var dec decoder.AudioDecoder
+	var err error
+	switch {
+	case isRemoteInput(fileName):
+		dec, err = openRemote(fileName)
+	case fileName == "-" && rawFormat == "":
+		dec, err = decoders.OpenReader(os.Stdin, ".wav")
+	case rawFormat != "":
+		dec, err = openRaw(fileName, rawFormat)
+	case bitsPerSample != 0:
+		return decoders.NewDecoderWithBitDepth(fileName, bitsPerSample)
+	default:
+		return decoders.NewDecoder(fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bitsPerSample == 0 {
+		return dec, nil
+	}
+
+	out, err := decoders.SetOutputBitDepth(dec, bitsPerSample)
+	if err != nil {
+		dec.Close()
+		return nil, err
+	}
+	return out, nil
+}
+
+// openRaw opens fileName as headerless PCM in the rate:channels:bits format.
+func openRaw(fileName, rawFormat string) (decoder.AudioDecoder, error) {
+	format, err := parseFrameFormat(rawFormat)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := decoders.NewRawDecoder(fileName, format)
+	if err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
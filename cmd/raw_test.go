@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/drgolem/audiokit/pkg/types"
+)
+
+func TestParseFrameFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    types.FrameFormat
+		wantErr bool
+	}{
+		{in: "44100:2:16", want: types.FrameFormat{SampleRate: 44100, Channels: 2, BitsPerSample: 16}},
+		{in: "8000:1:8", want: types.FrameFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 8}},
+		{in: "96000:6:24", want: types.FrameFormat{SampleRate: 96000, Channels: 6, BitsPerSample: 24}},
+		{in: "", wantErr: true},
+		{in: "44100:2", wantErr: true},
+		{in: "44100:2:16:1", wantErr: true},
+		{in: "44100:two:16", wantErr: true},
+		{in: "44100:0:16", wantErr: true},
+		{in: "-44100:2:16", wantErr: true},
+		{in: "44100::16", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseFrameFormat(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseFrameFormat(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseFrameFormat(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
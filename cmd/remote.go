@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/frameserver"
+)
+
+// remoteInputPrefix marks a play input that is a frame server address.
+const remoteInputPrefix = "tcp://"
+
+// isRemoteInput reports whether name is a frame server address rather than
+// a file.
+func isRemoteInput(name string) bool {
+	return strings.HasPrefix(name, remoteInputPrefix)
+}
+
+// openRemote connects to the frame server at a tcp://host:port name and
+// returns a decoder for its stream.
+func openRemote(name string) (decoder.AudioDecoder, error) {
+	client, err := frameserver.Dial(strings.TrimPrefix(name, remoteInputPrefix))
+	if err != nil {
+		return nil, err
+	}
+	dec, err := client.Decoder(context.Background())
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return dec, nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"log/slog"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/decoders"
+	"github.com/drgolem/musictools/internal/metadata"
+)
+
+// replayGainUsage is the flag help for the --replaygain flags.
+const replayGainUsage = "Apply ReplayGain tags (FLAC, WAV, MP3): off, track, or album (falls back to track)"
+
+// applyReplayGain wraps dec with the ReplayGain level that fileName's tags
+// give for mode. Files without ReplayGain tags, or whose tags can't be read
+// or are invalid, play unchanged.
+func applyReplayGain(dec decoder.AudioDecoder, fileName string, mode metadata.ReplayGainMode) decoder.AudioDecoder {
+	if mode == metadata.ReplayGainOff {
+		return dec
+	}
+
+	tags, err := metadata.ReadFile(fileName)
+	if err != nil {
+		slog.Warn("Failed to read tags, playing without ReplayGain", "file", fileName, "error", err)
+		return dec
+	}
+	rg, err := metadata.ParseReplayGain(tags)
+	if err != nil {
+		slog.Warn("Invalid ReplayGain tag, playing without ReplayGain", "file", fileName, "error", err)
+		return dec
+	}
+	gain, ok := rg.Gain(mode)
+	if !ok {
+		slog.Info("No ReplayGain tags", "file", fileName, "mode", mode)
+		return dec
+	}
+
+	gainDec, err := decoders.NewGainDecoder(dec, gain)
+	if err != nil {
+		slog.Warn("Invalid ReplayGain level, playing without ReplayGain", "file", fileName, "error", err)
+		return dec
+	}
+	slog.Info("Applying ReplayGain", "file", fileName, "mode", mode, "linear", gain)
+	return gainDec
+}
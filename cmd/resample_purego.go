@@ -0,0 +1,16 @@
+//go:build nosoxr
+
+package cmd
+
+import "github.com/drgolem/musictools/internal/audioproc"
+
+// resamplerName identifies the resampler compiled in, for logging.
+const resamplerName = "pure-go"
+
+// resampleAudio resamples audio data with the pure-Go windowed-sinc
+// resampler. Used in builds without libsoxr (-tags nosoxr), which still need
+// cgo for PortAudio, libFLAC and Opus; the quality is good but below soxr's
+// high-quality mode.
+func resampleAudio(audioData []byte, fromRate, toRate, channels, bitsPerSample int) ([]byte, error) {
+	return audioproc.Resample(audioData, bitsPerSample, channels, fromRate, toRate)
+}
@@ -0,0 +1,77 @@
+//go:build !nosoxr
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+
+	"github.com/drgolem/musictools/internal/audioproc"
+	soxr "github.com/zaf/resample"
+)
+
+// resamplerName identifies the resampler compiled in, for logging.
+const resamplerName = "soxr"
+
+// resampleAudio resamples audio data using SoXR (high-quality resampler).
+// SoXR takes 16- and 32-bit integer samples; 8- and 24-bit audio is widened
+// to 32 bits for resampling and rounded back to its depth afterwards.
+func resampleAudio(audioData []byte, fromRate, toRate, channels, bitsPerSample int) ([]byte, error) {
+	if fromRate == toRate {
+		return audioData, nil
+	}
+
+	switch bitsPerSample {
+	case 16:
+		return soxrResample(audioData, fromRate, toRate, channels, soxr.I16)
+	case 32:
+		return soxrResample(audioData, fromRate, toRate, channels, soxr.I32)
+	case 8, 24:
+		wide, err := audioproc.WidenBitDepth(audioData, bitsPerSample, 32)
+		if err != nil {
+			return nil, err
+		}
+		resampled, err := soxrResample(wide, fromRate, toRate, channels, soxr.I32)
+		if err != nil {
+			return nil, err
+		}
+		return audioproc.ReduceBitDepth(resampled, channels, 32, bitsPerSample, audioproc.ShapingNone)
+	default:
+		return nil, fmt.Errorf("unsupported bit depth for resampling: %d", bitsPerSample)
+	}
+}
+
+// soxrResample resamples interleaved samples of the given SoXR format.
+func soxrResample(audioData []byte, fromRate, toRate, channels, format int) ([]byte, error) {
+	var bufResampled bytes.Buffer
+	bufWriter := bufio.NewWriter(&bufResampled)
+
+	resampler, err := soxr.New(
+		bufWriter,
+		float64(fromRate),
+		float64(toRate),
+		channels,
+		format,
+		soxr.HighQ, // High quality
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resampler: %w", err)
+	}
+
+	_, err = resampler.Write(audioData)
+	if err != nil {
+		resampler.Close()
+		return nil, fmt.Errorf("failed to resample: %w", err)
+	}
+
+	if err := resampler.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close resampler: %w", err)
+	}
+
+	if err := bufWriter.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush buffer: %w", err)
+	}
+
+	return bufResampled.Bytes(), nil
+}
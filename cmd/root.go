@@ -12,13 +12,22 @@ var rootCmd = &cobra.Command{
 	Short: "Audio player and converter",
 	Long: `musictools - Command-line audio player and converter.
 
-Supports MP3, FLAC, WAV, OGG Vorbis, and Opus formats.
+Supports MP3, FLAC, WAV, OGG Vorbis, Opus and AIFF formats.
 
 Commands:
-  play       Play a single audio file
-  playlist   Play multiple files sequentially
-  transform  Resample and convert to WAV
-  samplecut  Extract a time segment from an audio file`,
+  play         Play a single audio file
+  playlist     Play multiple files sequentially
+  mix          Play several files at once, mixed together
+  transform    Resample and convert to WAV
+  samplecut    Extract a time segment from an audio file
+  clip         Extract a clip (start + duration) to a WAV file
+  devices      List audio host APIs and output devices
+  doctor       Check that PortAudio and the decoders work
+  verify       Check that audio files decode cleanly
+  info         Show the format, duration and tags of audio files
+  bench        Measure decode throughput
+  spectrogram  Render a spectrogram of a file to PNG
+  serve        Stream a decoded file to a remote player over TCP`,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -1,12 +1,10 @@
 package cmd
 
 import (
-	"io"
 	"log/slog"
 	"os"
 	"time"
 
-	"github.com/drgolem/audiokit/pkg/decoder"
 	"github.com/drgolem/musictools/internal/decoders"
 	"github.com/spf13/cobra"
 	"github.com/youpy/go-wav"
@@ -84,48 +82,13 @@ func doSamplecutCmd(cmd *cobra.Command, args []string) {
 		"channels", channels,
 		"bits_per_sample", bitsPerSample)
 
-	startSamples := int(start.Seconds() * float64(sampleRate))
-	durationSamples := int(dur.Seconds() * float64(sampleRate))
-	bytesPerFrame := channels * bitsPerSample / 8
-
-	// Seek to start position
-	if startSamples > 0 {
-		if seekable, ok := dec.(decoder.Seekable); ok {
-			if _, err := seekable.Seek(int64(startSamples), io.SeekCurrent); err != nil {
-				slog.Error("seek failed", "error", err)
-				return
-			}
-		} else {
-			// Skip samples by decoding and discarding
-			skipped := 0
-			skipBuf := make([]byte, 2048*bytesPerFrame)
-			for skipped < startSamples {
-				toRead := min(2048, startSamples-skipped)
-				n, err := dec.DecodeSamples(toRead, skipBuf)
-				if err != nil || n == 0 {
-					slog.Error("failed to skip to start position", "skipped", skipped, "target", startSamples)
-					return
-				}
-				skipped += n
-			}
-		}
-	}
-
-	// Read duration samples
-	audioData := make([]byte, 0, durationSamples*bytesPerFrame)
-	readBuf := make([]byte, 2048*bytesPerFrame)
-	samplesRead := 0
-
-	for samplesRead < durationSamples {
-		toRead := min(2048, durationSamples-samplesRead)
-		n, err := dec.DecodeSamples(toRead, readBuf)
-		if n > 0 {
-			audioData = append(audioData, readBuf[:n*bytesPerFrame]...)
-			samplesRead += n
-		}
-		if err != nil || n == 0 {
-			break
+	audioData, samplesRead, err := decoders.DecodeRange(dec, start, dur)
+	if err != nil {
+		if samplesRead == 0 {
+			slog.Error("failed to decode segment", "error", err)
+			return
 		}
+		slog.Warn("segment ended early", "decoded_samples", samplesRead, "error", err)
 	}
 
 	slog.Info("Decoded segment", "samples", samplesRead)
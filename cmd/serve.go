@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"os"
+
+	"github.com/drgolem/musictools/internal/decoders"
+	"github.com/drgolem/musictools/internal/frameserver"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen          string
+	serveSamplesPerFrame int
+	serveMaxRate         float64
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve <audio_file>",
+	Short: "Stream a decoded file to a remote player over TCP",
+	Long: `Decode an audio file and stream its frames over TCP to a player on another
+machine, which plays it with 'musictools play tcp://host:port'.
+
+Clients are served one at a time; if the player disconnects, the next one
+continues from where it stopped. The server exits when the file ends.
+
+By default frames are sent as fast as the client reads them. --max-rate caps
+decoding at a number of samples per second, e.g. the file's sample rate to
+send in real time to a client that doesn't pace itself.
+
+Examples:
+  musictools serve --listen :7878 music.flac
+  musictools serve --max-rate 44100 music.flac
+  musictools play tcp://server:7878`,
+	Args: cobra.ExactArgs(1),
+	Run:  runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveListen, "listen", ":7878", "TCP address to listen on")
+	serveCmd.Flags().IntVarP(&serveSamplesPerFrame, "samples", "s", 4096, "Samples per AudioFrame sent")
+	serveCmd.Flags().Float64Var(&serveMaxRate, "max-rate", 0, "Maximum decode rate in samples per second (0 = unlimited)")
+}
+
+func runServe(cmd *cobra.Command, args []string) {
+	fileName := args[0]
+
+	if serveSamplesPerFrame < 1 || serveSamplesPerFrame > math.MaxUint16 {
+		slog.Error("Invalid samples per frame", "samples", serveSamplesPerFrame, "valid_range", "1-65535")
+		os.Exit(1)
+	}
+	if serveMaxRate < 0 {
+		slog.Error("Invalid maximum decode rate", "max_rate", serveMaxRate)
+		os.Exit(1)
+	}
+
+	dec, err := decoders.NewDecoder(fileName)
+	if err != nil {
+		slog.Error("Failed to create decoder", "error", err)
+		os.Exit(1)
+	}
+	defer dec.Close()
+
+	if serveMaxRate > 0 {
+		dec, err = decoders.NewThrottleDecoder(dec, serveMaxRate)
+		if err != nil {
+			slog.Error("Failed to limit decode rate", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Limiting decode rate", "samples_per_sec", serveMaxRate)
+	}
+
+	ln, err := net.Listen("tcp", serveListen)
+	if err != nil {
+		slog.Error("Failed to listen", "address", serveListen, "error", err)
+		os.Exit(1)
+	}
+	defer ln.Close()
+
+	rate, channels, bps := dec.GetFormat()
+	slog.Info("Serving",
+		"file", fileName,
+		"address", ln.Addr(),
+		"sample_rate", rate,
+		"channels", channels,
+		"bits_per_sample", bps)
+
+	if err := frameserver.Serve(ln, dec, serveSamplesPerFrame); err != nil {
+		slog.Error("Serving failed", "error", err)
+		os.Exit(1)
+	}
+}
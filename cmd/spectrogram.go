@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"image/png"
+	"log/slog"
+	"os"
+
+	"github.com/drgolem/audiokit/pkg/types"
+	"github.com/drgolem/musictools/internal/audioproc"
+	"github.com/drgolem/musictools/internal/decoders"
+	"github.com/drgolem/musictools/internal/spectrogram"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	spectrogramOut    string
+	spectrogramWindow int
+	spectrogramWidth  int
+	spectrogramMinDB  float64
+)
+
+var spectrogramCmd = &cobra.Command{
+	Use:   "spectrogram <audio_file>",
+	Short: "Render a spectrogram of an audio file to PNG",
+	Long: `Decode an audio file, downmix it to mono and render its short-time spectrum
+as a PNG image: time runs left to right, frequency from 0 Hz at the bottom to
+the Nyquist frequency at the top, and color shows the level from --min-db
+(black) up to 0 dBFS (white).
+
+Examples:
+  musictools spectrogram song.flac --out song.png
+
+  # Finer frequency resolution, wider image
+  musictools spectrogram song.wav --window 4096 --width 2400 --out song.png`,
+	Args: cobra.ExactArgs(1),
+	Run:  runSpectrogram,
+}
+
+func init() {
+	rootCmd.AddCommand(spectrogramCmd)
+
+	defaults := spectrogram.DefaultOptions()
+	spectrogramCmd.Flags().StringVar(&spectrogramOut, "out", "spectrogram.png", "Output PNG file path")
+	spectrogramCmd.Flags().IntVar(&spectrogramWindow, "window", defaults.WindowSize, "FFT window size in samples (power of two); the image is window/2 pixels high")
+	spectrogramCmd.Flags().IntVar(&spectrogramWidth, "width", defaults.Width, "Image width in pixels")
+	spectrogramCmd.Flags().Float64Var(&spectrogramMinDB, "min-db", defaults.MinDB, "Level drawn as black, in dBFS")
+}
+
+func runSpectrogram(cmd *cobra.Command, args []string) {
+	inFileName := args[0]
+
+	samples, rate, err := decodeMonoFloat(inFileName)
+	if err != nil {
+		slog.Error("Failed to decode audio", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Decoded audio", "file", inFileName, "samples", len(samples), "sample_rate", rate)
+
+	img, err := spectrogram.Render(samples, spectrogram.Options{
+		WindowSize: spectrogramWindow,
+		Width:      spectrogramWidth,
+		MinDB:      spectrogramMinDB,
+	})
+	if err != nil {
+		slog.Error("Failed to render spectrogram", "error", err)
+		os.Exit(1)
+	}
+
+	f, err := os.Create(spectrogramOut)
+	if err != nil {
+		slog.Error("Failed to create output file", "error", err)
+		os.Exit(1)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		slog.Error("Failed to write PNG", "error", err)
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		slog.Error("Failed to close output file", "error", err)
+		os.Exit(1)
+	}
+
+	bounds := img.Bounds()
+	slog.Info("Spectrogram written",
+		"path", spectrogramOut,
+		"width", bounds.Dx(),
+		"height", bounds.Dy(),
+		"hz_per_row", fmt.Sprintf("%.1f", float64(rate)/float64(spectrogramWindow)))
+}
+
+// decodeMonoFloat decodes fileName, downmixed to mono, as floats in [-1, 1].
+func decodeMonoFloat(fileName string) ([]float64, int, error) {
+	dec, err := decoders.NewDecoder(fileName)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer dec.Close()
+
+	mono, err := decoders.NewMonoDownmix(dec)
+	if err != nil {
+		return nil, 0, err
+	}
+	rate, channels, bps := mono.GetFormat()
+	format := types.FrameFormat{SampleRate: rate, Channels: channels, BitsPerSample: bps}
+
+	var samples []float64
+	_, err = decodeChunks(mono, format, 16384, func(chunk []byte) error {
+		f, err := audioproc.ToFloat64(chunk, bps)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, f...)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return samples, rate, nil
+}
@@ -2,18 +2,21 @@ package cmd
 
 import (
 	"bufio"
-	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
-	"strings"
 
 	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/audiokit/pkg/types"
+	"github.com/drgolem/musictools/internal/audioproc"
 	"github.com/drgolem/musictools/internal/decoders"
+	"github.com/drgolem/musictools/internal/metadata"
 
 	"github.com/spf13/cobra"
 	wav "github.com/youpy/go-wav"
-	soxr "github.com/zaf/resample"
 )
 
 var transformCmd = &cobra.Command{
@@ -32,13 +35,33 @@ Examples:
   # Transform WAV with default settings (48kHz)
   musictools transform input.wav
 
+  # Do not copy title/artist/album tags to the output
+  musictools transform input.flac --no-tags --out output.wav
+
+  # Report sizes and clipping risk without writing anything
+  musictools transform input.flac --new-samplerate 44100 --dry-run
+
+  # Write headerless big-endian PCM for a big-endian pipeline
+  musictools transform input.flac --raw --endian be --out output.pcm
+
+  # Reduce to 8-bit with noise-shaped dither
+  musictools transform input.wav --bits 8 --dither shaped --out output.wav
+
+  # Convert headerless 16-bit stereo PCM at 44.1kHz to a 48kHz WAV
+  musictools transform capture.raw --raw-format 44100:2:16 --out capture.wav
+
 Supported Input Formats:
   - MP3 (.mp3)
   - FLAC (.flac)
   - WAV (.wav)
+  - Headerless PCM (8, 16, 24 or 32-bit) with --raw-format
 
 Output Format:
-  - WAV (16-bit PCM)
+  - WAV (PCM at the input bit depth, or lower with --bits), or headerless
+    PCM with --raw (little- or big-endian)
+  - Tags from WAV (LIST/INFO), FLAC (Vorbis comments) and MP3 (ID3v2)
+    inputs are written to the output LIST/INFO chunk unless --no-tags is
+    given
 
 Sample Rate Options:
   Common rates: 8000, 16000, 22050, 44100, 48000, 96000, 192000 Hz`,
@@ -50,8 +73,16 @@ func init() {
 	rootCmd.AddCommand(transformCmd)
 
 	transformCmd.Flags().Int("new-samplerate", 48000, "Target sample rate in Hz")
-	transformCmd.Flags().String("out", "out_transformed.wav", "Output WAV file path")
+	transformCmd.Flags().String("out", "out_transformed.wav", "Output file path")
 	transformCmd.Flags().Bool("mono", false, "Convert output to mono signal (average channels)")
+	transformCmd.Flags().Bool("no-tags", false, "Do not copy metadata tags to the output file")
+	transformCmd.Flags().Bool("raw", false, "Write headerless raw PCM instead of WAV")
+	transformCmd.Flags().String("endian", "le", "Byte order of --raw output: le or be")
+	transformCmd.Flags().String("raw-format", "", rawFormatUsage)
+	transformCmd.Flags().Int("bits", 0, "Output bit depth (8, 16 or 24), at most the input's; 0 keeps the input depth")
+	transformCmd.Flags().String("dither", "tpdf", "Dither when reducing bit depth: none, tpdf, or shaped (noise-shaped)")
+	transformCmd.Flags().Int("max-decode-mb", 2048, "Refuse inputs that decode to more than this many MiB of PCM")
+	transformCmd.Flags().Bool("dry-run", false, "Decode and report the planned output without writing it")
 }
 
 func runTransform(cmd *cobra.Command, args []string) {
@@ -80,12 +111,78 @@ func runTransform(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	noTags, err := cmd.Flags().GetBool("no-tags")
+	if err != nil {
+		slog.Error("Failed to get no-tags flag", "error", err)
+		os.Exit(1)
+	}
+
+	rawOutput, err := cmd.Flags().GetBool("raw")
+	if err != nil {
+		slog.Error("Failed to get raw flag", "error", err)
+		os.Exit(1)
+	}
+
+	endian, err := cmd.Flags().GetString("endian")
+	if err != nil {
+		slog.Error("Failed to get endian flag", "error", err)
+		os.Exit(1)
+	}
+
+	if endian != "le" && endian != "be" {
+		slog.Error("Invalid byte order", "endian", endian, "valid", "le, be")
+		os.Exit(1)
+	}
+	if endian == "be" && !rawOutput {
+		slog.Error("Big-endian output requires --raw (WAV is always little-endian)")
+		os.Exit(1)
+	}
+
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		slog.Error("Failed to get dry-run flag", "error", err)
+		os.Exit(1)
+	}
+
+	rawFormat, err := cmd.Flags().GetString("raw-format")
+	if err != nil {
+		slog.Error("Failed to get raw-format flag", "error", err)
+		os.Exit(1)
+	}
+
+	outputBits, err := cmd.Flags().GetInt("bits")
+	if err != nil {
+		slog.Error("Failed to get bits flag", "error", err)
+		os.Exit(1)
+	}
+
+	ditherName, err := cmd.Flags().GetString("dither")
+	if err != nil {
+		slog.Error("Failed to get dither flag", "error", err)
+		os.Exit(1)
+	}
+	dither, err := audioproc.ParseShapingKind(ditherName)
+	if err != nil {
+		slog.Error("Invalid dither", "error", err)
+		os.Exit(1)
+	}
+
+	maxDecodeMB, err := cmd.Flags().GetInt("max-decode-mb")
+	if err != nil {
+		slog.Error("Failed to get max-decode-mb flag", "error", err)
+		os.Exit(1)
+	}
+	if maxDecodeMB <= 0 {
+		slog.Error("Invalid decode limit", "max_decode_mb", maxDecodeMB)
+		os.Exit(1)
+	}
+
 	if newSampleRate <= 0 || newSampleRate > 384000 {
 		slog.Error("Invalid sample rate", "rate", newSampleRate, "valid_range", "1-384000")
 		os.Exit(1)
 	}
 
-	dec, err := decoders.NewDecoder(inFileName)
+	dec, err := openInput(inFileName, rawFormat, 0)
 	if err != nil {
 		slog.Error("Failed to create decoder", "error", err)
 		os.Exit(1)
@@ -94,6 +191,18 @@ func runTransform(cmd *cobra.Command, args []string) {
 
 	inSampleRate, channels, bitsPerSample := dec.GetFormat()
 
+	outBits := bitsPerSample
+	if outputBits != 0 {
+		validBits := outputBits == 8 || outputBits == 16 || outputBits == 24
+		if !validBits || outputBits > bitsPerSample {
+			slog.Error("Invalid output bit depth",
+				"bits", outputBits,
+				"input_bits_per_sample", bitsPerSample)
+			os.Exit(1)
+		}
+		outBits = outputBits
+	}
+
 	slog.Info("Audio transformation starting",
 		"input_file", inFileName,
 		"input_sample_rate", inSampleRate,
@@ -104,7 +213,14 @@ func runTransform(cmd *cobra.Command, args []string) {
 		"output_file", outFileName)
 
 	slog.Info("Decoding audio data")
-	audioData, totalSamples, err := decodeAllAudio(dec, channels, bitsPerSample)
+	format := types.FrameFormat{SampleRate: inSampleRate, Channels: channels, BitsPerSample: bitsPerSample}
+	audioData, totalSamples, err := decodeAllAudioCapped(dec, format, maxDecodeMB<<20)
+	if errors.Is(err, errTruncated) {
+		slog.Error("Input is too large to transform in memory, raise --max-decode-mb to allow it",
+			"max_decode_mb", maxDecodeMB,
+			"decoded_samples", totalSamples)
+		os.Exit(1)
+	}
 	if err != nil {
 		slog.Error("Failed to decode audio", "error", err)
 		os.Exit(1)
@@ -114,11 +230,22 @@ func runTransform(cmd *cobra.Command, args []string) {
 		"input_samples", totalSamples,
 		"input_bytes", len(audioData))
 
+	if dryRun {
+		peak, err := audioproc.Peak(audioData, bitsPerSample)
+		if err != nil {
+			slog.Warn("Failed to measure peak level", "error", err)
+		}
+		plan := planTransform(inSampleRate, newSampleRate, channels, outBits, totalSamples, convertToMono, rawOutput, peak)
+		logTransformPlan(plan, outFileName)
+		return
+	}
+
 	slog.Info("Resampling audio",
 		"from_rate", inSampleRate,
-		"to_rate", newSampleRate)
+		"to_rate", newSampleRate,
+		"resampler", resamplerName)
 
-	resampledData, err := resampleAudio(audioData, inSampleRate, newSampleRate, channels)
+	resampledData, err := resampleAudio(audioData, inSampleRate, newSampleRate, channels, bitsPerSample)
 	if err != nil {
 		slog.Error("Failed to resample audio", "error", err)
 		os.Exit(1)
@@ -136,14 +263,32 @@ func runTransform(cmd *cobra.Command, args []string) {
 
 	if convertToMono && channels > 1 {
 		slog.Info("Converting to mono", "input_channels", channels)
-		outputData = convertToMono16Bit(resampledData, channels)
+		outputData, err = audioproc.DownmixToMono(resampledData, bitsPerSample, channels)
+		if err != nil {
+			slog.Error("Failed to convert to mono", "error", err)
+			os.Exit(1)
+		}
 		outChannels = 1
 		slog.Info("Mono conversion complete", "output_channels", 1)
 	}
 
-	slog.Info("Writing output WAV file", "path", outFileName)
-	if err := writeWAVFile(outFileName, outputData, uint32(outSamples), uint16(outChannels), uint32(newSampleRate), uint16(bitsPerSample)); err != nil {
-		slog.Error("Failed to write WAV file", "error", err)
+	if outBits != bitsPerSample {
+		slog.Info("Reducing bit depth", "from", bitsPerSample, "to", outBits, "dither", dither)
+		outputData, err = audioproc.ReduceBitDepth(outputData, outChannels, bitsPerSample, outBits, dither)
+		if err != nil {
+			slog.Error("Failed to reduce bit depth", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if rawOutput {
+		slog.Info("Writing raw PCM file", "path", outFileName, "endian", endian)
+		err = writeRawPCMFile(outFileName, outputData, outBits, endian == "be")
+	} else {
+		err = writeWAVOutput(inFileName, outFileName, outputData, outChannels, newSampleRate, outBits, noTags)
+	}
+	if err != nil {
+		slog.Error("Failed to write output file", "error", err)
 		os.Exit(1)
 	}
 
@@ -153,118 +298,199 @@ func runTransform(cmd *cobra.Command, args []string) {
 		"sample_rate_ratio", fmt.Sprintf("%.3f", float64(newSampleRate)/float64(inSampleRate)))
 }
 
-// decodeAllAudio reads all audio data from the decoder into memory
-func decodeAllAudio(dec decoder.AudioDecoder, channels, bitsPerSample int) ([]byte, int, error) {
-	const bufferSamples = 4096
-	bytesPerSample := bitsPerSample / 8
-	bufferSize := bufferSamples * channels * bytesPerSample
-
-	buffer := make([]byte, bufferSize)
-	audioData := make([]byte, 0, bufferSize*10) // Pre-allocate for efficiency
-	totalSamples := 0
-
-	for {
-		samplesRead, err := dec.DecodeSamples(bufferSamples, buffer)
-		if samplesRead > 0 {
-			bytesRead := samplesRead * channels * bytesPerSample
-			audioData = append(audioData, buffer[:bytesRead]...)
-			totalSamples += samplesRead
-		}
+// wavHeaderSize is the size of a minimal PCM WAV header (RIFF, fmt, data).
+const wavHeaderSize = 44
+
+// clipRiskPeak is the input peak, as a fraction of full scale, above which
+// resampling is likely to clip: the resampler's filter can overshoot
+// near-full-scale transients.
+const clipRiskPeak = 0.98
+
+// transformPlan is what transform would write for a decoded input.
+type transformPlan struct {
+	inSampleRate  int
+	outSampleRate int
+	inChannels    int
+	outChannels   int
+	bitsPerSample int
+	inSamples     int
+	outSamples    int   // estimated sample frames after resampling
+	outBytes      int64 // estimated file size, without tags
+	ratio         float64
+	peak          float64
+	clipRisk      bool
+}
 
-		if err != nil {
-			// Check if it's EOF (expected at end of file)
-			if strings.Contains(err.Error(), "EOF") || strings.Contains(err.Error(), "done") {
-				break
-			}
-			return nil, 0, fmt.Errorf("decode error: %w", err)
-		}
+// planTransform estimates the output of transforming inSamples frames of
+// audio with the given options.
+func planTransform(inRate, outRate, channels, bitsPerSample, inSamples int, mono, raw bool, peak float64) transformPlan {
+	outChannels := channels
+	if mono && channels > 1 {
+		outChannels = 1
+	}
 
-		if samplesRead == 0 {
-			break
-		}
+	outSamples := int((int64(inSamples)*int64(outRate) + int64(inRate) - 1) / int64(inRate))
+	outBytes := int64(outSamples) * int64(outChannels*bitsPerSample/8)
+	if !raw {
+		outBytes += wavHeaderSize
 	}
 
-	return audioData, totalSamples, nil
+	return transformPlan{
+		inSampleRate:  inRate,
+		outSampleRate: outRate,
+		inChannels:    channels,
+		outChannels:   outChannels,
+		bitsPerSample: bitsPerSample,
+		inSamples:     inSamples,
+		outSamples:    outSamples,
+		outBytes:      outBytes,
+		ratio:         float64(outRate) / float64(inRate),
+		peak:          peak,
+		clipRisk:      inRate != outRate && peak >= clipRiskPeak,
+	}
 }
 
-// resampleAudio resamples audio data using SoXR (high-quality resampler)
-func resampleAudio(audioData []byte, fromRate, toRate, channels int) ([]byte, error) {
-	if fromRate == toRate {
-		return audioData, nil
+// logTransformPlan reports a dry-run plan.
+func logTransformPlan(plan transformPlan, outFileName string) {
+	slog.Info("Dry run, no output written",
+		"output_file", outFileName,
+		"sample_rate_ratio", fmt.Sprintf("%.3f", plan.ratio),
+		"output_sample_rate", plan.outSampleRate,
+		"output_channels", plan.outChannels,
+		"output_bits_per_sample", plan.bitsPerSample,
+		"estimated_output_samples", plan.outSamples,
+		"estimated_output_bytes", plan.outBytes)
+
+	peakDB := math.Inf(-1)
+	if plan.peak > 0 {
+		peakDB = 20 * math.Log10(plan.peak)
 	}
-
-	var bufResampled bytes.Buffer
-	bufWriter := bufio.NewWriter(&bufResampled)
-
-	resampler, err := soxr.New(
-		bufWriter,
-		float64(fromRate),
-		float64(toRate),
-		channels,
-		soxr.I16,    // 16-bit input
-		soxr.HighQ,  // High quality
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resampler: %w", err)
+	if plan.clipRisk {
+		slog.Warn("Input peaks near full scale, resampled output may clip",
+			"peak_dbfs", fmt.Sprintf("%.2f", peakDB))
+	} else {
+		slog.Info("Clipping risk low", "peak_dbfs", fmt.Sprintf("%.2f", peakDB))
 	}
+}
 
-	_, err = resampler.Write(audioData)
-	if err != nil {
-		resampler.Close()
-		return nil, fmt.Errorf("failed to resample: %w", err)
+// errTruncated is returned by decodeAllAudioCapped when the input decodes to
+// more than the byte limit.
+var errTruncated = errors.New("decoded audio exceeds size limit")
+
+// decodeAllAudioCapped reads the audio data from the decoder into memory, up
+// to maxBytes. If the input holds more, it returns the whole sample frames
+// that fit with errTruncated.
+func decodeAllAudioCapped(dec decoder.AudioDecoder, format types.FrameFormat, maxBytes int) ([]byte, int, error) {
+	const (
+		bufferSamples  = 4096
+		maxPreallocSec = 10
+	)
+	frameSize := format.FrameSize()
+	if frameSize <= 0 || maxBytes < frameSize {
+		return nil, 0, fmt.Errorf("invalid decode limit: %d bytes for %s", maxBytes, format)
 	}
+	maxBytes -= maxBytes % frameSize
 
-	if err := resampler.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close resampler: %w", err)
+	// The decoder's length comes from the file header, which may be wrong,
+	// so it only sizes the first allocation, up to a few seconds of audio.
+	preallocate := bufferSamples * frameSize * 10
+	if total, err := decoders.TotalSamples(dec); err == nil {
+		preallocate = int(max(0, min(total, int64(format.SampleRate)*maxPreallocSec))) * frameSize
 	}
 
-	if err := bufWriter.Flush(); err != nil {
-		return nil, fmt.Errorf("failed to flush buffer: %w", err)
+	audioData := make([]byte, 0, min(preallocate, maxBytes))
+	_, err := decodeChunks(dec, format, bufferSamples, func(chunk []byte) error {
+		if len(audioData)+len(chunk) > maxBytes {
+			audioData = append(audioData, chunk[:maxBytes-len(audioData)]...)
+			return errTruncated
+		}
+		audioData = append(audioData, chunk...)
+		return nil
+	})
+	totalSamples := len(audioData) / frameSize
+	if err != nil {
+		if errors.Is(err, errTruncated) {
+			return audioData, totalSamples, err
+		}
+		return nil, 0, err
 	}
 
-	return bufResampled.Bytes(), nil
+	return audioData, totalSamples, nil
 }
 
-// convertToMono16Bit converts stereo (or multi-channel) 16-bit audio to mono by averaging channels
-func convertToMono16Bit(stereoData []byte, channels int) []byte {
-	if channels == 1 {
-		return stereoData
+// decodeChunks decodes dec in chunks of up to chunkSamples sample frames and
+// calls fn with the PCM bytes of each chunk, without accumulating them.
+// The slice passed to fn is reused between calls, so fn must copy any data it
+// keeps. Returns the total number of sample frames decoded.
+func decodeChunks(dec decoder.AudioDecoder, format types.FrameFormat, chunkSamples int, fn func([]byte) error) (int, error) {
+	frameSize := format.FrameSize()
+	if frameSize <= 0 || chunkSamples <= 0 {
+		return 0, fmt.Errorf("invalid chunk layout: %s, %d samples", format, chunkSamples)
 	}
 
-	monoSize := len(stereoData) / channels
-	monoData := make([]byte, monoSize)
+	buffer := make([]byte, chunkSamples*frameSize)
+	totalSamples := 0
 
-	idx := 0
-	outIdx := 0
+	for {
+		samplesRead, err := dec.DecodeSamples(chunkSamples, buffer)
+		if samplesRead > 0 {
+			if fnErr := fn(buffer[:samplesRead*frameSize]); fnErr != nil {
+				return totalSamples, fnErr
+			}
+			totalSamples += samplesRead
+		}
 
-	for idx < len(stereoData) {
-		sum := int32(0)
-		for ch := 0; ch < channels; ch++ {
-			if idx+1 >= len(stereoData) {
+		if err != nil {
+			// EOF is expected at end of file
+			if decoders.IsEOF(err) {
 				break
 			}
+			return totalSamples, fmt.Errorf("decode error: %w", err)
+		}
 
-			// Read 16-bit sample (little-endian)
-			b0 := int16(stereoData[idx])
-			b1 := int16(stereoData[idx+1])
-			sample := int16((b1 << 8) | b0)
+		if samplesRead == 0 {
+			break
+		}
+	}
+
+	return totalSamples, nil
+}
 
-			sum += int32(sample)
-			idx += 2
+// writeWAVOutput writes the transformed audio as WAV, copying the input's
+// tags into a LIST/INFO chunk unless noTags is set.
+func writeWAVOutput(inFileName, outFileName string, audioData []byte, channels, sampleRate, bitsPerSample int, noTags bool) error {
+	var tags map[string]string
+	if !noTags {
+		var err error
+		tags, err = metadata.ReadFile(inFileName)
+		if err != nil {
+			slog.Warn("Failed to read tags, output will be untagged", "error", err)
+		} else if len(tags) > 0 {
+			slog.Info("Copying tags", "count", len(tags))
 		}
+	}
 
-		// Average channels
-		avgSample := int16(sum / int32(channels))
+	slog.Info("Writing output WAV file", "path", outFileName)
+	if len(tags) > 0 {
+		return writeWAVWithTags(outFileName, audioData, uint16(channels), uint32(sampleRate), uint16(bitsPerSample), tags)
+	}
+	numSamples := len(audioData) / (channels * bitsPerSample / 8)
+	return writeWAVFile(outFileName, audioData, uint32(numSamples), uint16(channels), uint32(sampleRate), uint16(bitsPerSample))
+}
 
-		// Write mono sample (16-bit little-endian)
-		if outIdx+1 < len(monoData) {
-			monoData[outIdx] = byte(avgSample & 0xFF)
-			monoData[outIdx+1] = byte((avgSample >> 8) & 0xFF)
-			outIdx += 2
+// writeRawPCMFile writes audio data without a header. Samples are converted
+// to big-endian in place when bigEndian is set.
+func writeRawPCMFile(fileName string, audioData []byte, bitsPerSample int, bigEndian bool) error {
+	if bigEndian {
+		if err := audioproc.SwapEndian(audioData, bitsPerSample); err != nil {
+			return fmt.Errorf("failed to convert byte order: %w", err)
 		}
 	}
 
-	return monoData
+	if err := os.WriteFile(fileName, audioData, 0644); err != nil {
+		return fmt.Errorf("failed to write raw PCM data: %w", err)
+	}
+	return nil
 }
 
 // writeWAVFile writes audio data to a WAV file
@@ -283,3 +509,47 @@ func writeWAVFile(fileName string, audioData []byte, numSamples uint32, numChann
 
 	return nil
 }
+
+// writeWAVWithTags writes audio data to a PCM WAV file with a LIST/INFO chunk
+// carrying the given tags, placed between the fmt and data chunks.
+func writeWAVWithTags(fileName string, audioData []byte, numChannels uint16, sampleRate uint32, bitsPerSample uint16, tags map[string]string) error {
+	fOut, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer fOut.Close()
+
+	info := metadata.EncodeWAVInfo(tags)
+	blockAlign := numChannels * bitsPerSample / 8
+	dataSize := uint32(len(audioData))
+	riffSize := 4 + (8 + 16) + uint32(len(info)) + 8 + dataSize + dataSize%2
+
+	w := bufio.NewWriter(fOut)
+	w.WriteString("RIFF")
+	binary.Write(w, binary.LittleEndian, riffSize)
+	w.WriteString("WAVE")
+
+	w.WriteString("fmt ")
+	binary.Write(w, binary.LittleEndian, uint32(16))
+	binary.Write(w, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(w, binary.LittleEndian, numChannels)
+	binary.Write(w, binary.LittleEndian, sampleRate)
+	binary.Write(w, binary.LittleEndian, sampleRate*uint32(blockAlign))
+	binary.Write(w, binary.LittleEndian, blockAlign)
+	binary.Write(w, binary.LittleEndian, bitsPerSample)
+
+	w.Write(info)
+
+	w.WriteString("data")
+	binary.Write(w, binary.LittleEndian, dataSize)
+	w.Write(audioData)
+	if dataSize%2 == 1 {
+		w.WriteByte(0)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write WAV data: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/drgolem/audiokit/pkg/types"
+	"github.com/drgolem/musictools/internal/audiotest"
+	"github.com/drgolem/musictools/internal/decoders/wav"
+	"github.com/drgolem/musictools/internal/metadata"
+)
+
+// lengthDecoder decodes samples frames of 16-bit mono whose n-th sample is
+// n, and reports header as its length.
+type lengthDecoder struct {
+	samples, header, pos int
+}
+
+func (d *lengthDecoder) Open(string) error          { return nil }
+func (d *lengthDecoder) Close() error               { return nil }
+func (d *lengthDecoder) GetFormat() (int, int, int) { return 8000, 1, 16 }
+
+func (d *lengthDecoder) TotalSamples() (int64, error) {
+	return int64(d.header), nil
+}
+
+func (d *lengthDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.pos >= d.samples {
+		return 0, io.EOF
+	}
+	n := min(samples, len(audio)/2, d.samples-d.pos)
+	for i := range n {
+		v := uint16(d.pos + i)
+		audio[2*i], audio[2*i+1] = byte(v), byte(v>>8)
+	}
+	d.pos += n
+	return n, nil
+}
+
+func TestDecodeAllAudioCapped(t *testing.T) {
+	format := types.FrameFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 16}
+
+	tests := []struct {
+		name     string
+		samples  int // in the stream
+		header   int // length the decoder reports
+		maxBytes int
+		want     int // sample frames returned
+		wantErr  error
+	}{
+		{"under the cap", 1000, 1000, 4000, 1000, nil},
+		{"at the cap", 2000, 2000, 4000, 2000, nil},
+		{"over the cap", 10000, 10000, 4000, 2000, errTruncated},
+		{"cap rounded to whole frames", 10000, 10000, 4001, 2000, errTruncated},
+		{"header understates the length", 10000, 100, 4000, 2000, errTruncated},
+		{"header overstates the length", 1000, 1 << 40, 4000, 1000, nil},
+		{"negative header length", 1000, -5, 4000, 1000, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := &lengthDecoder{samples: tt.samples, header: tt.header}
+			audio, n, err := decodeAllAudioCapped(dec, format, tt.maxBytes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if n != tt.want || len(audio) != 2*tt.want {
+				t.Fatalf("decoded %d samples in %d bytes, want %d", n, len(audio), tt.want)
+			}
+			for i := range n {
+				if v := int(audio[2*i]) | int(audio[2*i+1])<<8; v != i&0xFFFF {
+					t.Fatalf("sample %d = %d", i, v)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeChunks(t *testing.T) {
+	format := types.FrameFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 16}
+
+	tests := []struct {
+		name       string
+		samples    int
+		chunk      int
+		wantChunks []int // bytes passed to each callback
+	}{
+		{"several chunks", 10000, 4096, []int{8192, 8192, 3616}},
+		{"exact multiple", 8192, 4096, []int{8192, 8192}},
+		{"one short chunk", 10, 4096, []int{20}},
+		{"empty stream", 0, 4096, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chunks []int
+			next := 0
+			n, err := decodeChunks(&lengthDecoder{samples: tt.samples}, format, tt.chunk, func(chunk []byte) error {
+				chunks = append(chunks, len(chunk))
+				for i := 0; i+1 < len(chunk); i += 2 {
+					if v := int(chunk[i]) | int(chunk[i+1])<<8; v != next&0xFFFF {
+						return fmt.Errorf("sample %d = %d", next, v)
+					}
+					next++
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.samples || next != tt.samples {
+				t.Fatalf("decoded %d samples, callbacks saw %d, want %d", n, next, tt.samples)
+			}
+			if !slices.Equal(chunks, tt.wantChunks) {
+				t.Fatalf("chunk sizes = %v, want %v", chunks, tt.wantChunks)
+			}
+		})
+	}
+}
+
+func TestDecodeChunksStopsOnCallbackError(t *testing.T) {
+	format := types.FrameFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 16}
+	errStop := errors.New("stop")
+	calls := 0
+	n, err := decodeChunks(&lengthDecoder{samples: 10000}, format, 1000, func([]byte) error {
+		if calls++; calls == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || n != 2000 || calls != 3 {
+		t.Fatalf("decodeChunks = %d, %v after %d calls, want 2000, %v after 3", n, err, calls, errStop)
+	}
+}
+
+func TestDecodeChunksInvalidLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		format types.FrameFormat
+		chunk  int
+	}{
+		{"no channels", types.FrameFormat{SampleRate: 8000, BitsPerSample: 16}, 4096},
+		{"no chunk", types.FrameFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 16}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeChunks(&lengthDecoder{samples: 10}, tt.format, tt.chunk, func([]byte) error { return nil }); err == nil {
+				t.Fatal("decodeChunks succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestPlanTransform(t *testing.T) {
+	tests := []struct {
+		name                   string
+		inRate, outRate        int
+		channels, bps          int
+		inSamples              int
+		mono, raw              bool
+		peak                   float64
+		wantSamples, wantChans int
+		wantBytes              int64
+		wantClipRisk           bool
+	}{
+		{"same rate", 44100, 44100, 2, 16, 44100, false, false, 0.5, 44100, 2, 44 + 44100*4, false},
+		{"upsample", 44100, 48000, 2, 16, 44100, false, false, 0.5, 48000, 2, 44 + 48000*4, false},
+		{"downsample rounds up", 48000, 44100, 2, 16, 1000, false, false, 0.5, 919, 2, 44 + 919*4, false},
+		{"mono", 44100, 44100, 2, 16, 100, true, false, 0.5, 100, 1, 44 + 100*2, false},
+		{"mono input stays mono", 44100, 44100, 1, 16, 100, true, false, 0.5, 100, 1, 44 + 100*2, false},
+		{"raw has no header", 44100, 44100, 2, 24, 100, false, true, 0.5, 100, 2, 100 * 6, false},
+		{"loud input resampled", 44100, 48000, 2, 16, 100, false, false, 0.99, 109, 2, 44 + 109*4, true},
+		{"loud input not resampled", 44100, 44100, 2, 16, 100, false, false, 1, 100, 2, 44 + 100*4, false},
+		{"empty input", 44100, 48000, 2, 16, 0, false, false, 0, 0, 2, 44, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := planTransform(tt.inRate, tt.outRate, tt.channels, tt.bps, tt.inSamples, tt.mono, tt.raw, tt.peak)
+			if p.outSamples != tt.wantSamples || p.outChannels != tt.wantChans || p.outBytes != tt.wantBytes {
+				t.Errorf("plan = %d samples, %d channels, %d bytes, want %d, %d, %d",
+					p.outSamples, p.outChannels, p.outBytes, tt.wantSamples, tt.wantChans, tt.wantBytes)
+			}
+			if p.clipRisk != tt.wantClipRisk {
+				t.Errorf("clipRisk = %v, want %v", p.clipRisk, tt.wantClipRisk)
+			}
+			if want := float64(tt.outRate) / float64(tt.inRate); p.ratio != want {
+				t.Errorf("ratio = %v, want %v", p.ratio, want)
+			}
+		})
+	}
+}
+
+func TestTransformDryRunWritesNothing(t *testing.T) {
+	pcm := make([]byte, 4*1000)
+	in := audiotest.WriteFile(t, "in.wav", audiotest.WAVFile(
+		audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(1, 2, 44100, 16)),
+		audiotest.RIFFChunk("data", pcm),
+	))
+	out := filepath.Join(t.TempDir(), "out.wav")
+
+	flags := transformCmd.Flags()
+	for name, value := range map[string]string{"dry-run": "true", "out": out, "new-samplerate": "48000"} {
+		old := flags.Lookup(name).Value.String()
+		if err := flags.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { flags.Set(name, old) })
+	}
+
+	runTransform(transformCmd, []string{in})
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("output after a dry run: %v", err)
+	}
+}
+
+func TestWriteWAVWithTags(t *testing.T) {
+	tags := map[string]string{
+		metadata.Title:  "Song",
+		metadata.Artist: "Band",
+		metadata.Album:  "Odd",
+	}
+	tests := []struct {
+		name     string
+		channels int
+		bps      int
+		audio    []byte
+	}{
+		{"16-bit stereo", 2, 16, audiotest.PCM16(1, -1, 300, -300, 32767, -32768)},
+		// An odd-sized data chunk needs a pad byte after it.
+		{"8-bit mono odd size", 1, 8, []byte{0x80, 0x00, 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "out.wav")
+			if err := writeWAVWithTags(fileName, tt.audio, uint16(tt.channels), 22050, uint16(tt.bps), tags); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.ReadFile(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := binary.LittleEndian.Uint32(file[4:]); int(size) != len(file)-8 {
+				t.Errorf("RIFF size = %d, want %d", size, len(file)-8)
+			}
+
+			got, err := metadata.ReadWAVInfo(bytes.NewReader(file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tags {
+				if got[key] != want {
+					t.Errorf("tag %s = %q, want %q", key, got[key], want)
+				}
+			}
+
+			dec := wav.NewDecoder()
+			if err := dec.Open(fileName); err != nil {
+				t.Fatal(err)
+			}
+			defer dec.Close()
+			if rate, channels, bps := dec.GetFormat(); rate != 22050 || channels != tt.channels || bps != tt.bps {
+				t.Fatalf("GetFormat = %d, %d, %d, want 22050, %d, %d", rate, channels, bps, tt.channels, tt.bps)
+			}
+			frameSize := tt.channels * tt.bps / 8
+			buf := make([]byte, len(tt.audio)+frameSize)
+			n, err := dec.DecodeSamples(len(buf)/frameSize, buf)
+			if err != nil && err != io.EOF {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf[:n*frameSize], tt.audio) {
+				t.Errorf("decoded % x, want % x", buf[:n*frameSize], tt.audio)
+			}
+		})
+	}
+}
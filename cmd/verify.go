@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/types"
+	"github.com/drgolem/musictools/internal/decoders"
+	"github.com/drgolem/musictools/internal/metadata"
+
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <audio_file> [audio_file...]",
+	Short: "Check that audio files decode cleanly",
+	Long: `Decode each file to the end without playing it and report decode errors
+and duration.
+
+For FLAC files the decoded sample count is also checked against STREAMINFO,
+and the audio MD5 signature is checked when the encoder stored one (16, 24
+and 32-bit streams).
+
+Exits with a non-zero status if any file fails.
+
+Examples:
+  musictools verify song.flac
+  musictools verify music/*.mp3 music/*.flac`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// verifyChunkSamples is the number of sample frames decoded per chunk.
+const verifyChunkSamples = 16384
+
+// verifyResult is the outcome of decoding one file.
+type verifyResult struct {
+	samples  int
+	duration time.Duration
+	md5      string // "match", "mismatch" or "not checked"
+	err      error
+}
+
+func runVerify(cmd *cobra.Command, args []string) {
+	failed := 0
+	for _, fileName := range args {
+		res := verifyFile(fileName)
+		if res.err != nil {
+			slog.Error("FAIL", "file", fileName, "decoded_samples", res.samples, "error", res.err)
+			failed++
+			continue
+		}
+		slog.Info("PASS",
+			"file", fileName,
+			"samples", res.samples,
+			"duration", res.duration.Round(time.Millisecond),
+			"md5", res.md5)
+	}
+
+	slog.Info("Verify complete", "files", len(args), "failed", failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// verifyFile decodes fileName fully and checks it against its FLAC
+// STREAMINFO, if any.
+func verifyFile(fileName string) verifyResult {
+	res := verifyResult{md5: "not checked"}
+
+	var streamInfo *metadata.FLACStreamInfo
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".flac", ".fla":
+		si, err := readFLACStreamInfo(fileName)
+		if err != nil {
+			res.err = err
+			return res
+		}
+		streamInfo = si
+	}
+
+	// Decode FLAC at its native depth so the MD5 signature can be checked.
+	bps := 0
+	if streamInfo != nil && flacMD5Checkable(streamInfo) {
+		bps = streamInfo.BitsPerSample
+	}
+	dec, err := decoders.NewRegistry().NewFromFile(fileName, bps)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer dec.Close()
+
+	rate, channels, bitsPerSample := dec.GetFormat()
+	format := types.FrameFormat{SampleRate: rate, Channels: channels, BitsPerSample: bitsPerSample}
+
+	checkMD5 := streamInfo != nil && streamInfo.HasMD5() &&
+		flacMD5Checkable(streamInfo) && bitsPerSample == streamInfo.BitsPerSample
+	hash := md5.New()
+
+	res.samples, res.err = decodeChunks(dec, format, verifyChunkSamples, func(chunk []byte) error {
+		if checkMD5 {
+			hash.Write(chunk)
+		}
+		return nil
+	})
+	if rate > 0 {
+		res.duration = time.Duration(int64(res.samples) * int64(time.Second) / int64(rate))
+	}
+	if res.err != nil {
+		return res
+	}
+
+	if streamInfo != nil && streamInfo.TotalSamples > 0 && int64(res.samples) != streamInfo.TotalSamples {
+		res.err = fmt.Errorf("decoded %d samples, STREAMINFO declares %d (truncated or corrupt)",
+			res.samples, streamInfo.TotalSamples)
+		return res
+	}
+
+	if checkMD5 {
+		if bytes.Equal(hash.Sum(nil), streamInfo.MD5[:]) {
+			res.md5 = "match"
+		} else {
+			res.md5 = "mismatch"
+			res.err = fmt.Errorf("audio MD5 does not match STREAMINFO")
+		}
+	}
+
+	return res
+}
+
+// flacMD5Checkable reports whether the decoder's output for the stream is
+// byte-identical to the data FLAC hashes. FLAC hashes whole-byte signed
+// little-endian samples, which only matches decoder output for 16, 24 and
+// 32-bit streams.
+func flacMD5Checkable(si *metadata.FLACStreamInfo) bool {
+	switch si.BitsPerSample {
+	case 16, 24, 32:
+		return true
+	default:
+		return false
+	}
+}
+
+// readFLACStreamInfo reads the STREAMINFO block of a FLAC file.
+func readFLACStreamInfo(fileName string) (*metadata.FLACStreamInfo, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return metadata.ReadFLACStreamInfo(f)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+// testWAV returns a stereo 16-bit 8 kHz WAV file of frames silent samples.
+func testWAV(frames int) []byte {
+	return audiotest.WAVFile(
+		audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(1, 2, 8000, 16)),
+		audiotest.RIFFChunk("data", make([]byte, 4*frames)),
+	)
+}
+
+func TestVerifyFile(t *testing.T) {
+	res := verifyFile(audiotest.WriteFile(t, "good.wav", testWAV(8000)))
+	if res.err != nil {
+		t.Fatalf("verifyFile: %v", res.err)
+	}
+	if res.samples != 8000 || res.duration != time.Second {
+		t.Errorf("verifyFile = %d samples, %v, want 8000, 1s", res.samples, res.duration)
+	}
+	if res.md5 != "not checked" {
+		t.Errorf("md5 = %q, want \"not checked\" for a WAV file", res.md5)
+	}
+}
+
+func TestVerifyFileTruncated(t *testing.T) {
+	// Cut the file short of the size its data chunk declares.
+	file := testWAV(8000)
+	res := verifyFile(audiotest.WriteFile(t, "truncated.wav", file[:len(file)-4*3000]))
+	if res.err == nil {
+		t.Fatal("verifyFile passed a truncated file")
+	}
+	if res.samples != 5000 {
+		t.Errorf("decoded %d samples before the error, want 5000", res.samples)
+	}
+}
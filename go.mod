@@ -5,6 +5,7 @@ go 1.26
 require (
 	github.com/drgolem/audiokit v0.0.0-20260309054244-8e6b8b01844b
 	github.com/drgolem/go-portaudio v0.0.0-20260309010403-03a2d827824b
+	github.com/imcarsen/go-mp3 v0.3.7
 	github.com/spf13/cobra v1.10.2
 	github.com/youpy/go-wav v0.3.2
 	github.com/zaf/resample v1.5.0
@@ -14,7 +15,6 @@ require (
 	github.com/drgolem/go-flac v0.0.0-20260309053727-b159fefb5931 // indirect
 	github.com/drgolem/go-opus v0.0.0-20260309031855-220c97a6ac4a // indirect
 	github.com/drgolem/ringbuffer v0.0.0-20260212040143-40ad42d6ca09 // indirect
-	github.com/imcarsen/go-mp3 v0.3.7 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/jfreymuth/oggvorbis v1.0.5 // indirect
 	github.com/jfreymuth/vorbis v1.0.2 // indirect
@@ -0,0 +1,30 @@
+package audioproc
+
+import "fmt"
+
+// WidenBitDepth converts interleaved audio from fromBits to a higher toBits
+// by scaling each sample up, which is lossless. The result is a new buffer.
+// Audio already at toBits is returned unchanged.
+func WidenBitDepth(audio []byte, fromBits, toBits int) ([]byte, error) {
+	if err := checkBitDepth(fromBits); err != nil {
+		return nil, err
+	}
+	if err := checkBitDepth(toBits); err != nil {
+		return nil, err
+	}
+	if toBits < fromBits {
+		return nil, fmt.Errorf("cannot widen %d-bit audio to %d bits", fromBits, toBits)
+	}
+	if toBits == fromBits {
+		return audio, nil
+	}
+
+	inBytes, outBytes := fromBits/8, toBits/8
+	shift := toBits - fromBits
+	count := len(audio) / inBytes
+	out := make([]byte, count*outBytes)
+	for i := 0; i < count; i++ {
+		writeSample(out[i*outBytes:], outBytes, readSample(audio[i*inBytes:], inBytes)<<shift)
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package audioproc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestWidenBitDepth(t *testing.T) {
+	tests := []struct {
+		name             string
+		fromBits, toBits int
+		in, want         []byte
+	}{
+		{"8 to 16", 8, 16, []byte{0x80, 0x81, 0x00, 0xFF}, audiotest.PCM16(0, 256, -32768, 127*256)},
+		{"16 to 24", 16, 24, audiotest.PCM16(1, -1, 32767), audiotest.PCM24(256, -256, 32767*256)},
+		{"16 to 32", 16, 32, audiotest.PCM16(-32768, 1), audiotest.PCM32(-1<<31, 1<<16)},
+		{"24 to 32", 24, 32, audiotest.PCM24(-1, 1<<23-1), audiotest.PCM32(-256, (1<<23-1)*256)},
+		{"same depth", 16, 16, audiotest.PCM16(5, -5), audiotest.PCM16(5, -5)},
+		{"partial sample dropped", 16, 24, append(audiotest.PCM16(1), 0x7F), audiotest.PCM24(256)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WidenBitDepth(tt.in, tt.fromBits, tt.toBits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWidenThenReduceIsLossless(t *testing.T) {
+	in := audiotest.PCM16(0, 1, -1, 12345, -32768, 32767)
+	wide, err := WidenBitDepth(in, 16, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReduceBitDepth(wide, 1, 24, 16, ShapingNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, in) {
+		t.Fatalf("got % x, want % x", got, in)
+	}
+}
+
+func TestWidenBitDepthErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		fromBits, toBits int
+	}{
+		{"narrowing", 24, 16},
+		{"unsupported source depth", 12, 16},
+		{"unsupported target depth", 16, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := WidenBitDepth(make([]byte, 12), tt.fromBits, tt.toBits); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
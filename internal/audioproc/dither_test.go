@@ -0,0 +1,161 @@
+package audioproc
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestParseShapingKind(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ShapingKind
+	}{
+		{"none", ShapingNone},
+		{"tpdf", ShapingTPDF},
+		{"shaped", ShapingNoiseShaped},
+	}
+	for _, tt := range tests {
+		got, err := ParseShapingKind(tt.in)
+		if err != nil {
+			t.Fatalf("ParseShapingKind(%q): %v", tt.in, err)
+		}
+		if got != tt.want || got.String() != tt.in {
+			t.Errorf("ParseShapingKind(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "TPDF", "triangular"} {
+		if _, err := ParseShapingKind(in); err == nil {
+			t.Errorf("ParseShapingKind(%q) succeeded, want error", in)
+		}
+	}
+	if got := ShapingKind(7).String(); got != "ShapingKind(7)" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestReduceBitDepthNoShaping(t *testing.T) {
+	tests := []struct {
+		name                       string
+		channels, fromBits, toBits int
+		in, want                   []byte
+	}{
+		{"16 to 8", 1, 16, 8, audiotest.PCM16(0, 256, -256, 383, 384, 32767, -32768), []byte{0x80, 0x81, 0x7F, 0x81, 0x82, 0xFF, 0x00}},
+		{"24 to 16", 1, 24, 16, audiotest.PCM24(256, -256, 127, 128, 1<<23-1, -1<<23), audiotest.PCM16(1, -1, 0, 1, 32767, -32768)},
+		{"32 to 24", 1, 32, 24, audiotest.PCM32(256, -512), audiotest.PCM24(1, -2)},
+		{"partial frame dropped", 2, 16, 8, audiotest.PCM16(256, 512, 768), []byte{0x81, 0x82}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReduceBitDepth(tt.in, tt.channels, tt.fromBits, tt.toBits, ShapingNone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReduceBitDepthDither(t *testing.T) {
+	// A constant halfway between two output values: plain rounding always
+	// goes up, dither must average out to the input.
+	const samples = 20000
+	vs := make([]int16, samples)
+	for i := range vs {
+		vs[i] = 128 + 256*10
+	}
+	in := audiotest.PCM16(vs...)
+	for _, shaping := range []ShapingKind{ShapingTPDF, ShapingNoiseShaped} {
+		t.Run(shaping.String(), func(t *testing.T) {
+			out, err := ReduceBitDepth(in, 2, 16, 8, shaping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out) != samples {
+				t.Fatalf("got %d samples, want %d", len(out), samples)
+			}
+			var sum float64
+			for _, b := range out {
+				v := int(b) - 128
+				if v < 9 || v > 12 {
+					t.Fatalf("sample %d outside the dither range of 10.5", v)
+				}
+				sum += float64(v)
+			}
+			if mean := sum / samples; math.Abs(mean-10.5) > 0.05 {
+				t.Errorf("mean %.3f, want 10.5", mean)
+			}
+		})
+	}
+}
+
+func TestReduceBitDepthNoiseSpectrum(t *testing.T) {
+	// Split the requantization error into bands with a two-tap sum (low
+	// pass) and difference (high pass). TPDF noise is white, so both bands
+	// carry about the same energy; first-order shaping moves it up, leaving
+	// the low band with about a third of the high band's energy.
+	const samples = 20000
+	vs := make([]int16, samples)
+	for i := range vs {
+		vs[i] = int16(3000 * math.Sin(2*math.Pi*float64(i)/441))
+	}
+	in := audiotest.PCM16(vs...)
+
+	bandRatio := func(shaping ShapingKind) float64 {
+		out, err := ReduceBitDepth(in, 1, 16, 8, shaping)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var low, high, prev float64
+		for i, b := range out {
+			e := float64(int(b)-128) - float64(vs[i])/256
+			if i > 0 {
+				low += (e + prev) * (e + prev)
+				high += (e - prev) * (e - prev)
+			}
+			prev = e
+		}
+		return low / high
+	}
+
+	if r := bandRatio(ShapingTPDF); r < 0.8 || r > 1.25 {
+		t.Errorf("TPDF low/high band error energy = %.2f, want about 1", r)
+	}
+	if r := bandRatio(ShapingNoiseShaped); r > 0.5 {
+		t.Errorf("shaped low/high band error energy = %.2f, want about 1/3", r)
+	}
+}
+
+func TestReduceBitDepthSameDepth(t *testing.T) {
+	in := audiotest.PCM16(1, 2)
+	got, err := ReduceBitDepth(in, 1, 16, 16, ShapingTPDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, in) {
+		t.Fatalf("got % x, want % x", got, in)
+	}
+}
+
+func TestReduceBitDepthErrors(t *testing.T) {
+	tests := []struct {
+		name                       string
+		channels, fromBits, toBits int
+	}{
+		{"widening", 1, 16, 24},
+		{"unsupported source depth", 1, 20, 16},
+		{"unsupported target depth", 1, 24, 12},
+		{"no channels", 0, 24, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReduceBitDepth(make([]byte, 12), tt.channels, tt.fromBits, tt.toBits, ShapingNone); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
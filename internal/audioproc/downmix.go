@@ -0,0 +1,34 @@
+package audioproc
+
+import "fmt"
+
+// DownmixToMono averages the channels of each interleaved frame of audio
+// into a single sample. The mono samples are written over the start of
+// audio, which is returned truncated to the mono length. A trailing partial
+// frame is dropped.
+func DownmixToMono(audio []byte, bitsPerSample, channels int) ([]byte, error) {
+	if err := checkBitDepth(bitsPerSample); err != nil {
+		return nil, err
+	}
+	if channels <= 0 {
+		return nil, fmt.Errorf("invalid channel count: %d", channels)
+	}
+
+	bytesPerSample := bitsPerSample / 8
+	frameSize := channels * bytesPerSample
+	frames := len(audio) / frameSize
+	if channels == 1 {
+		return audio[:frames*frameSize], nil
+	}
+
+	// Each mono sample is written at or before the frame it is read from,
+	// so working in place is safe.
+	for i := 0; i < frames; i++ {
+		var sum int64
+		for ch := 0; ch < channels; ch++ {
+			sum += int64(readSample(audio[i*frameSize+ch*bytesPerSample:], bytesPerSample))
+		}
+		writeSample(audio[i*bytesPerSample:], bytesPerSample, clampSample(float64(sum)/float64(channels), bitsPerSample))
+	}
+	return audio[:frames*bytesPerSample], nil
+}
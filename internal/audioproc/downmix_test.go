@@ -0,0 +1,56 @@
+package audioproc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestDownmixToMono(t *testing.T) {
+	tests := []struct {
+		name     string
+		bps      int
+		channels int
+		in       []byte
+		want     []byte
+	}{
+		{"mono unchanged", 16, 1, audiotest.PCM16(1, -2, 3), audiotest.PCM16(1, -2, 3)},
+		{"stereo", 16, 2, audiotest.PCM16(100, 200, -100, -300, 32767, 32767), audiotest.PCM16(150, -200, 32767)},
+		{"rounds away from zero", 16, 2, audiotest.PCM16(1, 2, -1, -2), audiotest.PCM16(2, -2)},
+		{"opposite phase cancels", 16, 2, audiotest.PCM16(1000, -1000), audiotest.PCM16(0)},
+		{"5.1", 16, 6, audiotest.PCM16(6, 6, 6, 0, 0, 0), audiotest.PCM16(3)},
+		{"8-bit", 8, 2, []byte{0x90, 0xB0}, []byte{0xA0}},
+		{"24-bit", 24, 2, audiotest.PCM24(1<<23-1, 1<<23-1, -1<<23, -1<<23), audiotest.PCM24(1<<23-1, -1<<23)},
+		{"32-bit", 32, 2, audiotest.PCM32(1<<30, 1<<30), audiotest.PCM32(1 << 30)},
+		{"partial frame dropped", 16, 2, audiotest.PCM16(10, 20, 30), audiotest.PCM16(15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DownmixToMono(bytes.Clone(tt.in), tt.bps, tt.channels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownmixToMonoErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		bps, channels int
+	}{
+		{"unsupported depth", 12, 2},
+		{"no channels", 16, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DownmixToMono(audiotest.PCM16(1, 2), tt.bps, tt.channels); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package audioproc
+
+import "fmt"
+
+// SwapEndian reverses the byte order of every sample in audio in place,
+// turning little-endian PCM into big-endian PCM and back. 8-bit audio has
+// no byte order and is left unchanged.
+func SwapEndian(audio []byte, bitsPerSample int) error {
+	if err := checkBitDepth(bitsPerSample); err != nil {
+		return err
+	}
+
+	bytesPerSample := bitsPerSample / 8
+	if len(audio)%bytesPerSample != 0 {
+		return fmt.Errorf("audio length %d is not a multiple of the %d-byte sample size", len(audio), bytesPerSample)
+	}
+	if bytesPerSample == 1 {
+		return nil
+	}
+
+	for offset := 0; offset < len(audio); offset += bytesPerSample {
+		s := audio[offset : offset+bytesPerSample]
+		for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+			s[i], s[j] = s[j], s[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package audioproc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSwapEndian(t *testing.T) {
+	tests := []struct {
+		name string
+		bps  int
+		in   []byte
+		want []byte
+	}{
+		{"8-bit unchanged", 8, []byte{1, 2, 3}, []byte{1, 2, 3}},
+		{"16-bit", 16, []byte{1, 2, 3, 4}, []byte{2, 1, 4, 3}},
+		{"24-bit", 24, []byte{1, 2, 3, 4, 5, 6}, []byte{3, 2, 1, 6, 5, 4}},
+		{"32-bit", 32, []byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{4, 3, 2, 1, 8, 7, 6, 5}},
+		{"empty", 16, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bytes.Clone(tt.in)
+			if err := SwapEndian(got, tt.bps); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+			if err := SwapEndian(got, tt.bps); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.in) {
+				t.Fatalf("swapping twice gave % x, want % x", got, tt.in)
+			}
+		})
+	}
+}
+
+func TestSwapEndianErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		bps  int
+		in   []byte
+	}{
+		{"partial 16-bit sample", 16, []byte{1, 2, 3}},
+		{"partial 24-bit sample", 24, []byte{1, 2, 3, 4}},
+		{"unsupported depth", 12, []byte{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SwapEndian(tt.in, tt.bps); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package audioproc
+
+// ToFloat64 converts interleaved PCM samples to floats in [-1, 1), keeping
+// the interleaving. A trailing partial sample is dropped.
+func ToFloat64(audio []byte, bitsPerSample int) ([]float64, error) {
+	if err := checkBitDepth(bitsPerSample); err != nil {
+		return nil, err
+	}
+
+	bytesPerSample := bitsPerSample / 8
+	scale := float64(int64(1) << (bitsPerSample - 1))
+	out := make([]float64, len(audio)/bytesPerSample)
+	for i := range out {
+		out[i] = float64(readSample(audio[i*bytesPerSample:], bytesPerSample)) / scale
+	}
+	return out, nil
+}
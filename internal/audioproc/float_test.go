@@ -0,0 +1,40 @@
+package audioproc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestToFloat64(t *testing.T) {
+	tests := []struct {
+		name string
+		bps  int
+		in   []byte
+		want []float64
+	}{
+		{"16-bit", 16, audiotest.PCM16(0, 16384, -32768), []float64{0, 0.5, -1}},
+		{"8-bit", 8, []byte{0x80, 0xC0, 0x00}, []float64{0, 0.5, -1}},
+		{"24-bit", 24, audiotest.PCM24(1<<21, -1<<23), []float64{0.25, -1}},
+		{"32-bit", 32, audiotest.PCM32(-1<<30, 1<<29), []float64{-0.5, 0.25}},
+		{"partial sample dropped", 16, append(audiotest.PCM16(16384), 0x01), []float64{0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToFloat64(tt.in, tt.bps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToFloat64UnsupportedDepth(t *testing.T) {
+	if _, err := ToFloat64(audiotest.PCM16(1), 12); err == nil {
+		t.Fatal("got nil error")
+	}
+}
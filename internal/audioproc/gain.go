@@ -0,0 +1,54 @@
+package audioproc
+
+import (
+	"fmt"
+	"math"
+)
+
+// ApplyChannelGains scales each interleaved channel of audio by the matching
+// entry in gains, clamping the result to the range of bitsPerSample.
+// The number of gains is the channel count of the audio.
+func ApplyChannelGains(audio []byte, bitsPerSample int, gains []float64) error {
+	if err := checkBitDepth(bitsPerSample); err != nil {
+		return err
+	}
+	if len(gains) == 0 {
+		return fmt.Errorf("no channel gains given")
+	}
+
+	bytesPerSample := bitsPerSample / 8
+	frameSize := len(gains) * bytesPerSample
+
+	for offset := 0; offset+frameSize <= len(audio); offset += frameSize {
+		for ch, gain := range gains {
+			if gain == 1.0 {
+				continue
+			}
+			sampleOffset := offset + ch*bytesPerSample
+			s := readSample(audio[sampleOffset:], bytesPerSample)
+			writeSample(audio[sampleOffset:], bytesPerSample, clampSample(float64(s)*gain, bitsPerSample))
+		}
+	}
+
+	return nil
+}
+
+// ApplyGain scales every sample of audio by gain, clamping the result to the
+// range of bitsPerSample.
+func ApplyGain(audio []byte, bitsPerSample int, gain float64) error {
+	return ApplyChannelGains(audio, bitsPerSample, []float64{gain})
+}
+
+// DBToGain converts a gain in decibels to a linear amplitude factor.
+func DBToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// BalanceGains maps a stereo balance in the range [-1, 1] to left/right gains.
+// -1 is full left, 0 is centered (both channels at unity), 1 is full right.
+func BalanceGains(balance float64) []float64 {
+	balance = math.Max(-1, math.Min(1, balance))
+	left := math.Min(1, 1-balance)
+	right := math.Min(1, 1+balance)
+	return []float64{left, right}
+}
@@ -0,0 +1,163 @@
+package audioproc
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestApplyChannelGains(t *testing.T) {
+	tests := []struct {
+		name  string
+		bps   int
+		in    []byte
+		gains []float64
+		want  []byte
+	}{
+		{"unity", 16, audiotest.PCM16(100, -100, 200, -200), []float64{1, 1}, audiotest.PCM16(100, -100, 200, -200)},
+		{"left muted", 16, audiotest.PCM16(100, -100, 200, -200), []float64{0, 1}, audiotest.PCM16(0, -100, 0, -200)},
+		{"rounds away from zero", 16, audiotest.PCM16(101, -101), []float64{0.5, 0.5}, audiotest.PCM16(51, -51)},
+		{"clamped", 16, audiotest.PCM16(20000, -20000), []float64{2, 2}, audiotest.PCM16(32767, -32768)},
+		{"8-bit", 8, []byte{0x80, 0x90, 0x70}, []float64{0.5}, []byte{0x80, 0x88, 0x78}},
+		{"24-bit", 24, audiotest.PCM24(1<<22, -1<<22), []float64{4}, audiotest.PCM24(1<<23-1, -1<<23)},
+		{"32-bit", 32, audiotest.PCM32(1<<30, -1<<30), []float64{0.5}, audiotest.PCM32(1<<29, -1<<29)},
+		{"partial frame left alone", 16, audiotest.PCM16(100, -100, 200), []float64{0, 0}, audiotest.PCM16(0, 0, 200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bytes.Clone(tt.in)
+			if err := ApplyChannelGains(got, tt.bps, tt.gains); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyChannelGainsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		bps   int
+		gains []float64
+	}{
+		{"no gains", 16, nil},
+		{"12-bit", 12, []float64{1}},
+		{"0-bit", 0, []float64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ApplyChannelGains(audiotest.PCM16(1, 2), tt.bps, tt.gains); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
+
+func TestApplyGain(t *testing.T) {
+	got := audiotest.PCM16(1000, -1000, 30000)
+	if err := ApplyGain(got, 16, 2); err != nil {
+		t.Fatal(err)
+	}
+	if want := audiotest.PCM16(2000, -2000, 32767); !bytes.Equal(got, want) {
+		t.Fatalf("got % x, want % x", got, want)
+	}
+}
+
+func TestDBToGain(t *testing.T) {
+	tests := []struct {
+		db   float64
+		want float64
+	}{
+		{0, 1},
+		{20, 10},
+		{-20, 0.1},
+		{6.0206, 2},
+		{-6.0206, 0.5},
+	}
+	for _, tt := range tests {
+		if got := DBToGain(tt.db); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("DBToGain(%v) = %v, want %v", tt.db, got, tt.want)
+		}
+	}
+}
+
+func TestBalanceGains(t *testing.T) {
+	tests := []struct {
+		balance     float64
+		left, right float64
+	}{
+		{0, 1, 1},
+		{-1, 1, 0},
+		{1, 0, 1},
+		{-0.5, 1, 0.5},
+		{0.25, 0.75, 1},
+		{-3, 1, 0},
+		{3, 0, 1},
+	}
+	for _, tt := range tests {
+		got := BalanceGains(tt.balance)
+		if len(got) != 2 || got[0] != tt.left || got[1] != tt.right {
+			t.Errorf("BalanceGains(%v) = %v, want [%v %v]", tt.balance, got, tt.left, tt.right)
+		}
+	}
+}
+
+func TestSampleRoundTrip(t *testing.T) {
+	tests := []struct {
+		bps  int
+		vals []int32
+	}{
+		{8, []int32{-128, -1, 0, 1, 127}},
+		{16, []int32{math.MinInt16, -1, 0, 1, math.MaxInt16}},
+		{24, []int32{-1 << 23, -1, 0, 1, 1<<23 - 1}},
+		{32, []int32{math.MinInt32, -1, 0, 1, math.MaxInt32}},
+	}
+	for _, tt := range tests {
+		bytesPerSample := tt.bps / 8
+		buf := make([]byte, bytesPerSample)
+		for _, v := range tt.vals {
+			writeSample(buf, bytesPerSample, v)
+			if got := readSample(buf, bytesPerSample); got != v {
+				t.Errorf("%d-bit: wrote %d, read %d", tt.bps, v, got)
+			}
+		}
+	}
+}
+
+func TestReadSample8BitUnsigned(t *testing.T) {
+	for b, want := range map[byte]int32{0x00: -128, 0x80: 0, 0xFF: 127} {
+		if got := readSample([]byte{b}, 1); got != want {
+			t.Errorf("readSample(%#x) = %d, want %d", b, got, want)
+		}
+	}
+}
+
+func TestClampSample(t *testing.T) {
+	tests := []struct {
+		v    float64
+		bps  int
+		want int32
+	}{
+		{0, 16, 0},
+		{1.4, 16, 1},
+		{1.5, 16, 2},
+		{-1.4, 16, -1},
+		{-1.5, 16, -2},
+		{40000, 16, math.MaxInt16},
+		{-40000, 16, math.MinInt16},
+		{200, 8, 127},
+		{-200, 8, -128},
+		{1e9, 24, 1<<23 - 1},
+		{1e10, 32, math.MaxInt32},
+		{-1e10, 32, math.MinInt32},
+	}
+	for _, tt := range tests {
+		if got := clampSample(tt.v, tt.bps); got != tt.want {
+			t.Errorf("clampSample(%v, %d) = %d, want %d", tt.v, tt.bps, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package audioproc
+
+// Peak returns the largest sample magnitude in audio as a fraction of full
+// scale, from 0 (silence) to 1 (a sample at the most negative value).
+func Peak(audio []byte, bitsPerSample int) (float64, error) {
+	if err := checkBitDepth(bitsPerSample); err != nil {
+		return 0, err
+	}
+
+	bytesPerSample := bitsPerSample / 8
+	var peak int64
+	for offset := 0; offset+bytesPerSample <= len(audio); offset += bytesPerSample {
+		v := int64(readSample(audio[offset:], bytesPerSample))
+		if v < 0 {
+			v = -v
+		}
+		peak = max(peak, v)
+	}
+
+	return float64(peak) / float64(int64(1)<<(bitsPerSample-1)), nil
+}
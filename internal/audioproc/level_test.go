@@ -0,0 +1,42 @@
+package audioproc
+
+import (
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestPeak(t *testing.T) {
+	tests := []struct {
+		name string
+		bps  int
+		in   []byte
+		want float64
+	}{
+		{"silence", 16, audiotest.PCM16(0, 0, 0), 0},
+		{"empty", 16, nil, 0},
+		{"half scale", 16, audiotest.PCM16(100, -16384, 200), 0.5},
+		{"most negative", 16, audiotest.PCM16(-32768, 32767), 1},
+		{"8-bit", 8, []byte{0x80, 0xC0, 0x90}, 0.5},
+		{"24-bit", 24, audiotest.PCM24(-1<<21, 1<<20), 0.25},
+		{"32-bit", 32, audiotest.PCM32(-1<<31, 0), 1},
+		{"partial sample ignored", 16, append(audiotest.PCM16(16384), 0xFF), 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Peak(tt.in, tt.bps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeakUnsupportedDepth(t *testing.T) {
+	if _, err := Peak(audiotest.PCM16(1), 12); err == nil {
+		t.Fatal("got nil error")
+	}
+}
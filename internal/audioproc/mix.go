@@ -0,0 +1,47 @@
+package audioproc
+
+import "fmt"
+
+// Mix sums equally long streams of interleaved PCM sample by sample, scaling
+// each stream by its entry in gains and clamping the sum to the range of
+// bitsPerSample. The streams must share a format; channel layout does not
+// matter since samples are summed position by position. A nil gains slice
+// mixes every stream at unity gain.
+func Mix(streams [][]byte, bitsPerSample int, gains []float64) ([]byte, error) {
+	if err := checkBitDepth(bitsPerSample); err != nil {
+		return nil, err
+	}
+	if len(streams) == 0 {
+		return nil, fmt.Errorf("no streams to mix")
+	}
+	if gains != nil && len(gains) != len(streams) {
+		return nil, fmt.Errorf("got %d gains for %d streams", len(gains), len(streams))
+	}
+
+	size := len(streams[0])
+	for i, s := range streams {
+		if len(s) != size {
+			return nil, fmt.Errorf("stream %d is %d bytes, stream 0 is %d bytes", i, len(s), size)
+		}
+	}
+
+	bytesPerSample := bitsPerSample / 8
+	if size%bytesPerSample != 0 {
+		return nil, fmt.Errorf("stream length %d is not a multiple of the %d-byte sample size", size, bytesPerSample)
+	}
+
+	out := make([]byte, size)
+	for offset := 0; offset < size; offset += bytesPerSample {
+		var sum float64
+		for i, s := range streams {
+			v := float64(readSample(s[offset:], bytesPerSample))
+			if gains != nil {
+				v *= gains[i]
+			}
+			sum += v
+		}
+		writeSample(out[offset:], bytesPerSample, clampSample(sum, bitsPerSample))
+	}
+
+	return out, nil
+}
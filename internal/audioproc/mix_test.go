@@ -0,0 +1,60 @@
+package audioproc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestMix(t *testing.T) {
+	tests := []struct {
+		name    string
+		bps     int
+		streams [][]byte
+		gains   []float64
+		want    []byte
+	}{
+		{"single stream", 16, [][]byte{audiotest.PCM16(100, -100)}, nil, audiotest.PCM16(100, -100)},
+		{"sum", 16, [][]byte{audiotest.PCM16(100, -100, 0), audiotest.PCM16(50, 50, -7)}, nil, audiotest.PCM16(150, -50, -7)},
+		{"gains", 16, [][]byte{audiotest.PCM16(100, -100), audiotest.PCM16(100, 100)}, []float64{0.5, 0.25}, audiotest.PCM16(75, -25)},
+		{"clamped", 16, [][]byte{audiotest.PCM16(30000, -30000), audiotest.PCM16(30000, -30000)}, nil, audiotest.PCM16(32767, -32768)},
+		{"8-bit", 8, [][]byte{{0x90, 0x70}, {0x90, 0x80}}, nil, []byte{0xA0, 0x70}},
+		{"24-bit", 24, [][]byte{audiotest.PCM24(1<<22, -5), audiotest.PCM24(1<<22, 3)}, nil, audiotest.PCM24(1<<23-1, -2)},
+		{"32-bit", 32, [][]byte{audiotest.PCM32(1<<30, -1), audiotest.PCM32(1<<30, -1)}, nil, audiotest.PCM32(1<<31-1, -2)},
+		{"empty streams", 16, [][]byte{nil, nil}, nil, []byte{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Mix(tt.streams, tt.bps, tt.gains)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMixErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		bps     int
+		streams [][]byte
+		gains   []float64
+	}{
+		{"no streams", 16, nil, nil},
+		{"unsupported depth", 12, [][]byte{audiotest.PCM16(1)}, nil},
+		{"gain count", 16, [][]byte{audiotest.PCM16(1), audiotest.PCM16(2)}, []float64{1}},
+		{"length mismatch", 16, [][]byte{audiotest.PCM16(1, 2), audiotest.PCM16(1)}, nil},
+		{"partial sample", 16, [][]byte{{1, 2, 3}, {1, 2, 3}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Mix(tt.streams, tt.bps, tt.gains); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package audioproc
+
+import "fmt"
+
+// 24-bit convention: on disk and in decoder output a 24-bit sample is three
+// little-endian bytes ("packed"). For processing it is widened to an int32
+// holding the same value, sign-extended, in the range [-2^23, 2^23-1] (it is
+// not shifted up to full 32-bit scale). Pack24 and Unpack24 convert between
+// the two; every helper in this package that reads or writes 24-bit samples
+// goes through them.
+
+// Unpack24 converts packed 24-bit little-endian samples to sign-extended
+// int32 values. A trailing partial sample is an error.
+func Unpack24(data []byte) ([]int32, error) {
+	if len(data)%3 != 0 {
+		return nil, fmt.Errorf("24-bit data length %d is not a multiple of 3", len(data))
+	}
+	out := make([]int32, len(data)/3)
+	for i := range out {
+		out[i] = unpack24(data[i*3:])
+	}
+	return out, nil
+}
+
+// Pack24 converts int32 values in the 24-bit range to packed 24-bit
+// little-endian samples. Values outside the range are clamped.
+func Pack24(samples []int32) []byte {
+	out := make([]byte, len(samples)*3)
+	for i, v := range samples {
+		pack24(out[i*3:], max(-1<<23, min(1<<23-1, v)))
+	}
+	return out
+}
+
+// unpack24 reads one packed 24-bit sample and sign-extends it.
+func unpack24(b []byte) int32 {
+	v := int32(b[0]) | int32(b[1])<<8 | int32(b[2])<<16
+	return v << 8 >> 8
+}
+
+// pack24 writes the low 24 bits of v as one packed sample.
+func pack24(b []byte, v int32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
@@ -0,0 +1,55 @@
+package audioproc
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestUnpack24(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want []int32
+	}{
+		{"empty", nil, []int32{}},
+		{"small values", []byte{0x01, 0x00, 0x00, 0xFF, 0xFF, 0xFF}, []int32{1, -1}},
+		{"extremes", []byte{0xFF, 0xFF, 0x7F, 0x00, 0x00, 0x80}, []int32{1<<23 - 1, -1 << 23}},
+		{"byte order", []byte{0x56, 0x34, 0x12}, []int32{0x123456}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unpack24(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			if back := Pack24(got); !bytes.Equal(back, tt.in) {
+				t.Fatalf("Pack24 gave % x, want % x", back, tt.in)
+			}
+		})
+	}
+}
+
+func TestUnpack24PartialSample(t *testing.T) {
+	for _, n := range []int{1, 2, 4, 5} {
+		if _, err := Unpack24(make([]byte, n)); err == nil {
+			t.Errorf("Unpack24 of %d bytes succeeded, want error", n)
+		}
+	}
+}
+
+func TestPack24Clamps(t *testing.T) {
+	got := Pack24([]int32{1 << 23, -1<<23 - 1, 1 << 30, -1 << 30})
+	want := []byte{
+		0xFF, 0xFF, 0x7F,
+		0x00, 0x00, 0x80,
+		0xFF, 0xFF, 0x7F,
+		0x00, 0x00, 0x80,
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got % x, want % x", got, want)
+	}
+}
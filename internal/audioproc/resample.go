@@ -0,0 +1,113 @@
+package audioproc
+
+import (
+	"fmt"
+	"math"
+)
+
+// Resampler filter parameters. The filter is a Blackman-windowed sinc
+// spanning resampleZeroCrossings zero crossings on each side, tabulated at
+// resamplePhases points per input sample and linearly interpolated.
+const (
+	resampleZeroCrossings = 16
+	resamplePhases        = 512
+)
+
+// Resample converts interleaved PCM audio from fromRate to toRate with a
+// pure-Go windowed-sinc resampler. When downsampling, the filter cutoff is
+// lowered to the output Nyquist frequency to prevent aliasing.
+//
+// It is slower than libsoxr and has a wider transition band and less
+// stopband attenuation (roughly 70 dB versus soxr's 100+ dB at high
+// quality), which is inaudible for most material but not transparent.
+// Output samples are clamped to the range of bitsPerSample.
+func Resample(audio []byte, bitsPerSample, channels, fromRate, toRate int) ([]byte, error) {
+	if err := checkBitDepth(bitsPerSample); err != nil {
+		return nil, err
+	}
+	if channels <= 0 {
+		return nil, fmt.Errorf("invalid channel count: %d", channels)
+	}
+	if fromRate <= 0 || toRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rates: %d -> %d", fromRate, toRate)
+	}
+	if fromRate == toRate {
+		return audio, nil
+	}
+
+	bytesPerSample := bitsPerSample / 8
+	frameSize := channels * bytesPerSample
+	inFrames := len(audio) / frameSize
+	outFrames := int(int64(inFrames) * int64(toRate) / int64(fromRate))
+
+	// Deinterleave to float planes.
+	planes := make([][]float64, channels)
+	for ch := range planes {
+		plane := make([]float64, inFrames)
+		for i := range plane {
+			plane[i] = float64(readSample(audio[i*frameSize+ch*bytesPerSample:], bytesPerSample))
+		}
+		planes[ch] = plane
+	}
+
+	step := float64(fromRate) / float64(toRate)
+	cutoff := math.Min(1, 1/step)
+	halfWidth := int(math.Ceil(resampleZeroCrossings / cutoff))
+	table := resampleFilterTable(cutoff, halfWidth)
+
+	out := make([]byte, outFrames*frameSize)
+	for i := 0; i < outFrames; i++ {
+		x := float64(i) * step
+		center := int(x)
+		frac := x - float64(center)
+
+		for ch, plane := range planes {
+			var sum float64
+			for k := center - halfWidth + 1; k <= center+halfWidth; k++ {
+				if k < 0 || k >= inFrames {
+					continue
+				}
+				sum += plane[k] * filterAt(table, math.Abs(float64(k-center)-frac))
+			}
+			writeSample(out[i*frameSize+ch*bytesPerSample:], bytesPerSample, clampSample(sum, bitsPerSample))
+		}
+	}
+
+	return out, nil
+}
+
+// resampleFilterTable tabulates the windowed-sinc filter for distances
+// 0..halfWidth input samples at resamplePhases points per sample.
+func resampleFilterTable(cutoff float64, halfWidth int) []float64 {
+	table := make([]float64, halfWidth*resamplePhases+2)
+	for i := range table {
+		t := float64(i) / resamplePhases
+		if t >= float64(halfWidth) {
+			continue
+		}
+		// Blackman window over [-halfWidth, halfWidth].
+		w := 0.42 + 0.5*math.Cos(math.Pi*t/float64(halfWidth)) + 0.08*math.Cos(2*math.Pi*t/float64(halfWidth))
+		table[i] = cutoff * sinc(cutoff*t) * w
+	}
+	return table
+}
+
+// filterAt looks up the filter at distance t (in input samples), linearly
+// interpolating between table entries.
+func filterAt(table []float64, t float64) float64 {
+	pos := t * resamplePhases
+	idx := int(pos)
+	if idx+1 >= len(table) {
+		return 0
+	}
+	frac := pos - float64(idx)
+	return table[idx] + (table[idx+1]-table[idx])*frac
+}
+
+// sinc is the normalized sinc function sin(pi*x)/(pi*x).
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
@@ -0,0 +1,101 @@
+package audioproc
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+// sinePCM16 returns frames frames of a 16-bit sine at freq Hz and amplitude
+// amp (a fraction of full scale), repeated on every channel.
+func sinePCM16(freq, amp float64, rate, channels, frames int) []byte {
+	vs := make([]int16, 0, frames*channels)
+	for i := range frames {
+		v := int16(amp * math.MaxInt16 * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+		for range channels {
+			vs = append(vs, v)
+		}
+	}
+	return audiotest.PCM16(vs...)
+}
+
+// rms16 returns the RMS level of channel ch of 16-bit PCM as a fraction of
+// full scale, skipping skip frames at each end to leave out filter edges.
+func rms16(audio []byte, channels, ch, skip int) float64 {
+	frames := len(audio) / (2 * channels)
+	var sum float64
+	n := 0
+	for i := skip; i < frames-skip; i++ {
+		v := float64(readSample(audio[(i*channels+ch)*2:], 2)) / math.MaxInt16
+		sum += v * v
+		n++
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		name             string
+		channels         int
+		freq             float64
+		fromRate, toRate int
+		wantRMS          float64 // of a 0.5 amplitude input
+	}{
+		{"upsample", 1, 1000, 44100, 48000, 0.5 / math.Sqrt2},
+		{"downsample", 1, 1000, 48000, 44100, 0.5 / math.Sqrt2},
+		{"stereo halve", 2, 1000, 96000, 48000, 0.5 / math.Sqrt2},
+		{"above new Nyquist removed", 1, 15000, 44100, 22050, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const frames = 4410
+			in := sinePCM16(tt.freq, 0.5, tt.fromRate, tt.channels, frames)
+			out, err := Resample(in, 16, tt.channels, tt.fromRate, tt.toRate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantFrames := frames * tt.toRate / tt.fromRate
+			if got := len(out) / (2 * tt.channels); got != wantFrames {
+				t.Fatalf("got %d frames, want %d", got, wantFrames)
+			}
+			for ch := range tt.channels {
+				if got := rms16(out, tt.channels, ch, 64); math.Abs(got-tt.wantRMS) > 0.01 {
+					t.Errorf("channel %d RMS %.4f, want %.4f", ch, got, tt.wantRMS)
+				}
+			}
+		})
+	}
+}
+
+func TestResampleSameRate(t *testing.T) {
+	in := audiotest.PCM16(1, 2, 3, 4)
+	out, err := Resample(in, 16, 2, 44100, 44100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, in) {
+		t.Fatalf("got % x, want % x", out, in)
+	}
+}
+
+func TestResampleErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		bps, channels    int
+		fromRate, toRate int
+	}{
+		{"unsupported depth", 12, 1, 44100, 48000},
+		{"no channels", 16, 0, 44100, 48000},
+		{"zero source rate", 16, 1, 0, 48000},
+		{"negative target rate", 16, 1, 44100, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Resample(audiotest.PCM16(1, 2), tt.bps, tt.channels, tt.fromRate, tt.toRate); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
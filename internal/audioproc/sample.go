@@ -0,0 +1,69 @@
+// Package audioproc provides helpers for processing interleaved little-endian
+// PCM audio in place, as produced by the decoders.
+package audioproc
+
+import "fmt"
+
+// checkBitDepth returns an error for bit depths the helpers cannot process.
+func checkBitDepth(bitsPerSample int) error {
+	switch bitsPerSample {
+	case 8, 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("unsupported bits per sample: %d", bitsPerSample)
+	}
+}
+
+// sampleRange returns the minimum and maximum sample value for a bit depth.
+func sampleRange(bitsPerSample int) (minVal, maxVal int64) {
+	maxVal = int64(1)<<(bitsPerSample-1) - 1
+	return -maxVal - 1, maxVal
+}
+
+// readSample decodes one little-endian sample of the given byte width.
+// 8-bit samples are unsigned (WAV convention) and are re-centered around zero.
+func readSample(data []byte, bytesPerSample int) int32 {
+	switch bytesPerSample {
+	case 1:
+		return int32(data[0]) - 128
+	case 2:
+		return int32(int16(uint16(data[0]) | uint16(data[1])<<8))
+	case 3:
+		return unpack24(data)
+	default:
+		return int32(uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24)
+	}
+}
+
+// writeSample encodes one little-endian sample of the given byte width.
+func writeSample(data []byte, bytesPerSample int, v int32) {
+	switch bytesPerSample {
+	case 1:
+		data[0] = byte(v + 128)
+	case 2:
+		data[0] = byte(v)
+		data[1] = byte(v >> 8)
+	case 3:
+		pack24(data, v)
+	default:
+		data[0] = byte(v)
+		data[1] = byte(v >> 8)
+		data[2] = byte(v >> 16)
+		data[3] = byte(v >> 24)
+	}
+}
+
+// clampSample rounds v and clamps it to the range of bitsPerSample.
+func clampSample(v float64, bitsPerSample int) int32 {
+	minVal, maxVal := sampleRange(bitsPerSample)
+	if v >= float64(maxVal) {
+		return int32(maxVal)
+	}
+	if v <= float64(minVal) {
+		return int32(minVal)
+	}
+	if v < 0 {
+		return int32(v - 0.5)
+	}
+	return int32(v + 0.5)
+}
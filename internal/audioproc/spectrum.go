@@ -0,0 +1,66 @@
+package audioproc
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// Spectrum returns the magnitude spectrum of a block of samples in [-1, 1]
+// after applying a Hann window: len(samples)/2+1 bins from 0 Hz to the
+// Nyquist frequency, bin i being at i*sampleRate/len(samples) Hz.
+// Magnitudes are scaled so that a full-scale sine reads about 1.0.
+// The block length must be a power of two.
+func Spectrum(samples []float64) ([]float64, error) {
+	n := len(samples)
+	if n < 2 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("spectrum block length must be a power of two, got %d", n)
+	}
+
+	buf := make([]complex128, n)
+	var windowSum float64
+	for i, s := range samples {
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+		windowSum += w
+		buf[i] = complex(s*w, 0)
+	}
+	fft(buf)
+
+	mags := make([]float64, n/2+1)
+	scale := 2 / windowSum
+	for i := range mags {
+		mags[i] = cmplx.Abs(buf[i]) * scale
+	}
+	return mags, nil
+}
+
+// fft computes the discrete Fourier transform of x in place with the
+// iterative radix-2 Cooley-Tukey algorithm. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit-reversal permutation.
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k] = a + b
+				x[start+k+size/2] = a - b
+				w *= step
+			}
+		}
+	}
+}
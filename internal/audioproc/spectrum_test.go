@@ -0,0 +1,80 @@
+package audioproc
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestSpectrum(t *testing.T) {
+	const n = 1024
+	tests := []struct {
+		name    string
+		bin     int // of a sine, or -1 for DC
+		amp     float64
+		wantMag float64
+	}{
+		{"full-scale sine", 64, 1, 1},
+		{"half-scale sine", 100, 0.5, 0.5},
+		{"DC", -1, 0.5, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := make([]float64, n)
+			for i := range samples {
+				if tt.bin < 0 {
+					samples[i] = tt.amp
+				} else {
+					samples[i] = tt.amp * math.Sin(2*math.Pi*float64(tt.bin*i)/n)
+				}
+			}
+			mags, err := Spectrum(samples)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(mags) != n/2+1 {
+				t.Fatalf("got %d bins, want %d", len(mags), n/2+1)
+			}
+			peak := max(tt.bin, 0)
+			if got := mags[peak]; math.Abs(got-tt.wantMag) > 0.01 {
+				t.Errorf("bin %d magnitude %.4f, want %.4f", peak, got, tt.wantMag)
+			}
+			// The Hann window spreads a bin-centered tone over its neighbours
+			// only.
+			for i, m := range mags {
+				if (i < peak-1 || i > peak+1) && m > 1e-9 {
+					t.Errorf("bin %d magnitude %g, want 0", i, m)
+				}
+			}
+		})
+	}
+}
+
+func TestSpectrumLength(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 1000} {
+		if _, err := Spectrum(make([]float64, n)); err == nil {
+			t.Errorf("Spectrum of %d samples succeeded, want error", n)
+		}
+	}
+}
+
+func TestFFTMatchesDFT(t *testing.T) {
+	for _, n := range []int{2, 8, 64} {
+		x := make([]complex128, n)
+		for i := range x {
+			x[i] = complex(math.Sin(float64(3*i)), math.Cos(float64(i*i)))
+		}
+		want := make([]complex128, n)
+		for k := range want {
+			for j, v := range x {
+				want[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/float64(n)))
+			}
+		}
+		fft(x)
+		for k := range x {
+			if cmplx.Abs(x[k]-want[k]) > 1e-9 {
+				t.Errorf("n=%d bin %d: got %v, want %v", n, k, x[k], want[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package audiotest builds PCM samples and audio files for tests.
+package audiotest
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// PCM16 returns 16-bit little-endian PCM of the given samples.
+func PCM16(vs ...int16) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint16(b, uint16(v))
+	}
+	return b
+}
+
+// PCM24 returns packed 24-bit little-endian PCM of the given samples.
+func PCM24(vs ...int32) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = append(b, byte(v), byte(v>>8), byte(v>>16))
+	}
+	return b
+}
+
+// PCM32 returns 32-bit little-endian PCM of the given samples.
+func PCM32(vs ...int32) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint32(b, uint32(v))
+	}
+	return b
+}
+
+// RIFFChunk returns a RIFF chunk with the given ID and body, padded to an
+// even size.
+func RIFFChunk(id string, body []byte) []byte {
+	c := binary.LittleEndian.AppendUint32([]byte(id), uint32(len(body)))
+	c = append(c, body...)
+	if len(body)%2 == 1 {
+		c = append(c, 0)
+	}
+	return c
+}
+
+// WAVFile returns a RIFF/WAVE file of the given chunks.
+func WAVFile(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return RIFFChunk("RIFF", body)
+}
+
+// WAVFormat returns the 16-byte body of a WAV fmt chunk.
+func WAVFormat(tag uint16, channels, rate, bps int) []byte {
+	le := binary.LittleEndian
+	b := le.AppendUint16(nil, tag)
+	b = le.AppendUint16(b, uint16(channels))
+	b = le.AppendUint32(b, uint32(rate))
+	b = le.AppendUint32(b, uint32(rate*channels*bps/8))
+	b = le.AppendUint16(b, uint16(channels*bps/8))
+	return le.AppendUint16(b, uint16(bps))
+}
+
+// WriteFile writes data to name in a temporary directory and returns the
+// file's path.
+func WriteFile(t testing.TB, name string, data []byte) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(fileName, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
@@ -0,0 +1,224 @@
+// Package cuesheet parses CUE sheets, which describe the tracks of an album
+// stored as one audio file (or a few) by their start offsets.
+package cuesheet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// framesPerSecond is the CD frame rate used by INDEX timestamps.
+const framesPerSecond = 75
+
+// Sheet is a parsed CUE sheet.
+type Sheet struct {
+	Title     string
+	Performer string
+	Tracks    []Track
+}
+
+// Track is one TRACK entry. Start is the offset of INDEX 01 in File; a
+// pregap (INDEX 00) is treated as the end of the previous track.
+type Track struct {
+	Number    int
+	Title     string
+	Performer string // the sheet performer if the track has none
+	File      string // as written in the sheet, usually relative to it
+	Start     time.Duration
+}
+
+// End returns where track i ends in its file: the start of the next track if
+// that is in the same file, otherwise 0, meaning the end of the file.
+func (s *Sheet) End(i int) time.Duration {
+	if i+1 < len(s.Tracks) && s.Tracks[i+1].File == s.Tracks[i].File {
+		return s.Tracks[i+1].Start
+	}
+	return 0
+}
+
+// ParseFile parses the CUE sheet in fileName.
+func ParseFile(fileName string) (*Sheet, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheet, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return sheet, nil
+}
+
+// Parse reads a CUE sheet. Commands that do not affect track boundaries or
+// names (REM, FLAGS, ISRC, PREGAP, ...) are ignored.
+func Parse(r io.Reader) (*Sheet, error) {
+	sheet := &Sheet{}
+	var file string
+	var track *Track
+	hasIndex := false
+
+	finishTrack := func(line int) error {
+		if track == nil {
+			return nil
+		}
+		if !hasIndex {
+			return fmt.Errorf("line %d: track %d has no INDEX 01", line, track.Number)
+		}
+		if track.Performer == "" {
+			track.Performer = sheet.Performer
+		}
+		sheet.Tracks = append(sheet.Tracks, *track)
+		track = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		fields := splitFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "TITLE":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: TITLE without a value", lineNo)
+			}
+			if track != nil {
+				track.Title = fields[1]
+			} else {
+				sheet.Title = fields[1]
+			}
+
+		case "PERFORMER":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: PERFORMER without a value", lineNo)
+			}
+			if track != nil {
+				track.Performer = fields[1]
+			} else {
+				sheet.Performer = fields[1]
+			}
+
+		case "FILE":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: FILE without a name", lineNo)
+			}
+			if err := finishTrack(lineNo); err != nil {
+				return nil, err
+			}
+			file = fields[1]
+
+		case "TRACK":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: TRACK without a number", lineNo)
+			}
+			if file == "" {
+				return nil, fmt.Errorf("line %d: TRACK before any FILE", lineNo)
+			}
+			if err := finishTrack(lineNo); err != nil {
+				return nil, err
+			}
+			num, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid track number %q", lineNo, fields[1])
+			}
+			track = &Track{Number: num, File: file}
+			hasIndex = false
+
+		case "INDEX":
+			if track == nil {
+				return nil, fmt.Errorf("line %d: INDEX outside a TRACK", lineNo)
+			}
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("line %d: INDEX needs a number and a time", lineNo)
+			}
+			num, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid index number %q", lineNo, fields[1])
+			}
+			if num != 1 {
+				continue
+			}
+			start, err := parseTimestamp(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			track.Start = start
+			hasIndex = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finishTrack(lineNo); err != nil {
+		return nil, err
+	}
+	if len(sheet.Tracks) == 0 {
+		return nil, fmt.Errorf("no tracks in cue sheet")
+	}
+	return sheet, nil
+}
+
+// parseTimestamp parses an mm:ss:ff INDEX time, where ff is in CD frames.
+// Minutes may exceed 59.
+func parseTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid index time %q, want mm:ss:ff", s)
+	}
+	var v [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid index time %q, want mm:ss:ff", s)
+		}
+		v[i] = n
+	}
+	if v[1] >= 60 || v[2] >= framesPerSecond {
+		return 0, fmt.Errorf("index time %q out of range", s)
+	}
+	return time.Duration(v[0]*60+v[1])*time.Second + time.Duration(v[2])*time.Second/framesPerSecond, nil
+}
+
+// splitFields splits a line into whitespace-separated fields, keeping
+// double-quoted strings together without their quotes.
+func splitFields(line string) []string {
+	var fields []string
+	var cur strings.Builder
+	inQuotes, inField := false, false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			inField = true
+		case (r == ' ' || r == '\t' || r == '\r') && !inQuotes:
+			if inField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inField = false
+			}
+		default:
+			cur.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
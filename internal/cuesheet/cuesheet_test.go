@@ -0,0 +1,148 @@
+package cuesheet
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const album = "\ufeff" + `REM GENRE Jazz
+PERFORMER "The Band"
+TITLE "Live Album"
+FILE "album.flac" WAVE
+  TRACK 01 AUDIO
+    TITLE "Opener"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Second"
+    PERFORMER "Guest"
+    FLAGS DCP
+    INDEX 00 03:58:50
+    INDEX 01 04:00:15
+FILE "bonus.wav" WAVE
+  TRACK 03 AUDIO
+    TITLE "Bonus"
+    INDEX 01 00:01:74
+`
+
+func TestParse(t *testing.T) {
+	sheet, err := Parse(strings.NewReader(album))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Sheet{
+		Title:     "Live Album",
+		Performer: "The Band",
+		Tracks: []Track{
+			{Number: 1, Title: "Opener", Performer: "The Band", File: "album.flac", Start: 0},
+			{Number: 2, Title: "Second", Performer: "Guest", File: "album.flac", Start: 4*time.Minute + 15*time.Second/75},
+			{Number: 3, Title: "Bonus", Performer: "The Band", File: "bonus.wav", Start: time.Second + 74*time.Second/75},
+		},
+	}
+	if !reflect.DeepEqual(sheet, want) {
+		t.Fatalf("got %+v, want %+v", sheet, want)
+	}
+
+	ends := []time.Duration{sheet.Tracks[1].Start, 0, 0}
+	for i, want := range ends {
+		if got := sheet.End(i); got != want {
+			t.Errorf("End(%d) = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		sheet string
+		want  string
+	}{
+		{"empty", "", "no tracks"},
+		{"no tracks", "FILE \"a.wav\" WAVE\n", "no tracks"},
+		{"track before file", "TRACK 01 AUDIO\n", "before any FILE"},
+		{"bad track number", "FILE a.wav WAVE\nTRACK one AUDIO\n", "invalid track number"},
+		{"index outside track", "FILE a.wav WAVE\nINDEX 01 00:00:00\n", "outside a TRACK"},
+		{"missing index", "FILE a.wav WAVE\nTRACK 01 AUDIO\nTRACK 02 AUDIO\nINDEX 01 00:00:00\n", "track 1 has no INDEX 01"},
+		{"only pregap", "FILE a.wav WAVE\nTRACK 01 AUDIO\nINDEX 00 00:00:00\n", "track 1 has no INDEX 01"},
+		{"index without time", "FILE a.wav WAVE\nTRACK 01 AUDIO\nINDEX 01\n", "needs a number and a time"},
+		{"bad index time", "FILE a.wav WAVE\nTRACK 01 AUDIO\nINDEX 01 00:00\n", "want mm:ss:ff"},
+		{"title without value", "TITLE\n", "TITLE without a value"},
+		{"file without name", "FILE\n", "FILE without a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.sheet))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "album.cue")
+	if err := os.WriteFile(name, []byte(album), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sheet, err := ParseFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Tracks) != 3 {
+		t.Fatalf("got %d tracks, want 3", len(sheet.Tracks))
+	}
+
+	if err := os.WriteFile(name, []byte("TRACK 01 AUDIO\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseFile(name); err == nil || !strings.Contains(err.Error(), name) {
+		t.Fatalf("got error %v, want one naming the file", err)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"00:00:00", 0, false},
+		{"01:02:03", time.Minute + 2*time.Second + 40*time.Millisecond, false},
+		{"00:00:74", 74 * time.Second / 75, false},
+		{"120:00:00", 2 * time.Hour, false},
+		{"00:60:00", 0, true},
+		{"00:00:75", 0, true},
+		{"00:-1:00", 0, true},
+		{"00:00", 0, true},
+		{"aa:00:00", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTimestamp(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseTimestamp(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"TRACK 01 AUDIO", []string{"TRACK", "01", "AUDIO"}},
+		{"\tTITLE  \"Two  Words\"\r", []string{"TITLE", "Two  Words"}},
+		{`FILE "" WAVE`, []string{"FILE", "", "WAVE"}},
+		{`PERFORMER a"b c"d`, []string{"PERFORMER", "ab cd"}},
+	}
+	for _, tt := range tests {
+		if got := splitFields(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("splitFields(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package aiff decodes AIFF and uncompressed AIFF-C files.
+//
+// AIFF stores big-endian signed PCM; samples are converted to the
+// little-endian layout of the other decoders on decode, and 8-bit samples
+// are made unsigned as in WAV.
+package aiff
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// AIFF-C compression types for uncompressed PCM.
+const (
+	compressionNone = "NONE"
+	compressionSowt = "sowt" // little-endian PCM
+	compressionTwos = "twos" // big-endian PCM, same as NONE
+)
+
+// Decoder decodes AIFF and AIFF-C (NONE, twos, sowt) files.
+// Pure Go implementation — no CGo required.
+// Supports sample sizes up to 32 bits; sizes that are not a whole number of
+// bytes (e.g. 12 or 20 bits) are stored left-justified and are output at the
+// container width. Implements decoder.AudioDecoder.
+type Decoder struct {
+	file *os.File
+
+	rate     int
+	channels int
+	bps      int // container bits per sample
+
+	littleEndian bool
+	frameSize    int   // bytes per sample frame
+	dataStart    int64 // offset of the first sample byte
+	dataSize     int64 // sample data size in bytes
+	pos          int64 // bytes consumed from the sample data
+}
+
+// NewDecoder creates a new AIFF decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+// Open opens an AIFF file and parses its header.
+func (d *Decoder) Open(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open AIFF file: %w", err)
+	}
+
+	if err := d.init(file); err != nil {
+		file.Close()
+		return err
+	}
+	d.file = file
+	return nil
+}
+
+// init parses the FORM header of f and positions it at the sample data.
+func (d *Decoder) init(f io.ReadSeeker) error {
+	var form [12]byte
+	if _, err := io.ReadFull(f, form[:]); err != nil {
+		return fmt.Errorf("failed to read FORM header: %w", err)
+	}
+	formType := string(form[8:12])
+	if string(form[0:4]) != "FORM" || (formType != "AIFF" && formType != "AIFC") {
+		return errors.New("not an AIFF file")
+	}
+	aifc := formType == "AIFC"
+
+	haveComm, haveData := false, false
+	var frames int64
+	for !(haveComm && haveData) {
+		var header [8]byte
+		if _, err := io.ReadFull(f, header[:]); err != nil {
+			if !haveComm {
+				return fmt.Errorf("no COMM chunk found: %w", err)
+			}
+			return fmt.Errorf("no SSND chunk found: %w", err)
+		}
+		id := string(header[0:4])
+		size := int64(binary.BigEndian.Uint32(header[4:8]))
+		start, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("failed to locate %q chunk: %w", id, err)
+		}
+		next := start + size + size%2
+
+		switch id {
+		case "COMM":
+			if frames, err = d.parseComm(f, size, aifc); err != nil {
+				return err
+			}
+			haveComm = true
+
+		case "SSND":
+			var ssnd [8]byte
+			if size < 8 {
+				return fmt.Errorf("invalid SSND chunk size: %d", size)
+			}
+			if _, err := io.ReadFull(f, ssnd[:]); err != nil {
+				return fmt.Errorf("failed to read SSND header: %w", err)
+			}
+			offset := int64(binary.BigEndian.Uint32(ssnd[0:4]))
+			if offset > size-8 {
+				return fmt.Errorf("invalid SSND offset: %d", offset)
+			}
+			d.dataStart = start + 8 + offset
+			d.dataSize = size - 8 - offset
+			haveData = true
+		}
+
+		if _, err := f.Seek(next, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to skip %q chunk: %w", id, err)
+		}
+	}
+
+	// COMM gives the authoritative length; SSND may be padded.
+	d.dataSize = min(d.dataSize, frames*int64(d.frameSize))
+	d.dataSize -= d.dataSize % int64(d.frameSize)
+	d.pos = 0
+	if _, err := f.Seek(d.dataStart, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to sample data: %w", err)
+	}
+	return nil
+}
+
+// parseComm reads a COMM chunk body of the given size and returns the
+// number of sample frames it declares.
+func (d *Decoder) parseComm(r io.Reader, size int64, aifc bool) (int64, error) {
+	minSize := int64(18)
+	if aifc {
+		minSize = 22
+	}
+	if size < minSize || size > 1024 {
+		return 0, fmt.Errorf("invalid COMM chunk size: %d", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, fmt.Errorf("failed to read AIFF format: %w", err)
+	}
+
+	channels := int(binary.BigEndian.Uint16(body[0:2]))
+	frames := int64(binary.BigEndian.Uint32(body[2:6]))
+	sampleSize := int(binary.BigEndian.Uint16(body[6:8]))
+	rate := extendedToFloat(body[8:18])
+
+	d.littleEndian = false
+	if aifc {
+		switch compression := string(body[18:22]); compression {
+		case compressionNone, compressionTwos:
+		case compressionSowt:
+			d.littleEndian = true
+		default:
+			return 0, fmt.Errorf("unsupported AIFF-C compression: %q", compression)
+		}
+	}
+
+	if sampleSize < 1 || sampleSize > 32 {
+		return 0, fmt.Errorf("unsupported AIFF sample size: %d", sampleSize)
+	}
+	if channels <= 0 || rate < 1 || rate > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid AIFF format: %d channels, %g Hz", channels, rate)
+	}
+
+	d.channels = channels
+	d.rate = int(math.Round(rate))
+	d.bps = (sampleSize + 7) / 8 * 8
+	d.frameSize = channels * d.bps / 8
+	return frames, nil
+}
+
+// extendedToFloat converts an 80-bit IEEE 754 extended precision number,
+// as used for the AIFF sample rate, to float64.
+func extendedToFloat(b []byte) float64 {
+	sign := 1.0
+	if b[0]&0x80 != 0 {
+		sign = -1
+	}
+	exp := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exp == 0 && mantissa == 0 {
+		return 0
+	}
+	return sign * math.Ldexp(float64(mantissa), exp-16383-63)
+}
+
+// Close closes the underlying file.
+func (d *Decoder) Close() error {
+	if d.file != nil {
+		err := d.file.Close()
+		d.file = nil
+		return err
+	}
+	return nil
+}
+
+// GetFormat returns the output format.
+func (d *Decoder) GetFormat() (sampleRate, channels, bitsPerSample int) {
+	return d.rate, d.channels, d.bps
+}
+
+// TotalSamples returns the number of sample frames in the file.
+func (d *Decoder) TotalSamples() (int64, error) {
+	if d.file == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+	return d.dataSize / int64(d.frameSize), nil
+}
+
+// DecodeSamples decodes up to `samples` audio sample frames into the provided buffer,
+// which must hold samples * channels * (bitsPerSample/8) bytes.
+// Returns io.EOF once the sample data is exhausted.
+func (d *Decoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.file == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+
+	frames := min(int64(samples), (d.dataSize-d.pos)/int64(d.frameSize), int64(len(audio)/d.frameSize))
+	if frames <= 0 {
+		if d.pos >= d.dataSize {
+			return 0, io.EOF
+		}
+		return 0, nil
+	}
+
+	raw := audio[:frames*int64(d.frameSize)]
+	n, err := io.ReadFull(d.file, raw)
+	d.pos += int64(n)
+	got := n / d.frameSize
+	d.toLittleEndian(raw[:got*d.frameSize])
+
+	if err != nil {
+		// The header promised more data than the file holds.
+		d.dataSize = d.pos
+		return got, fmt.Errorf("AIFF sample data truncated at byte %d", d.dataStart+d.pos)
+	}
+	return got, nil
+}
+
+// toLittleEndian converts samples in place to little-endian byte order and
+// 8-bit samples from signed to unsigned.
+func (d *Decoder) toLittleEndian(data []byte) {
+	bytesPerSample := d.bps / 8
+	if bytesPerSample == 1 {
+		for i := range data {
+			data[i] ^= 0x80
+		}
+		return
+	}
+	if d.littleEndian {
+		return
+	}
+	for i := 0; i+bytesPerSample <= len(data); i += bytesPerSample {
+		s := data[i : i+bytesPerSample]
+		for l, r := 0, len(s)-1; l < r; l, r = l+1, r-1 {
+			s[l], s[r] = s[r], s[l]
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package aiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+// iffChunk returns a big-endian IFF chunk, padded to an even size.
+func iffChunk(id string, body []byte) []byte {
+	c := binary.BigEndian.AppendUint32([]byte(id), uint32(len(body)))
+	c = append(c, body...)
+	if len(body)%2 == 1 {
+		c = append(c, 0)
+	}
+	return c
+}
+
+// formFile returns a FORM file of formType holding chunks.
+func formFile(formType string, chunks ...[]byte) []byte {
+	body := []byte(formType)
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return iffChunk("FORM", body)
+}
+
+// floatToExtended encodes a positive v as an 80-bit IEEE 754 extended
+// precision number.
+func floatToExtended(v float64) []byte {
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, frac in [0.5, 1)
+	b := binary.BigEndian.AppendUint16(nil, uint16(exp-1+16383))
+	return binary.BigEndian.AppendUint64(b, uint64(frac*(1<<64)))
+}
+
+// commBody returns a COMM chunk body, AIFF-C if compression is set.
+func commBody(channels, frames, sampleSize int, rate float64, compression string) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(channels))
+	b = binary.BigEndian.AppendUint32(b, uint32(frames))
+	b = binary.BigEndian.AppendUint16(b, uint16(sampleSize))
+	b = append(b, floatToExtended(rate)...)
+	if compression != "" {
+		b = append(b, compression...)
+		b = append(b, 0) // empty pascal string name, padded
+		b = append(b, 0)
+	}
+	return b
+}
+
+// ssndBody returns an SSND chunk body with offset bytes of padding before
+// data.
+func ssndBody(offset int, data []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(offset))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = append(b, make([]byte, offset)...)
+	return append(b, data...)
+}
+
+// decodeAll decodes d a frame at a time until io.EOF.
+func decodeAll(t *testing.T, d *Decoder) []byte {
+	t.Helper()
+	_, channels, bps := d.GetFormat()
+	frameSize := channels * bps / 8
+	var out []byte
+	buf := make([]byte, frameSize)
+	for {
+		n, err := d.DecodeSamples(1, buf)
+		out = append(out, buf[:n*frameSize]...)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDecodeFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		formType    string
+		compression string
+		channels    int
+		sampleSize  int
+		rate        float64
+		data        []byte
+		bps         int
+		want        []byte
+	}{
+		{"8-bit", "AIFF", "", 1, 8, 8000, []byte{0x00, 0x7F, 0x80, 0xFF}, 8, []byte{0x80, 0xFF, 0x00, 0x7F}},
+		{"16-bit", "AIFF", "", 2, 16, 44100, []byte{0x12, 0x34, 0xAB, 0xCD}, 16, []byte{0x34, 0x12, 0xCD, 0xAB}},
+		{"24-bit", "AIFF", "", 1, 24, 48000, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}, 24, []byte{0x03, 0x02, 0x01, 0x06, 0x05, 0x04}},
+		{"32-bit", "AIFF", "", 1, 32, 96000, []byte{0x01, 0x02, 0x03, 0x04}, 32, []byte{0x04, 0x03, 0x02, 0x01}},
+		{"12-bit in 16", "AIFF", "", 1, 12, 22050, []byte{0x12, 0x30}, 16, []byte{0x30, 0x12}},
+		{"AIFF-C NONE", "AIFC", "NONE", 1, 16, 44100, []byte{0x12, 0x34}, 16, []byte{0x34, 0x12}},
+		{"AIFF-C twos", "AIFC", "twos", 1, 16, 44100, []byte{0x12, 0x34}, 16, []byte{0x34, 0x12}},
+		{"AIFF-C sowt", "AIFC", "sowt", 1, 16, 44100, []byte{0x12, 0x34}, 16, []byte{0x12, 0x34}},
+		{"AIFF-C sowt 8-bit", "AIFC", "sowt", 1, 8, 8000, []byte{0x00, 0x80}, 8, []byte{0x80, 0x00}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := len(tt.data) / (tt.channels * tt.bps / 8)
+			file := formFile(tt.formType,
+				iffChunk("COMM", commBody(tt.channels, frames, tt.sampleSize, tt.rate, tt.compression)),
+				iffChunk("SSND", ssndBody(0, tt.data)))
+			d := NewDecoder()
+			if err := d.Open(audiotest.WriteFile(t, "test.aiff", file)); err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			rate, channels, bps := d.GetFormat()
+			if rate != int(tt.rate) || channels != tt.channels || bps != tt.bps {
+				t.Fatalf("GetFormat = %d, %d, %d, want %g, %d, %d", rate, channels, bps, tt.rate, tt.channels, tt.bps)
+			}
+			if total, err := d.TotalSamples(); err != nil || total != int64(frames) {
+				t.Fatalf("TotalSamples = %d, %v, want %d", total, err, frames)
+			}
+			if got := decodeAll(t, d); !bytes.Equal(got, tt.want) {
+				t.Fatalf("decoded % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleDataLayout(t *testing.T) {
+	data := []byte{0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04}
+
+	tests := []struct {
+		name   string
+		chunks [][]byte
+		want   []byte
+	}{
+		{
+			"SSND offset",
+			[][]byte{iffChunk("COMM", commBody(1, 4, 16, 8000, "")), iffChunk("SSND", ssndBody(6, data))},
+			[]byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04, 0x00},
+		},
+		{
+			"COMM length trims SSND padding",
+			[][]byte{iffChunk("COMM", commBody(1, 2, 16, 8000, "")), iffChunk("SSND", ssndBody(0, data))},
+			[]byte{0x01, 0x00, 0x02, 0x00},
+		},
+		{
+			"SSND before COMM, other chunks skipped",
+			[][]byte{
+				iffChunk("NAME", []byte("odd")),
+				iffChunk("SSND", ssndBody(0, data)),
+				iffChunk("COMM", commBody(1, 4, 16, 8000, "")),
+			},
+			[]byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04, 0x00},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder()
+			if err := d.Open(audiotest.WriteFile(t, "test.aiff", formFile("AIFF", tt.chunks...))); err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			if got := decodeAll(t, d); !bytes.Equal(got, tt.want) {
+				t.Fatalf("decoded % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	comm := iffChunk("COMM", commBody(1, 1, 16, 8000, ""))
+	ssnd := iffChunk("SSND", ssndBody(0, []byte{0, 0}))
+	badOffset := ssndBody(0, []byte{0, 0})
+	binary.BigEndian.PutUint32(badOffset, 100)
+
+	tests := []struct {
+		name string
+		file []byte
+	}{
+		{"empty", nil},
+		{"not FORM", append([]byte("RIFF\x00\x00\x00\x04"), "AIFF"...)},
+		{"not AIFF", formFile("8SVX", comm, ssnd)},
+		{"no COMM chunk", formFile("AIFF", ssnd)},
+		{"no SSND chunk", formFile("AIFF", comm)},
+		{"short COMM chunk", formFile("AIFF", iffChunk("COMM", commBody(1, 1, 16, 8000, "")[:16]), ssnd)},
+		{"AIFF-C without compression", formFile("AIFC", comm, ssnd)},
+		{"compressed AIFF-C", formFile("AIFC", iffChunk("COMM", commBody(1, 1, 16, 8000, "ima4")), ssnd)},
+		{"zero sample size", formFile("AIFF", iffChunk("COMM", commBody(1, 1, 0, 8000, "")), ssnd)},
+		{"sample size over 32", formFile("AIFF", iffChunk("COMM", commBody(1, 1, 33, 8000, "")), ssnd)},
+		{"no channels", formFile("AIFF", iffChunk("COMM", commBody(0, 1, 16, 8000, "")), ssnd)},
+		{"zero rate", formFile("AIFF", iffChunk("COMM", commBody(1, 1, 16, 0, "")), ssnd)},
+		{"short SSND chunk", formFile("AIFF", comm, iffChunk("SSND", []byte{0, 0, 0, 0}))},
+		{"SSND offset past the chunk", formFile("AIFF", comm, iffChunk("SSND", badOffset))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder()
+			if err := d.Open(audiotest.WriteFile(t, "test.aiff", tt.file)); err == nil {
+				d.Close()
+				t.Fatal("Open succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestTruncatedSampleData(t *testing.T) {
+	// COMM and SSND both claim 100 frames; the file holds 10.
+	ssnd := iffChunk("SSND", ssndBody(0, make([]byte, 200)))
+	file := formFile("AIFF", iffChunk("COMM", commBody(1, 100, 16, 8000, "")), ssnd)
+	file = file[:len(file)-180]
+
+	d := NewDecoder()
+	if err := d.Open(audiotest.WriteFile(t, "test.aiff", file)); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	n, err := d.DecodeSamples(100, make([]byte, 200))
+	if n != 10 || err == nil || err == io.EOF {
+		t.Fatalf("DecodeSamples = %d, %v, want 10 samples and a truncation error", n, err)
+	}
+	if n, err := d.DecodeSamples(100, make([]byte, 200)); n != 0 || err != io.EOF {
+		t.Fatalf("DecodeSamples after the error = %d, %v, want 0, io.EOF", n, err)
+	}
+}
+
+func TestExtendedToFloat(t *testing.T) {
+	for _, rate := range []float64{8000, 11025, 22050, 44100, 48000, 96000, 192000} {
+		if got := extendedToFloat(floatToExtended(rate)); got != rate {
+			t.Errorf("extendedToFloat(%g) = %g", rate, got)
+		}
+	}
+	if got := extendedToFloat(make([]byte, 10)); got != 0 {
+		t.Errorf("extendedToFloat(0) = %g", got)
+	}
+}
@@ -0,0 +1,221 @@
+package decoders
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/audiokit/pkg/types"
+)
+
+// CachedSource decodes files once and keeps their PCM in memory, so short
+// files played over and over (UI sound effects) don't reopen a decoder on
+// every play. Up to maxEntries files are kept; opening another evicts the
+// least recently opened one. Decoders already handed out keep the audio of
+// an evicted file alive until they are dropped.
+//
+// A CachedSource is safe for concurrent use. The cache is keyed by file
+// name, so a file changed on disk keeps playing its old audio until evicted
+// or Forget is called.
+type CachedSource struct {
+	maxEntries int
+	open       func(fileName string) (decoder.AudioDecoder, error)
+
+	mu      sync.Mutex
+	lru     *list.List // of *cachedAudio, most recently opened first
+	entries map[string]*list.Element
+}
+
+// cachedAudio is the decoded PCM of one file.
+type cachedAudio struct {
+	fileName string
+	format   types.FrameFormat
+	audio    []byte
+}
+
+// NewCachedSource returns a cache of up to maxEntries decoded files.
+func NewCachedSource(maxEntries int) (*CachedSource, error) {
+	if maxEntries <= 0 {
+		return nil, fmt.Errorf("cache size must be positive: %d", maxEntries)
+	}
+	return &CachedSource{
+		maxEntries: maxEntries,
+		open:       NewDecoder,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}, nil
+}
+
+// Open returns a decoder playing fileName from the start, decoding the file
+// into the cache first if it isn't there. Each call returns a new decoder
+// with its own position; it seeks and knows its length.
+func (c *CachedSource) Open(fileName string) (decoder.AudioDecoder, error) {
+	entry, err := c.load(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryDecoder{source: c, entry: entry}, nil
+}
+
+// Forget drops fileName from the cache.
+func (c *CachedSource) Forget(fileName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[fileName]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, fileName)
+	}
+}
+
+// Len returns the number of files in the cache.
+func (c *CachedSource) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// load returns the cached audio of fileName, decoding it on a miss. The
+// file is decoded without holding the lock, so concurrent misses on the
+// same file may both decode it; the first to finish is kept.
+func (c *CachedSource) load(fileName string) (*cachedAudio, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[fileName]; ok {
+		c.lru.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*cachedAudio), nil
+	}
+	c.mu.Unlock()
+
+	entry, err := c.decode(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[fileName]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*cachedAudio), nil
+	}
+	c.entries[fileName] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedAudio).fileName)
+	}
+	return entry, nil
+}
+
+// decode decodes all of fileName into memory.
+func (c *CachedSource) decode(fileName string) (*cachedAudio, error) {
+	dec, err := c.open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	rate, channels, bps := dec.GetFormat()
+	entry := &cachedAudio{
+		fileName: fileName,
+		format:   types.FrameFormat{SampleRate: rate, Channels: channels, BitsPerSample: bps},
+	}
+	if total, err := TotalSamples(dec); err == nil {
+		entry.audio = make([]byte, 0, int(total)*entry.format.FrameSize())
+	}
+	for chunk, err := range Samples(dec, 4096) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", fileName, err)
+		}
+		entry.audio = append(entry.audio, chunk...)
+	}
+	return entry, nil
+}
+
+// memoryDecoder plays a file's cached audio.
+type memoryDecoder struct {
+	source *CachedSource
+	entry  *cachedAudio
+	pos    int64 // in sample frames
+}
+
+// Open switches to fileName's cached audio, loading it into the source's
+// cache if needed, and rewinds.
+func (d *memoryDecoder) Open(fileName string) error {
+	entry, err := d.source.load(fileName)
+	if err != nil {
+		return err
+	}
+	d.entry, d.pos = entry, 0
+	return nil
+}
+
+// Close releases the decoder's reference to the audio. The cache keeps it.
+func (d *memoryDecoder) Close() error {
+	d.entry = nil
+	return nil
+}
+
+// GetFormat returns the format of the cached file.
+func (d *memoryDecoder) GetFormat() (int, int, int) {
+	if d.entry == nil {
+		return 0, 0, 0
+	}
+	f := d.entry.format
+	return f.SampleRate, f.Channels, f.BitsPerSample
+}
+
+// TotalSamples returns the number of sample frames in the cached file.
+func (d *memoryDecoder) TotalSamples() (int64, error) {
+	if d.entry == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+	return int64(len(d.entry.audio) / d.entry.format.FrameSize()), nil
+}
+
+// DecodeSamples copies up to samples sample frames into audio. Returns
+// io.EOF at the end of the file.
+func (d *memoryDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.entry == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+
+	frameSize := d.entry.format.FrameSize()
+	offset := int(d.pos) * frameSize
+	if offset >= len(d.entry.audio) {
+		return 0, io.EOF
+	}
+	n := min(samples, len(audio)/frameSize, (len(d.entry.audio)-offset)/frameSize)
+	if n <= 0 {
+		return 0, nil
+	}
+	copy(audio, d.entry.audio[offset:offset+n*frameSize])
+	d.pos += int64(n)
+	return n, nil
+}
+
+// Seek moves to offset sample frames relative to whence, clamped to the
+// file.
+func (d *memoryDecoder) Seek(offset int64, whence int) (int64, error) {
+	total, err := d.TotalSamples()
+	if err != nil {
+		return 0, err
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += d.pos
+	case io.SeekEnd:
+		offset += total
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	d.pos = max(0, min(offset, total))
+	return d.pos, nil
+}
+
+// TellCurrentSample returns the position in sample frames.
+func (d *memoryDecoder) TellCurrentSample() int64 {
+	return d.pos
+}
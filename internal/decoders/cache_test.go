@@ -0,0 +1,145 @@
+package decoders
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// countingOpen returns an open function for CachedSource serving a ramp of
+// frames samples for any name, and the number of decoders it opened per name.
+func countingOpen(frames int) (func(string) (decoder.AudioDecoder, error), map[string]int) {
+	opened := make(map[string]int)
+	open := func(fileName string) (decoder.AudioDecoder, error) {
+		opened[fileName]++
+		d := newMockDecoder(8000, 1, 16, rampPCM(1, frames))
+		d.block = 100
+		d.Open(fileName)
+		return d, nil
+	}
+	return open, opened
+}
+
+func TestCachedSourceOpens(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		opens      []string
+		wantOpened map[string]int
+		wantLen    int
+	}{
+		{
+			name:       "second open is cached",
+			maxEntries: 2,
+			opens:      []string{"a", "a"},
+			wantOpened: map[string]int{"a": 1},
+			wantLen:    1,
+		},
+		{
+			name:       "files fitting the cache",
+			maxEntries: 2,
+			opens:      []string{"a", "b", "a", "b"},
+			wantOpened: map[string]int{"a": 1, "b": 1},
+			wantLen:    2,
+		},
+		{
+			name:       "least recently opened is evicted",
+			maxEntries: 2,
+			opens:      []string{"a", "b", "a", "c", "a", "b"},
+			wantOpened: map[string]int{"a": 1, "b": 2, "c": 1},
+			wantLen:    2,
+		},
+		{
+			name:       "cache of one",
+			maxEntries: 1,
+			opens:      []string{"a", "b", "a"},
+			wantOpened: map[string]int{"a": 2, "b": 1},
+			wantLen:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCachedSource(tt.maxEntries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var opened map[string]int
+			c.open, opened = countingOpen(1000)
+
+			for _, name := range tt.opens {
+				dec, err := c.Open(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				dec.Close()
+			}
+			for name, want := range tt.wantOpened {
+				if opened[name] != want {
+					t.Errorf("%s decoded %d times, want %d", name, opened[name], want)
+				}
+			}
+			if c.Len() != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", c.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestCachedSourceDecoders(t *testing.T) {
+	c, err := NewCachedSource(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.open, _ = countingOpen(1000)
+	want := rampPCM(1, 1000)
+
+	first, err := c.Open("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.DecodeSamples(300, make([]byte, 600)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second decoder starts at the beginning, whatever the first's position.
+	second, err := c.Open("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readAll(second, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("second decoder doesn't play the whole file")
+	}
+	if total, err := TotalSamples(second); total != 1000 || err != nil {
+		t.Fatalf("TotalSamples = %d, %v, want 1000", total, err)
+	}
+
+	// The first keeps its position, and its audio, after "a" is evicted.
+	if _, err := c.Open("b"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = readAll(first, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want[600:]) {
+		t.Fatal("first decoder lost its position or audio")
+	}
+
+	seeker := second.(decoder.Seekable)
+	if pos, err := seeker.Seek(-10, io.SeekEnd); pos != 990 || err != nil {
+		t.Fatalf("Seek = %d, %v, want 990", pos, err)
+	}
+	got, err = readAll(second, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want[2*990:]) {
+		t.Fatal("decoded the wrong samples after Seek")
+	}
+}
@@ -0,0 +1,9 @@
+// Package codecerr holds the errors shared by the decoders package and the
+// codec packages under it, which decoders imports and so can't import it.
+package codecerr
+
+import "errors"
+
+// ErrUnknownLength is returned by TotalSamples for streams whose length
+// can't be known without decoding them.
+var ErrUnknownLength = errors.New("stream length unknown")
@@ -0,0 +1,148 @@
+package decoders
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drgolem/audiokit/pkg/decoder/flac"
+	"github.com/drgolem/audiokit/pkg/decoder/mp3"
+	"github.com/drgolem/musictools/internal/metadata"
+	gomp3 "github.com/imcarsen/go-mp3"
+)
+
+// flacDecoder adds Lengther to the audiokit FLAC decoder, reading the length
+// from the file's STREAMINFO block, OutputDepthSetter and MetadataReader.
+type flacDecoder struct {
+	*flac.Decoder
+
+	fileName   string
+	total      int64 // 0 if unknown
+	streamBits int   // 0 if unknown
+}
+
+// Open opens the file and reads its length.
+func (d *flacDecoder) Open(fileName string) error {
+	if err := d.Decoder.Open(fileName); err != nil {
+		return err
+	}
+	d.fileName = fileName
+	// A file the decoder accepts but whose length can't be read still plays.
+	d.total, d.streamBits = 0, 0
+	if f, err := os.Open(fileName); err == nil {
+		if si, err := metadata.ReadFLACStreamInfo(f); err == nil {
+			d.total, d.streamBits = si.TotalSamples, si.BitsPerSample
+		}
+		f.Close()
+	}
+	return nil
+}
+
+// Close closes the file.
+func (d *flacDecoder) Close() error {
+	d.fileName = ""
+	return d.Decoder.Close()
+}
+
+// SetOutputBitDepth makes libFLAC output bitsPerSample-bit samples. libFLAC
+// only outputs a stream at its own depth, or a 24-bit stream at 16 bits;
+// other depths return an error, for SetOutputBitDepth to fall back to a
+// DepthConverter. The depth is fixed when a file is opened, so the file is
+// reopened at the new depth and the current position.
+func (d *flacDecoder) SetOutputBitDepth(bitsPerSample int) error {
+	if d.fileName == "" {
+		return fmt.Errorf("decoder not initialized")
+	}
+	if bitsPerSample != d.streamBits && (d.streamBits != 24 || bitsPerSample != 16) {
+		return fmt.Errorf("libFLAC can't output %d-bit FLAC at %d bits", d.streamBits, bitsPerSample)
+	}
+	dec, err := flac.NewDecoder(bitsPerSample)
+	if err != nil {
+		return err
+	}
+
+	pos := d.Decoder.TellCurrentSample()
+	if err := dec.Open(d.fileName); err != nil {
+		return err
+	}
+	if pos > 0 {
+		if _, err := dec.Seek(pos, io.SeekStart); err != nil {
+			dec.Close()
+			return fmt.Errorf("failed to restore position after depth change: %w", err)
+		}
+	}
+	d.Decoder.Close()
+	d.Decoder = dec
+	return nil
+}
+
+// TotalSamples returns the sample count from STREAMINFO.
+func (d *flacDecoder) TotalSamples() (int64, error) {
+	if d.total <= 0 {
+		return 0, ErrUnknownLength
+	}
+	return d.total, nil
+}
+
+// Metadata reads the file's Vorbis comments.
+func (d *flacDecoder) Metadata() (map[string]string, error) {
+	return readFileTags(d.fileName, metadata.ReadFLACComments)
+}
+
+// mp3Decoder adds Lengther and MetadataReader to the audiokit MP3 decoder.
+type mp3Decoder struct {
+	*mp3.Decoder
+
+	fileName string
+}
+
+// Open opens the file.
+func (d *mp3Decoder) Open(fileName string) error {
+	if err := d.Decoder.Open(fileName); err != nil {
+		return err
+	}
+	d.fileName = fileName
+	return nil
+}
+
+// TotalSamples scans the frame headers of the file with a second go-mp3
+// decoder, so the playback decoder's position is left alone: seeking it to
+// the end fails with io.EOF and leaves it on the last frame.
+func (d *mp3Decoder) TotalSamples() (int64, error) {
+	if d.fileName == "" {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+	f, err := os.Open(d.fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scan, err := gomp3.NewDecoder(f)
+	if err != nil {
+		return 0, err
+	}
+	if scan.Length() < 0 {
+		return 0, ErrUnknownLength
+	}
+	// go-mp3 always outputs 16-bit stereo: 4 bytes per sample frame.
+	return scan.Length() / 4, nil
+}
+
+// Metadata reads the file's ID3v2 tag.
+func (d *mp3Decoder) Metadata() (map[string]string, error) {
+	return readFileTags(d.fileName, metadata.ReadID3v2)
+}
+
+// readFileTags reads the tags of fileName with read.
+func readFileTags(fileName string, read func(io.Reader) (map[string]string, error)) (map[string]string, error) {
+	if fileName == "" {
+		return nil, fmt.Errorf("decoder not initialized")
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return read(f)
+}
@@ -0,0 +1,108 @@
+//go:build cgo
+
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeFLACFixture(t *testing.T) {
+	// sine.flac is sine.wav compressed losslessly.
+	pcm := decodeFixture(t, "sine.flac", 8000, 1, 16)
+	want := decodeFixture(t, "sine.wav", 8000, 1, 16)
+	if !bytes.Equal(pcm, want) {
+		t.Fatalf("decoded %d samples differing from sine.wav's %d", len(pcm)/2, len(want)/2)
+	}
+	checkSine(t, pcm, 440, 8000)
+}
+
+func TestFLACSetOutputBitDepth(t *testing.T) {
+	// sine24.flac is 24-bit; its decoder starts at the default 16 bits,
+	// the top 16 bits of each sample.
+	fileName := filepath.Join("testdata", "sine24.flac")
+
+	ref, err := NewDecoder(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := readAll(ref, 128)
+	ref.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		skip int // sample frames decoded at 16 bits before switching
+	}{
+		{"before decoding", 0},
+		{"mid-stream", 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := NewDecoder(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dec.Close()
+			if tt.skip > 0 {
+				if n, err := dec.DecodeSamples(tt.skip, make([]byte, 2*tt.skip)); n != tt.skip || err != nil {
+					t.Fatalf("DecodeSamples = %d, %v", n, err)
+				}
+			}
+
+			out, err := SetOutputBitDepth(dec, 24)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != dec {
+				t.Fatalf("SetOutputBitDepth wrapped the FLAC decoder in %T, want native output", out)
+			}
+			if _, _, bps := dec.GetFormat(); bps != 24 {
+				t.Fatalf("bits per sample = %d, want 24", bps)
+			}
+
+			got, err := readAll(dec, 128)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rest := want[2*tt.skip:]
+			if len(got) != len(rest)/2*3 {
+				t.Fatalf("decoded %d samples, want %d", len(got)/3, len(rest)/2)
+			}
+			for i := range len(rest) / 2 {
+				v24 := int32(uint32(got[3*i])<<8|uint32(got[3*i+1])<<16|uint32(got[3*i+2])<<24) >> 16
+				v16 := int32(int16(binary.LittleEndian.Uint16(rest[2*i:])))
+				if v24 != v16 {
+					t.Fatalf("sample %d: 24-bit output %d, 16-bit output %d", tt.skip+i, v24, v16)
+				}
+			}
+		})
+	}
+}
+
+func TestFLACSetOutputBitDepthFallsBack(t *testing.T) {
+	// libFLAC can't widen the 16-bit sine.flac.
+	dec, err := NewDecoder(filepath.Join("testdata", "sine.flac"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+
+	if err := dec.(OutputDepthSetter).SetOutputBitDepth(24); err == nil {
+		t.Fatal("flacDecoder.SetOutputBitDepth(24) on 16-bit FLAC succeeded, want error")
+	}
+	out, err := SetOutputBitDepth(dec, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := out.(*DepthConverter); !ok {
+		t.Fatalf("SetOutputBitDepth returned %T, want *DepthConverter", out)
+	}
+	if _, _, bps := out.GetFormat(); bps != 24 {
+		t.Fatalf("bits per sample = %d, want 24", bps)
+	}
+}
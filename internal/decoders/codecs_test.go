@@ -0,0 +1,120 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// decodeCount decodes dec to the end and returns the number of sample
+// frames it produced.
+func decodeCount(t *testing.T, dec decoder.AudioDecoder) int64 {
+	t.Helper()
+	var total int64
+	for chunk, err := range Samples(dec, 1000) {
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		_, channels, bps := dec.GetFormat()
+		total += int64(len(chunk) / (channels * bps / 8))
+	}
+	return total
+}
+
+// decodeFixture opens testdata/name with NewDecoder, checks its format and
+// decodes it to the end with DecodeSamples.
+func decodeFixture(t *testing.T, name string, rate, channels, bps int) []byte {
+	t.Helper()
+	dec, err := NewDecoder(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+
+	if r, c, b := dec.GetFormat(); r != rate || c != channels || b != bps {
+		t.Fatalf("GetFormat = %d, %d, %d, want %d, %d, %d", r, c, b, rate, channels, bps)
+	}
+	pcm, err := readAll(dec, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pcm
+}
+
+// checkSine checks that pcm is 16-bit mono holding a sine of freq Hz at
+// half scale.
+func checkSine(t *testing.T, pcm []byte, freq float64, rate int) {
+	t.Helper()
+	var peak, crossings int
+	prev := int16(0)
+	for i := 0; i+1 < len(pcm); i += 2 {
+		v := int16(binary.LittleEndian.Uint16(pcm[i:]))
+		peak = max(peak, int(v), -int(v))
+		if i > 0 && (v < 0) != (prev < 0) {
+			crossings++
+		}
+		prev = v
+	}
+	if peak < 15000 || peak > 17000 {
+		t.Errorf("peak %d, want about %d", peak, math.MaxInt16/2)
+	}
+	want := 2 * freq * float64(len(pcm)/2) / float64(rate)
+	if math.Abs(float64(crossings)-want) > 2 {
+		t.Errorf("%d zero crossings, want about %.0f for %g Hz", crossings, want, freq)
+	}
+}
+
+func TestDecodeWAVFixture(t *testing.T) {
+	pcm := decodeFixture(t, "sine.wav", 8000, 1, 16)
+	if len(pcm) != 2*400 {
+		t.Fatalf("decoded %d samples, want 400", len(pcm)/2)
+	}
+	checkSine(t, pcm, 440, 8000)
+}
+
+func TestDecodeMP3Fixture(t *testing.T) {
+	// Four MPEG-1 Layer III frames of 1152 samples each.
+	pcm := decodeFixture(t, "silence.mp3", 32000, 2, 16)
+	if len(pcm) != 4*4*1152 {
+		t.Fatalf("decoded %d samples, want %d", len(pcm)/4, 4*1152)
+	}
+	for i := 0; i+1 < len(pcm); i += 2 {
+		if v := int16(binary.LittleEndian.Uint16(pcm[i:])); v < -16 || v > 16 {
+			t.Fatalf("sample %d of digital silence decoded to %d", i/2, v)
+		}
+	}
+}
+
+func TestMP3TotalSamplesKeepsPosition(t *testing.T) {
+	fileName := filepath.Join("testdata", "silence.mp3")
+
+	ref, err := NewDecoder(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decodeCount(t, ref)
+	ref.Close()
+	if want == 0 {
+		t.Fatal("fixture decoded to no samples")
+	}
+
+	dec, err := NewDecoder(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+
+	total, err := TotalSamples(dec)
+	if err != nil {
+		t.Fatalf("TotalSamples: %v", err)
+	}
+	if total != want {
+		t.Errorf("TotalSamples = %d, want %d", total, want)
+	}
+	if got := decodeCount(t, dec); got != want {
+		t.Errorf("decoded %d samples after TotalSamples, want %d", got, want)
+	}
+}
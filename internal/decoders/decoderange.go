@@ -0,0 +1,41 @@
+package decoders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// decodeRangeChunkSamples is the number of sample frames decoded per call
+// by DecodeRange.
+const decodeRangeChunkSamples = 4096
+
+// DecodeRange decodes duration worth of audio starting at start, seeking
+// there if dec is seekable and decoding and discarding up to it otherwise.
+// It returns the PCM bytes and the number of sample frames, which is less
+// than requested if the stream ends first. dec is left open.
+func DecodeRange(dec decoder.AudioDecoder, start, duration time.Duration) ([]byte, int, error) {
+	if duration <= 0 {
+		return nil, 0, fmt.Errorf("duration must be positive: %v", duration)
+	}
+	_, channels, bps := dec.GetFormat()
+	frameSize := channels * bps / 8
+	if frameSize <= 0 {
+		return nil, 0, fmt.Errorf("invalid decoder format: %d channels, %d bits per sample", channels, bps)
+	}
+
+	seg, err := NewSegmentDecoder(dec, start, start+duration)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var audio []byte
+	for chunk, err := range Samples(seg, decodeRangeChunkSamples) {
+		if err != nil {
+			return audio, len(audio) / frameSize, err
+		}
+		audio = append(audio, chunk...)
+	}
+	return audio, len(audio) / frameSize, nil
+}
@@ -0,0 +1,79 @@
+package decoders
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+func TestDecodeRange(t *testing.T) {
+	// At 8 kHz stereo, 10ms is 80 sample frames of 4 bytes.
+	const frames = 8000
+	pcm := rampPCM(2, frames)
+	ms := time.Millisecond
+
+	tests := []struct {
+		name            string
+		start, duration time.Duration
+		from, to        int // sample frames returned
+	}{
+		{"from the start", 0, 10 * ms, 0, 80},
+		{"middle", 250 * ms, 100 * ms, 2000, 2800},
+		{"runs past the end", 950 * ms, 100 * ms, 7600, frames},
+	}
+	for _, tt := range tests {
+		for _, seekable := range []bool{false, true} {
+			name := tt.name
+			if seekable {
+				name += ", seekable"
+			}
+			t.Run(name, func(t *testing.T) {
+				mock := newMockDecoder(8000, 2, 16, pcm)
+				mock.block = 300
+				var src decoder.AudioDecoder = mock
+				if seekable {
+					src = seekableMock{mock}
+				}
+
+				audio, n, err := DecodeRange(src, tt.start, tt.duration)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n != tt.to-tt.from || len(audio) != 4*n {
+					t.Fatalf("got %d samples in %d bytes, want %d", n, len(audio), tt.to-tt.from)
+				}
+				if want := pcm[4*tt.from : 4*tt.to]; !bytes.Equal(audio, want) {
+					t.Fatalf("clip does not start at sample frame %d", tt.from)
+				}
+				if mock.closed {
+					t.Error("DecodeRange closed the decoder")
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeRangeErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		channels, bps   int
+		start, duration time.Duration
+	}{
+		{"zero duration", 2, 16, 0, 0},
+		{"negative duration", 2, 16, 0, -time.Millisecond},
+		{"negative start", 2, 16, -time.Millisecond, time.Millisecond},
+		{"start past the end", 2, 16, time.Second, time.Millisecond},
+		{"no channels", 0, 16, 0, time.Millisecond},
+		{"zero bit depth", 2, 0, 0, time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := newMockDecoder(8000, tt.channels, tt.bps, rampPCM(2, 100))
+			if _, _, err := DecodeRange(dec, tt.start, tt.duration); err == nil {
+				t.Fatal("DecodeRange succeeded, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package decoders
+
+import (
+	"fmt"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/audioproc"
+)
+
+// OutputDepthSetter is implemented by decoders that can change the bit
+// depth of their output: FLAC decodes to the depths libFLAC supports
+// natively, and DepthConverter converts the output of any decoder.
+// SetOutputBitDepth returns an error for a depth the decoder can't produce.
+type OutputDepthSetter interface {
+	SetOutputBitDepth(bitsPerSample int) error
+}
+
+// SetOutputBitDepth returns dec with its output at bitsPerSample bits. A
+// decoder implementing OutputDepthSetter is asked to switch; if it can't, or
+// doesn't implement it, its output is wrapped in a DepthConverter.
+func SetOutputBitDepth(dec decoder.AudioDecoder, bitsPerSample int) (decoder.AudioDecoder, error) {
+	if _, _, bps := dec.GetFormat(); bps == bitsPerSample {
+		return dec, nil
+	}
+	if setter, ok := dec.(OutputDepthSetter); ok {
+		if err := setter.SetOutputBitDepth(bitsPerSample); err == nil {
+			return dec, nil
+		}
+	}
+	return NewDepthConverter(dec, bitsPerSample)
+}
+
+// DepthConverter wraps an AudioDecoder and converts its output to another
+// bit depth: widening is lossless, narrowing applies TPDF dither.
+type DepthConverter struct {
+	decoder.AudioDecoder
+
+	channels int
+	fromBits int
+	toBits   int
+	buf      []byte
+}
+
+// NewDepthConverter wraps dec to output bitsPerSample-bit samples.
+func NewDepthConverter(dec decoder.AudioDecoder, bitsPerSample int) (*DepthConverter, error) {
+	_, channels, bps := dec.GetFormat()
+	if err := checkDepth(bps); err != nil {
+		return nil, err
+	}
+	if err := checkDepth(bitsPerSample); err != nil {
+		return nil, err
+	}
+	if channels <= 0 {
+		return nil, fmt.Errorf("invalid channel count: %d", channels)
+	}
+	return &DepthConverter{AudioDecoder: dec, channels: channels, fromBits: bps, toBits: bitsPerSample}, nil
+}
+
+// SetOutputBitDepth changes the depth the output is converted to.
+func (d *DepthConverter) SetOutputBitDepth(bitsPerSample int) error {
+	if err := checkDepth(bitsPerSample); err != nil {
+		return err
+	}
+	d.toBits = bitsPerSample
+	return nil
+}
+
+// GetFormat returns the wrapped decoder's format at the converted depth.
+func (d *DepthConverter) GetFormat() (int, int, int) {
+	rate, channels, _ := d.AudioDecoder.GetFormat()
+	return rate, channels, d.toBits
+}
+
+// DecodeSamples decodes from the wrapped decoder and converts into audio.
+func (d *DepthConverter) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.fromBits == d.toBits {
+		return d.AudioDecoder.DecodeSamples(samples, audio)
+	}
+
+	inFrame := d.channels * d.fromBits / 8
+	samples = min(samples, len(audio)/(d.channels*d.toBits/8))
+	if cap(d.buf) < samples*inFrame {
+		d.buf = make([]byte, samples*inFrame)
+	}
+
+	n, err := d.AudioDecoder.DecodeSamples(samples, d.buf[:samples*inFrame])
+	if n > 0 {
+		var out []byte
+		var convErr error
+		if d.toBits > d.fromBits {
+			out, convErr = audioproc.WidenBitDepth(d.buf[:n*inFrame], d.fromBits, d.toBits)
+		} else {
+			out, convErr = audioproc.ReduceBitDepth(d.buf[:n*inFrame], d.channels, d.fromBits, d.toBits, audioproc.ShapingTPDF)
+		}
+		if convErr != nil {
+			return 0, convErr
+		}
+		copy(audio, out)
+	}
+	return n, err
+}
+
+// checkDepth reports whether bitsPerSample is a PCM depth DepthConverter
+// handles.
+func checkDepth(bitsPerSample int) error {
+	switch bitsPerSample {
+	case 8, 16, 24, 32:
+		return nil
+	}
+	return fmt.Errorf("unsupported bit depth: %d", bitsPerSample)
+}
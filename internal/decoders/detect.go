@@ -0,0 +1,104 @@
+package decoders
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnknownFormat is returned by DetectFormat for content it doesn't
+// recognize.
+var ErrUnknownFormat = errors.New("unknown audio format")
+
+// sniffSize is how much of the stream DetectFormat reads at each offset:
+// enough for an Ogg page header and the start of its first packet.
+const sniffSize = 64
+
+// DetectFormat identifies the audio format of r from its magic bytes and
+// returns the extension of the matching codec: ".wav", ".aiff", ".flac",
+// ".mp3", ".ogg" (Vorbis) or ".opus". An ID3v2 tag at the start is skipped.
+// Returns ErrUnknownFormat if the content matches none of them.
+func DetectFormat(r io.ReaderAt) (string, error) {
+	head, err := readHead(r, 0)
+	if err != nil {
+		return "", err
+	}
+
+	// An ID3v2 tag may precede MP3 (and, rarely, FLAC) data: skip it and
+	// look at what follows.
+	if len(head) >= 10 && bytes.HasPrefix(head, []byte("ID3")) {
+		size := int64(head[6]&0x7f)<<21 | int64(head[7]&0x7f)<<14 | int64(head[8]&0x7f)<<7 | int64(head[9]&0x7f)
+		offset := 10 + size
+		if head[5]&0x10 != 0 { // footer present
+			offset += 10
+		}
+		head, err = readHead(r, offset)
+		if err != nil {
+			return "", err
+		}
+		if bytes.HasPrefix(head, []byte("fLaC")) {
+			return ".flac", nil
+		}
+		// The tag alone says MP3 even if the audio doesn't start right
+		// after it; the MP3 decoder resyncs.
+		return ".mp3", nil
+	}
+
+	switch {
+	case len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "WAVE":
+		return ".wav", nil
+	case len(head) >= 12 && bytes.HasPrefix(head, []byte("FORM")) &&
+		(string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC"):
+		return ".aiff", nil
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return ".flac", nil
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return oggFormat(head)
+	case isMP3Sync(head):
+		return ".mp3", nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// readHead reads up to sniffSize bytes of r at offset. A short read at the
+// end of r is not an error.
+func readHead(r io.ReaderAt, offset int64) ([]byte, error) {
+	buf := make([]byte, sniffSize)
+	n, err := r.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read format header: %w", err)
+	}
+	return buf[:n], nil
+}
+
+// oggFormat tells Vorbis from Opus by the first packet of an Ogg stream,
+// which follows the 27-byte page header and its segment table.
+func oggFormat(head []byte) (string, error) {
+	if len(head) < 27 {
+		return "", ErrUnknownFormat
+	}
+	packet := head[min(len(head), 27+int(head[26])):]
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		return ".ogg", nil
+	case bytes.HasPrefix(packet, []byte("OpusHead")):
+		return ".opus", nil
+	}
+	return "", fmt.Errorf("unsupported Ogg stream: %w", ErrUnknownFormat)
+}
+
+// isMP3Sync reports whether head starts with an MPEG audio frame header:
+// an 11-bit frame sync followed by a valid version, a layer other than the
+// reserved one (which rules out AAC ADTS) and a valid bitrate and sample
+// rate.
+func isMP3Sync(head []byte) bool {
+	if len(head) < 4 || head[0] != 0xff || head[1]&0xe0 != 0xe0 {
+		return false
+	}
+	version := head[1] >> 3 & 0x3
+	layer := head[1] >> 1 & 0x3
+	bitrate := head[2] >> 4
+	sampleRate := head[2] >> 2 & 0x3
+	return version != 1 && layer != 0 && bitrate != 0xf && sampleRate != 0x3
+}
@@ -0,0 +1,78 @@
+package decoders
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// oggPage returns the start of an Ogg page whose first packet is packet,
+// with a segment table of segments entries.
+func oggPage(segments int, packet string) []byte {
+	b := make([]byte, 27+segments)
+	copy(b, "OggS")
+	b[26] = byte(segments)
+	return append(b, packet...)
+}
+
+// id3Tag returns an ID3v2 header for a tag body of size bytes, the body,
+// and a footer if footer is set.
+func id3Tag(size int, footer bool) []byte {
+	b := []byte{'I', 'D', '3', 4, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	if footer {
+		b[5] = 0x10
+	}
+	b = append(b, make([]byte, size)...)
+	if footer {
+		b = append(b, "3DI"...)
+		b = append(b, make([]byte, 7)...)
+	}
+	return b
+}
+
+func TestDetectFormat(t *testing.T) {
+	mp3Frame := []byte{0xff, 0xfb, 0x90, 0x64}
+	cat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), ".wav", false},
+		{"aiff", []byte("FORM\x00\x00\x00\x00AIFFCOMM"), ".aiff", false},
+		{"aifc", []byte("FORM\x00\x00\x00\x00AIFCFVER"), ".aiff", false},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), ".flac", false},
+		{"vorbis", oggPage(1, "\x01vorbis"), ".ogg", false},
+		{"opus", oggPage(1, "OpusHead"), ".opus", false},
+		{"mp3 frame", mp3Frame, ".mp3", false},
+		{"id3 then mp3", cat(id3Tag(300, false), mp3Frame), ".mp3", false},
+		{"id3 then flac", cat(id3Tag(300, false), []byte("fLaC")), ".flac", false},
+		{"id3 with footer then flac", cat(id3Tag(20, true), []byte("fLaC")), ".flac", false},
+		{"id3 tag alone", id3Tag(20, false), ".mp3", false},
+		{"riff but not wave", []byte("RIFF\x24\x00\x00\x00AVI LIST"), "", true},
+		{"truncated riff", []byte("RIFF\x24\x00"), "", true},
+		{"ogg of another codec", oggPage(1, "\x80theora"), "", true},
+		{"truncated ogg page", []byte("OggS\x00\x02"), "", true},
+		{"adts aac", []byte{0xff, 0xf1, 0x50, 0x80}, "", true},
+		{"reserved mpeg version", []byte{0xff, 0xeb, 0x90, 0x64}, "", true},
+		{"bad bitrate", []byte{0xff, 0xfb, 0xf0, 0x64}, "", true},
+		{"text", []byte("hello, world"), "", true},
+		{"empty", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(bytes.NewReader(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownFormat) {
+					t.Fatalf("DetectFormat = %q, %v, want ErrUnknownFormat", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("DetectFormat = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package decoders
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// IsEOF reports whether err marks a clean end of stream.
+// Some codec bindings report end of stream with their own error values
+// whose text mentions EOF or "done" instead of wrapping io.EOF.
+func IsEOF(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "EOF") || strings.Contains(msg, "done")
+}
+
+// ErrorTracker wraps an AudioDecoder and records the first decode error that
+// is not a clean end of stream, e.g. from a truncated or corrupt file.
+//
+// Players stop producing on any decode error and then play out what is
+// already buffered, so a truncated file still plays up to the damage;
+// ErrorTracker lets the caller tell that apart from a clean finish.
+type ErrorTracker struct {
+	decoder.AudioDecoder
+
+	mu      sync.Mutex
+	err     error
+	samples int64
+}
+
+// NewErrorTracker wraps dec to record decode errors.
+func NewErrorTracker(dec decoder.AudioDecoder) *ErrorTracker {
+	return &ErrorTracker{AudioDecoder: dec}
+}
+
+// DecodeSamples decodes from the wrapped decoder, recording unexpected errors.
+func (d *ErrorTracker) DecodeSamples(samples int, audio []byte) (int, error) {
+	n, err := d.AudioDecoder.DecodeSamples(samples, audio)
+
+	d.mu.Lock()
+	d.samples += int64(n)
+	if err != nil && !IsEOF(err) && d.err == nil {
+		d.err = err
+	}
+	d.mu.Unlock()
+
+	return n, err
+}
+
+// Err returns the first unexpected decode error, or nil if the stream
+// decoded cleanly so far.
+func (d *ErrorTracker) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// DecodedSamples returns the number of sample frames decoded so far.
+func (d *ErrorTracker) DecodedSamples() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.samples
+}
@@ -0,0 +1,48 @@
+package decoders
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestErrorTrackerMidStreamError(t *testing.T) {
+	errBroken := errors.New("broken stream")
+	pcm := rampPCM(2, 100)
+	mock := newMockDecoder(44100, 2, 16, pcm)
+	mock.endErr = errBroken
+	dec := NewErrorTracker(mock)
+
+	got, err := readAll(dec, 32)
+	if err != errBroken {
+		t.Fatalf("readAll error = %v, want %v", err, errBroken)
+	}
+	if !bytes.Equal(got, pcm) {
+		t.Fatalf("decoded %d bytes before the error, want %d", len(got), len(pcm))
+	}
+	if dec.Err() != errBroken {
+		t.Errorf("Err = %v, want %v", dec.Err(), errBroken)
+	}
+	if n := dec.DecodedSamples(); n != 100 {
+		t.Errorf("DecodedSamples = %d, want 100", n)
+	}
+}
+
+func TestErrorTrackerCleanEnd(t *testing.T) {
+	for _, endErr := range []error{io.EOF, fmt.Errorf("decoder done")} {
+		mock := newMockDecoder(44100, 2, 16, rampPCM(2, 50))
+		mock.endErr = endErr
+		dec := NewErrorTracker(mock)
+		if _, err := readAll(dec, 32); err != nil && !IsEOF(err) {
+			t.Fatal(err)
+		}
+		if dec.Err() != nil {
+			t.Errorf("end %q: Err = %v, want nil", endErr, dec.Err())
+		}
+		if n := dec.DecodedSamples(); n != 50 {
+			t.Errorf("end %q: DecodedSamples = %d, want 50", endErr, n)
+		}
+	}
+}
@@ -1,29 +1,138 @@
 package decoders
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/drgolem/audiokit/pkg/decoder"
 	"github.com/drgolem/audiokit/pkg/decoder/flac"
 	"github.com/drgolem/audiokit/pkg/decoder/mp3"
 	"github.com/drgolem/audiokit/pkg/decoder/opus"
 	"github.com/drgolem/audiokit/pkg/decoder/vorbis"
-	"github.com/drgolem/audiokit/pkg/decoder/wav"
+	"github.com/drgolem/musictools/internal/decoders/aiff"
+	"github.com/drgolem/musictools/internal/decoders/wav"
 )
 
+// codecs maps the supported file extensions to their decoder constructors.
+var codecs = map[string]decoder.ConstructorFn{
+	".mp3":  func(int) (decoder.AudioDecoder, error) { return &mp3Decoder{Decoder: mp3.NewDecoder()}, nil },
+	".flac": newFLACDecoder,
+	".fla":  newFLACDecoder,
+	".wav":  func(int) (decoder.AudioDecoder, error) { return wav.NewDecoder(), nil },
+	".ogg":  func(bps int) (decoder.AudioDecoder, error) { return vorbis.NewDecoder(bps) },
+	".oga":  func(bps int) (decoder.AudioDecoder, error) { return vorbis.NewDecoder(bps) },
+	".opus": func(int) (decoder.AudioDecoder, error) { return opus.NewDecoder(), nil },
+	".aiff": func(int) (decoder.AudioDecoder, error) { return aiff.NewDecoder(), nil },
+	".aif":  func(int) (decoder.AudioDecoder, error) { return aiff.NewDecoder(), nil },
+	".aifc": func(int) (decoder.AudioDecoder, error) { return aiff.NewDecoder(), nil },
+}
+
+// extensionFormat maps extension aliases to the extension DetectFormat
+// reports for the same content.
+var extensionFormat = map[string]string{
+	".fla":  ".flac",
+	".oga":  ".ogg",
+	".aif":  ".aiff",
+	".aifc": ".aiff",
+}
+
 // NewRegistry creates a decoder registry pre-loaded with all supported codecs.
 func NewRegistry() *decoder.Registry {
 	r := decoder.NewRegistry()
-	r.Register(".mp3", func(int) (decoder.AudioDecoder, error) { return mp3.NewDecoder(), nil })
-	r.Register(".flac", func(bps int) (decoder.AudioDecoder, error) { return flac.NewDecoder(bps) })
-	r.Register(".fla", func(bps int) (decoder.AudioDecoder, error) { return flac.NewDecoder(bps) })
-	r.Register(".wav", func(int) (decoder.AudioDecoder, error) { return wav.NewDecoder(), nil })
-	r.Register(".ogg", func(bps int) (decoder.AudioDecoder, error) { return vorbis.NewDecoder(bps) })
-	r.Register(".oga", func(bps int) (decoder.AudioDecoder, error) { return vorbis.NewDecoder(bps) })
-	r.Register(".opus", func(int) (decoder.AudioDecoder, error) { return opus.NewDecoder(), nil })
+	for ext, fn := range codecs {
+		r.Register(ext, fn)
+	}
 	return r
 }
 
+// newFLACDecoder creates a FLAC decoder with output at bps bits.
+func newFLACDecoder(bps int) (decoder.AudioDecoder, error) {
+	dec, err := flac.NewDecoder(bps)
+	if err != nil {
+		return nil, err
+	}
+	return &flacDecoder{Decoder: dec}, nil
+}
+
 // NewDecoder creates and opens the appropriate decoder based on file extension.
-// Supports .mp3, .flac, .fla, .wav, .ogg, .oga, and .opus formats.
+// Supports .mp3, .flac, .fla, .wav, .ogg, .oga, .opus, .aiff, .aif and .aifc formats.
+// If the extension is unknown or its decoder fails to open the file, the
+// format is detected from the file's content instead.
 func NewDecoder(fileName string) (decoder.AudioDecoder, error) {
-	return NewRegistry().NewFromFile(fileName, 0)
+	return openFile(fileName, 0)
+}
+
+// NewDecoderWithBitDepth opens fileName with output at bitsPerSample bits.
+// Codecs that can decode to a chosen depth (FLAC, Vorbis) are asked for it
+// directly; the output of the others (MP3, Opus, WAV, AIFF) is converted.
+func NewDecoderWithBitDepth(fileName string, bitsPerSample int) (decoder.AudioDecoder, error) {
+	dec, err := openFile(fileName, bitsPerSample)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := SetOutputBitDepth(dec, bitsPerSample)
+	if err != nil {
+		dec.Close()
+		return nil, err
+	}
+	return out, nil
+}
+
+// openFile opens fileName with the decoder for its extension, falling back
+// to the one for its detected format. The file is only sniffed when the
+// extension doesn't work, so correctly named files cost no extra reads.
+func openFile(fileName string, bps int) (decoder.AudioDecoder, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	var extErr error
+	if _, ok := codecs[ext]; ok {
+		dec, err := openAs(fileName, ext, bps)
+		if err == nil {
+			return dec, nil
+		}
+		extErr = err
+	} else {
+		extErr = fmt.Errorf("unsupported file format: %s", ext)
+	}
+
+	detected, err := detectFileFormat(fileName)
+	if err != nil {
+		return nil, extErr
+	}
+	if format, ok := extensionFormat[ext]; ok {
+		ext = format
+	}
+	if detected == ext {
+		return nil, extErr
+	}
+	dec, err := openAs(fileName, detected, bps)
+	if err != nil {
+		return nil, fmt.Errorf("%s content detected as %s: %w", filepath.Base(fileName), detected, err)
+	}
+	return dec, nil
+}
+
+// openAs opens fileName with the decoder registered for ext.
+func openAs(fileName, ext string, bps int) (decoder.AudioDecoder, error) {
+	dec, err := codecs[ext](bps)
+	if err != nil {
+		return nil, fmt.Errorf("creating decoder for %s: %w", ext, err)
+	}
+	if err := dec.Open(fileName); err != nil {
+		dec.Close()
+		return nil, fmt.Errorf("opening %s: %w", filepath.Base(fileName), err)
+	}
+	return dec, nil
+}
+
+// detectFileFormat runs DetectFormat on fileName.
+func detectFileFormat(fileName string) (string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return DetectFormat(f)
 }
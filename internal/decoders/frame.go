@@ -0,0 +1,48 @@
+package decoders
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/drgolem/audiokit/pkg/audioframe"
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// DecodeFrame decodes up to samples sample frames from dec into a new
+// AudioFrame carrying the decoder's format. The frame owns its audio buffer.
+//
+// A short read at the end of the stream returns the partial frame with a nil
+// error; the following call returns io.EOF. Decode errors other than end of
+// stream are returned as is, with any audio decoded before them dropped.
+func DecodeFrame(dec decoder.AudioDecoder, samples int) (audioframe.AudioFrame, error) {
+	if samples <= 0 || samples > math.MaxUint16 {
+		return audioframe.AudioFrame{}, fmt.Errorf("frame sample count out of range: %d", samples)
+	}
+
+	rate, channels, bps := dec.GetFormat()
+	frameSize := channels * bps / 8
+	if frameSize <= 0 {
+		return audioframe.AudioFrame{}, fmt.Errorf("invalid decoder format: %d channels, %d bits per sample",
+			channels, bps)
+	}
+
+	audio := make([]byte, samples*frameSize)
+	n, err := dec.DecodeSamples(samples, audio)
+	if err != nil && !IsEOF(err) {
+		return audioframe.AudioFrame{}, err
+	}
+	if n == 0 {
+		return audioframe.AudioFrame{}, io.EOF
+	}
+
+	return audioframe.AudioFrame{
+		Format: audioframe.FrameFormat{
+			SampleRate:    uint32(rate),
+			Channels:      uint8(channels),
+			BitsPerSample: uint8(bps),
+		},
+		SamplesCount: uint16(n),
+		Audio:        audio[:n*frameSize],
+	}, nil
+}
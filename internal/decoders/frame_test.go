@@ -0,0 +1,94 @@
+package decoders
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestDecodeFrame(t *testing.T) {
+	// 250 stereo frames read 100 at a time: two full frames, a short one,
+	// then the end of the stream.
+	pcm := rampPCM(2, 250)
+	dec := newMockDecoder(44100, 2, 16, pcm)
+
+	var got []byte
+	for i, want := range []int{100, 100, 50} {
+		frame, err := DecodeFrame(dec, 100)
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		f := frame.Format
+		if f.SampleRate != 44100 || f.Channels != 2 || f.BitsPerSample != 16 {
+			t.Fatalf("frame %d format = %+v", i, f)
+		}
+		if int(frame.SamplesCount) != want || len(frame.Audio) != 4*want {
+			t.Fatalf("frame %d has %d samples in %d bytes, want %d", i, frame.SamplesCount, len(frame.Audio), want)
+		}
+		got = append(got, frame.Audio...)
+	}
+	if !bytes.Equal(got, pcm) {
+		t.Fatal("frames do not concatenate to the stream")
+	}
+	for range 2 {
+		if _, err := DecodeFrame(dec, 100); err != io.EOF {
+			t.Fatalf("DecodeFrame at the end = %v, want io.EOF", err)
+		}
+	}
+}
+
+func TestDecodeFrameOwnsAudio(t *testing.T) {
+	dec := newMockDecoder(8000, 1, 16, rampPCM(1, 20))
+	first, err := DecodeFrame(dec, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bytes.Clone(first.Audio)
+	if _, err := DecodeFrame(dec, 10); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Audio, want) {
+		t.Fatal("the next frame overwrote the first's audio")
+	}
+}
+
+func TestDecodeFrameErrors(t *testing.T) {
+	errBroken := errors.New("broken stream")
+	tests := []struct {
+		name    string
+		dec     *mockDecoder
+		samples int
+		want    error // nil for any error
+	}{
+		{"no samples", newMockDecoder(8000, 1, 16, rampPCM(1, 10)), 0, nil},
+		{"negative samples", newMockDecoder(8000, 1, 16, rampPCM(1, 10)), -1, nil},
+		{"more samples than a frame holds", newMockDecoder(8000, 1, 16, rampPCM(1, 10)), math.MaxUint16 + 1, nil},
+		{"no channels", newMockDecoder(8000, 0, 16, nil), 10, nil},
+		{"zero bit depth", newMockDecoder(8000, 1, 0, nil), 10, nil},
+		{"decode error", &mockDecoder{rate: 8000, channels: 1, bps: 16, endErr: errBroken}, 10, errBroken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeFrame(tt.dec, tt.samples)
+			if err == nil || err == io.EOF {
+				t.Fatalf("DecodeFrame error = %v, want a failure", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("DecodeFrame error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFrameMaxSamples(t *testing.T) {
+	dec := newMockDecoder(8000, 1, 16, rampPCM(1, math.MaxUint16+10))
+	frame, err := DecodeFrame(dec, math.MaxUint16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.SamplesCount != math.MaxUint16 {
+		t.Fatalf("SamplesCount = %d, want %d", frame.SamplesCount, math.MaxUint16)
+	}
+}
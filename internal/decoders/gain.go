@@ -0,0 +1,92 @@
+package decoders
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/audioproc"
+)
+
+// ChannelGainDecoder wraps an AudioDecoder and applies a fixed gain to each
+// interleaved channel of the decoded PCM (balance and per-channel trims).
+type ChannelGainDecoder struct {
+	decoder.AudioDecoder
+	gains         []float64
+	channels      int
+	bitsPerSample int
+}
+
+// NewChannelGainDecoder wraps dec with per-channel gains.
+// The number of gains must match the decoder's channel count.
+func NewChannelGainDecoder(dec decoder.AudioDecoder, gains []float64) (*ChannelGainDecoder, error) {
+	_, channels, bps := dec.GetFormat()
+	if len(gains) != channels {
+		return nil, fmt.Errorf("got %d channel gains for %d channels", len(gains), channels)
+	}
+
+	return &ChannelGainDecoder{
+		AudioDecoder:  dec,
+		gains:         gains,
+		channels:      channels,
+		bitsPerSample: bps,
+	}, nil
+}
+
+// DecodeSamples decodes from the wrapped decoder and applies the channel gains.
+func (d *ChannelGainDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	n, err := d.AudioDecoder.DecodeSamples(samples, audio)
+	if n > 0 {
+		bytesDecoded := n * d.channels * d.bitsPerSample / 8
+		if gainErr := audioproc.ApplyChannelGains(audio[:bytesDecoded], d.bitsPerSample, d.gains); gainErr != nil {
+			return n, gainErr
+		}
+	}
+	return n, err
+}
+
+// GainDecoder wraps an AudioDecoder and scales every decoded sample by a
+// fixed linear gain, clamping to the sample range, e.g. to apply ReplayGain
+// so any player plays at the adjusted level.
+type GainDecoder struct {
+	decoder.AudioDecoder
+	gain          float64
+	frameSize     int
+	bitsPerSample int
+}
+
+// NewGainDecoder wraps dec with a linear gain (1.0 leaves samples unchanged).
+func NewGainDecoder(dec decoder.AudioDecoder, gain float64) (*GainDecoder, error) {
+	if gain < 0 || math.IsNaN(gain) || math.IsInf(gain, 0) {
+		return nil, fmt.Errorf("invalid gain: %g", gain)
+	}
+	_, channels, bps := dec.GetFormat()
+
+	return &GainDecoder{
+		AudioDecoder:  dec,
+		gain:          gain,
+		frameSize:     channels * bps / 8,
+		bitsPerSample: bps,
+	}, nil
+}
+
+// NewGainDecoderDB wraps dec with a gain given in decibels.
+func NewGainDecoderDB(dec decoder.AudioDecoder, db float64) (*GainDecoder, error) {
+	return NewGainDecoder(dec, audioproc.DBToGain(db))
+}
+
+// Gain returns the linear gain applied.
+func (d *GainDecoder) Gain() float64 {
+	return d.gain
+}
+
+// DecodeSamples decodes from the wrapped decoder and applies the gain.
+func (d *GainDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	n, err := d.AudioDecoder.DecodeSamples(samples, audio)
+	if n > 0 && d.gain != 1.0 {
+		if gainErr := audioproc.ApplyGain(audio[:n*d.frameSize], d.bitsPerSample, d.gain); gainErr != nil {
+			return n, gainErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,90 @@
+package decoders
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestChannelGainDecoder(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    []byte
+		gains []float64
+		want  []byte
+	}{
+		{"unity", audiotest.PCM16(100, -100, 200, -200), []float64{1, 1}, audiotest.PCM16(100, -100, 200, -200)},
+		{"left muted", audiotest.PCM16(100, -100, 200, -200), []float64{0, 1}, audiotest.PCM16(0, -100, 0, -200)},
+		{"right halved", audiotest.PCM16(100, -101, 200, -201), []float64{1, 0.5}, audiotest.PCM16(100, -51, 200, -101)},
+		{"clamped", audiotest.PCM16(20000, -20000), []float64{2, 2}, audiotest.PCM16(32767, -32768)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newMockDecoder(44100, 2, 16, tt.in)
+			src.block = 1
+			dec, err := NewChannelGainDecoder(src, tt.gains)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := readAll(dec, 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelGainDecoderGainCount(t *testing.T) {
+	src := newMockDecoder(44100, 2, 16, nil)
+	for _, gains := range [][]float64{nil, {1}, {1, 1, 1}} {
+		if _, err := NewChannelGainDecoder(src, gains); err == nil {
+			t.Errorf("NewChannelGainDecoder with %d gains for 2 channels succeeded, want error", len(gains))
+		}
+	}
+}
+
+func TestGainDecoder(t *testing.T) {
+	tests := []struct {
+		name string
+		bps  int
+		in   []byte
+		db   float64
+		want []byte
+	}{
+		{"0 dB", 16, audiotest.PCM16(1000, -1000, 32767), 0, audiotest.PCM16(1000, -1000, 32767)},
+		{"-6 dB", 16, audiotest.PCM16(1000, -1000, 0), -6.0206, audiotest.PCM16(500, -500, 0)},
+		{"+6 dB clamps", 16, audiotest.PCM16(1000, 20000, -20000), 6.0206, audiotest.PCM16(2000, 32767, -32768)},
+		{"8-bit", 8, []byte{0x80, 0x90, 0x70}, -6.0206, []byte{0x80, 0x88, 0x78}},
+		{"24-bit", 24, []byte{0x00, 0x10, 0x00, 0x00, 0xF0, 0xFF}, -6.0206, []byte{0x00, 0x08, 0x00, 0x00, 0xF8, 0xFF}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newMockDecoder(44100, 1, tt.bps, tt.in)
+			dec, err := NewGainDecoderDB(src, tt.db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := readAll(dec, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGainDecoderInvalidGain(t *testing.T) {
+	src := newMockDecoder(44100, 1, 16, nil)
+	for _, gain := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if _, err := NewGainDecoder(src, gain); err == nil {
+			t.Errorf("NewGainDecoder(%g) succeeded, want error", gain)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package decoders
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// Samples returns an iterator over the decoder's PCM output in chunks of up
+// to chunk sample frames. Each step yields the decoded bytes, or a nil slice
+// and the decode error, after which iteration ends. End of stream ends
+// iteration without an error.
+//
+// The yielded slice is reused between steps, so callers must copy any data
+// they keep. Breaking out of the loop early is safe and leaves the decoder
+// open; Samples never closes it.
+func Samples(dec decoder.AudioDecoder, chunk int) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		_, channels, bps := dec.GetFormat()
+		frameSize := channels * bps / 8
+		if frameSize <= 0 || chunk <= 0 {
+			yield(nil, fmt.Errorf("invalid chunk layout: %d channels, %d bits per sample, %d samples",
+				channels, bps, chunk))
+			return
+		}
+
+		buf := make([]byte, chunk*frameSize)
+		for {
+			n, err := dec.DecodeSamples(chunk, buf)
+			if n > 0 && !yield(buf[:n*frameSize], nil) {
+				return
+			}
+			if err != nil {
+				if !IsEOF(err) {
+					yield(nil, err)
+				}
+				return
+			}
+			if n == 0 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package decoders
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSamples(t *testing.T) {
+	pcm := rampPCM(2, 10000)
+	errBad := errors.New("bad frame")
+
+	tests := []struct {
+		name    string
+		block   int
+		chunk   int
+		endErr  error
+		wantErr error
+	}{
+		{"chunks divide the stream", 0, 1000, nil, nil},
+		{"last chunk short", 0, 4096, nil, nil},
+		{"one chunk", 0, 10000, nil, nil},
+		{"short decodes", 333, 1000, nil, nil},
+		{"decode error after the data", 0, 4096, errBad, errBad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := newMockDecoder(44100, 2, 16, pcm)
+			dec.block = tt.block
+			dec.endErr = tt.endErr
+
+			var got []byte
+			var gotErr error
+			for chunk, err := range Samples(dec, tt.chunk) {
+				if err != nil {
+					if chunk != nil {
+						t.Errorf("yielded %d bytes with the error", len(chunk))
+					}
+					gotErr = err
+					continue
+				}
+				if len(chunk) == 0 || len(chunk) > 4*tt.chunk || len(chunk)%4 != 0 {
+					t.Fatalf("yielded a chunk of %d bytes", len(chunk))
+				}
+				got = append(got, chunk...)
+			}
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", gotErr, tt.wantErr)
+			}
+			if !bytes.Equal(got, pcm) {
+				t.Fatalf("chunks concatenate to %d bytes, want %d", len(got), len(pcm))
+			}
+			if dec.closed {
+				t.Fatal("Samples closed the decoder")
+			}
+		})
+	}
+}
+
+func TestSamplesStopEarly(t *testing.T) {
+	pcm := rampPCM(1, 10000)
+	dec := newMockDecoder(8000, 1, 16, pcm)
+
+	steps := 0
+	for chunk, err := range Samples(dec, 1000) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(chunk, pcm[2*1000*steps:2*1000*(steps+1)]) {
+			t.Fatalf("chunk %d has the wrong samples", steps)
+		}
+		steps++
+		if steps == 3 {
+			break
+		}
+	}
+
+	// The decoder is left open where iteration stopped.
+	if dec.closed {
+		t.Fatal("Samples closed the decoder")
+	}
+	rest, err := readAll(dec, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rest, pcm[2*3000:]) {
+		t.Fatalf("decoder continued with %d bytes, want %d", len(rest), len(pcm)-2*3000)
+	}
+}
+
+func TestSamplesInvalidLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels int
+		chunk    int
+	}{
+		{"no channels", 0, 100},
+		{"empty chunk", 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := newMockDecoder(8000, tt.channels, 16, rampPCM(1, 10))
+			var errs int
+			for chunk, err := range Samples(dec, tt.chunk) {
+				if err == nil || chunk != nil {
+					t.Fatalf("yielded %d bytes, %v, want only an error", len(chunk), err)
+				}
+				errs++
+			}
+			if errs != 1 {
+				t.Fatalf("yielded %d errors, want 1", errs)
+			}
+		})
+	}
+}
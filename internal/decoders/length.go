@@ -0,0 +1,43 @@
+package decoders
+
+import (
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/decoders/codecerr"
+)
+
+// ErrUnknownLength is returned by TotalSamples for streams whose length
+// can't be known without decoding them.
+var ErrUnknownLength = codecerr.ErrUnknownLength
+
+// Lengther is implemented by decoders that know the length of their stream
+// up front: WAV, AIFF and raw PCM from the data size, FLAC from STREAMINFO
+// and MP3 from a frame scan.
+type Lengther interface {
+	// TotalSamples returns the number of sample frames in the stream.
+	TotalSamples() (int64, error)
+}
+
+// TotalSamples returns the number of sample frames in dec's stream, or
+// ErrUnknownLength if dec doesn't implement Lengther. Wrappers don't pass
+// the length through, so ask the decoder the file was opened with.
+func TotalSamples(dec decoder.AudioDecoder) (int64, error) {
+	if l, ok := dec.(Lengther); ok {
+		return l.TotalSamples()
+	}
+	return 0, ErrUnknownLength
+}
+
+// Duration returns the length of dec's stream, as for TotalSamples.
+func Duration(dec decoder.AudioDecoder) (time.Duration, error) {
+	total, err := TotalSamples(dec)
+	if err != nil {
+		return 0, err
+	}
+	rate, _, _ := dec.GetFormat()
+	if rate <= 0 {
+		return 0, ErrUnknownLength
+	}
+	return time.Duration(total * int64(time.Second) / int64(rate)), nil
+}
@@ -0,0 +1,217 @@
+package decoders
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/audioproc"
+)
+
+// mixSource is one input of a MixingDecoder.
+type mixSource struct {
+	id     int
+	dec    decoder.AudioDecoder
+	volume float64
+	buf    []byte
+}
+
+// MixingDecoder plays several decoders at once by summing their output,
+// e.g. background music with sound effects on top. Sources can be added,
+// removed and have their volume changed while it is playing. All sources
+// must have the mixer's format.
+//
+// A source is closed and dropped when it returns an error, including io.EOF.
+// A source with no data ready, returning (0, nil), is kept and contributes
+// silence to the rest of that call. The mix ends (io.EOF) once no sources
+// are left, so add the next source before the last one ends to keep playing.
+type MixingDecoder struct {
+	rate     int
+	channels int
+	bps      int
+
+	mu      sync.Mutex
+	sources []*mixSource
+	nextID  int
+}
+
+// NewMixingDecoder returns an empty mixer producing the given format.
+func NewMixingDecoder(sampleRate, channels, bitsPerSample int) (*MixingDecoder, error) {
+	if sampleRate <= 0 || channels <= 0 {
+		return nil, fmt.Errorf("invalid mixer format: %d Hz, %d channels", sampleRate, channels)
+	}
+	switch bitsPerSample {
+	case 8, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("unsupported bit depth: %d", bitsPerSample)
+	}
+	return &MixingDecoder{rate: sampleRate, channels: channels, bps: bitsPerSample}, nil
+}
+
+// AddSource adds dec to the mix at the given linear volume and returns an id
+// for RemoveSource and SetVolume. The mixer takes ownership of dec.
+func (m *MixingDecoder) AddSource(dec decoder.AudioDecoder, volume float64) (int, error) {
+	rate, channels, bps := dec.GetFormat()
+	if rate != m.rate || channels != m.channels || bps != m.bps {
+		return 0, fmt.Errorf("source format %d Hz/%dch/%d-bit does not match mixer %d Hz/%dch/%d-bit",
+			rate, channels, bps, m.rate, m.channels, m.bps)
+	}
+	if volume < 0 {
+		return 0, fmt.Errorf("volume must not be negative: %g", volume)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	m.sources = append(m.sources, &mixSource{id: m.nextID, dec: dec, volume: volume})
+	return m.nextID, nil
+}
+
+// RemoveSource closes and removes a source. Returns false if there is no
+// source with that id, e.g. because it already ended.
+func (m *MixingDecoder) RemoveSource(id int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, src := range m.sources {
+		if src.id == id {
+			src.dec.Close()
+			m.sources = append(m.sources[:i], m.sources[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetVolume changes the linear volume of a source. Returns false if there is
+// no source with that id.
+func (m *MixingDecoder) SetVolume(id int, volume float64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, src := range m.sources {
+		if src.id == id {
+			src.volume = max(0, volume)
+			return true
+		}
+	}
+	return false
+}
+
+// Sources returns the number of sources still playing.
+func (m *MixingDecoder) Sources() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sources)
+}
+
+// Open is not supported; sources are added with AddSource.
+func (m *MixingDecoder) Open(fileName string) error {
+	return errors.New("mixing decoder cannot open files")
+}
+
+// GetFormat returns the mixer format.
+func (m *MixingDecoder) GetFormat() (int, int, int) {
+	return m.rate, m.channels, m.bps
+}
+
+// DecodeSamples decodes the same number of samples from every source and
+// mixes them. Each source is read until it fills the request, so decoders
+// returning one block per call don't leave gaps; a source is only padded
+// with silence when it ends or has no data ready. Returns (0, nil) if no
+// source had any data.
+func (m *MixingDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	frameSize := m.channels * m.bps / 8
+	samples = min(samples, len(audio)/frameSize)
+	size := samples * frameSize
+
+	streams := make([][]byte, 0, len(m.sources))
+	gains := make([]float64, 0, len(m.sources))
+	live := m.sources[:0]
+	mixed := 0
+
+	for _, src := range m.sources {
+		if cap(src.buf) < size {
+			src.buf = make([]byte, size)
+		}
+		buf := src.buf[:size]
+
+		got, err := fillFrom(src.dec, samples, buf, frameSize)
+		if got > 0 {
+			fillSilence(buf[got*frameSize:], m.bps)
+			streams = append(streams, buf)
+			gains = append(gains, src.volume)
+			mixed = max(mixed, got)
+		}
+		if err != nil {
+			src.dec.Close()
+			continue
+		}
+		live = append(live, src)
+	}
+	clear(m.sources[len(live):])
+	m.sources = live
+
+	if mixed == 0 {
+		if len(m.sources) == 0 {
+			return 0, io.EOF
+		}
+		return 0, nil
+	}
+
+	out, err := audioproc.Mix(streams, m.bps, gains)
+	if err != nil {
+		return 0, err
+	}
+	copy(audio, out[:mixed*frameSize])
+	return mixed, nil
+}
+
+// fillFrom decodes from dec into buf until samples sample frames are read,
+// dec returns an error, or dec has no data ready. Returns the number of
+// sample frames read.
+func fillFrom(dec decoder.AudioDecoder, samples int, buf []byte, frameSize int) (int, error) {
+	got := 0
+	for got < samples {
+		n, err := dec.DecodeSamples(samples-got, buf[got*frameSize:])
+		got += n
+		if err != nil {
+			return got, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return got, nil
+}
+
+// Close closes all remaining sources.
+func (m *MixingDecoder) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for _, src := range m.sources {
+		errs = append(errs, src.dec.Close())
+	}
+	m.sources = nil
+	return errors.Join(errs...)
+}
+
+// fillSilence writes digital silence: zero for signed samples, 0x80 for
+// unsigned 8-bit.
+func fillSilence(buf []byte, bitsPerSample int) {
+	fill := byte(0)
+	if bitsPerSample == 8 {
+		fill = 0x80
+	}
+	for i := range buf {
+		buf[i] = fill
+	}
+}
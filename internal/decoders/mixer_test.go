@@ -0,0 +1,127 @@
+package decoders
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audioproc"
+)
+
+func TestMixingDecoderMixesTones(t *testing.T) {
+	const (
+		rate    = 8000
+		samples = 4096
+	)
+	// Frequencies on exact spectrum bins: bin k is at k*rate/samples Hz.
+	lowBin, highBin := 225, 640
+	binFreq := func(k int) float64 { return float64(k) * rate / samples }
+
+	mixer, err := NewMixingDecoder(rate, 1, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Block sizes like codecs returning one frame per call, differing so
+	// that a source padded mid-stream would show up as a gap.
+	for i, k := range []int{lowBin, highBin} {
+		block := []int{160, 441}[i]
+		if _, err := mixer.AddSource(newToneDecoder(binFreq(k), rate, samples, block), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := readAll(mixer, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2*samples {
+		t.Fatalf("mixed %d samples, want %d", len(out)/2, samples)
+	}
+
+	floats, err := audioproc.ToFloat64(out, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mags, err := audioproc.Spectrum(floats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []int{lowBin, highBin} {
+		if mags[k] < 0.4 {
+			t.Errorf("%.1f Hz magnitude = %.3f, want about 0.5", binFreq(k), mags[k])
+		}
+	}
+	if off := mags[512]; off > 0.01 {
+		t.Errorf("%.1f Hz magnitude = %.3f, want no energy between the tones", binFreq(512), off)
+	}
+}
+
+func TestMixingDecoderFillsFromBlockSources(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []int // block size of each source
+		chunk  int
+	}{
+		{"single source, small blocks", []int{7}, 64},
+		{"blocks larger than the request", []int{100}, 64},
+		{"two sources, ramp in smaller blocks", []int{5, 7}, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := rampPCM(1, 1000)
+			mixer, err := NewMixingDecoder(8000, 1, 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, block := range tt.blocks {
+				// The first source carries the ramp; the others are
+				// shorter and silent, so the mix must equal the ramp.
+				pcm := want
+				if i > 0 {
+					pcm = make([]byte, 600)
+				}
+				src := newMockDecoder(8000, 1, 16, pcm)
+				src.block = block
+				if _, err := mixer.AddSource(src, 1); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := readAll(mixer, tt.chunk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("mix differs from the source: got %d bytes, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestMixingDecoderKeepsIdleSource(t *testing.T) {
+	mixer, err := NewMixingDecoder(8000, 1, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idle := newMockDecoder(8000, 1, 16, rampPCM(1, 100))
+	idle.stalls = 1
+	if _, err := mixer.AddSource(idle, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mixer.AddSource(newMockDecoder(8000, 1, 16, make([]byte, 400)), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 2*50)
+	if n, err := mixer.DecodeSamples(50, buf); n != 50 || err != nil {
+		t.Fatalf("first DecodeSamples = %d, %v; want 50, nil", n, err)
+	}
+	if got := mixer.Sources(); got != 2 {
+		t.Fatalf("Sources() = %d after an empty read, want 2", got)
+	}
+	if n, err := mixer.DecodeSamples(50, buf); n != 50 || err != nil {
+		t.Fatalf("second DecodeSamples = %d, %v; want 50, nil", n, err)
+	}
+	if !bytes.Equal(buf, rampPCM(1, 100)[:100]) {
+		t.Error("idle source's audio missing after it had data again")
+	}
+}
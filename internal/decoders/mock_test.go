@@ -0,0 +1,121 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// mockDecoder plays a fixed buffer of PCM, returning at most block sample
+// frames per call (all requested if block is 0) and then endErr, or io.EOF
+// if it is nil. The first stalls calls return (0, nil), as a stream with no
+// data ready does.
+type mockDecoder struct {
+	rate, channels, bps int
+
+	pcm    []byte
+	block  int
+	stalls int
+	endErr error
+	pos    int // in bytes
+
+	opens  int
+	closed bool
+}
+
+// newMockDecoder plays pcm in the given format.
+func newMockDecoder(rate, channels, bps int, pcm []byte) *mockDecoder {
+	return &mockDecoder{rate: rate, channels: channels, bps: bps, pcm: pcm}
+}
+
+// newToneDecoder plays samples frames of a 16-bit mono sine at freq Hz and
+// half scale, returning at most block frames per call.
+func newToneDecoder(freq float64, rate, samples, block int) *mockDecoder {
+	d := newMockDecoder(rate, 1, 16, tonePCM(freq, rate, samples))
+	d.block = block
+	return d
+}
+
+// tonePCM returns samples frames of a 16-bit mono sine at freq Hz and half
+// scale.
+func tonePCM(freq float64, rate, samples int) []byte {
+	pcm := make([]byte, 2*samples)
+	for i := range samples {
+		v := 0.5 * math.Sin(2*math.Pi*freq*float64(i)/float64(rate))
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(v*math.MaxInt16)))
+	}
+	return pcm
+}
+
+// rampPCM returns samples frames of 16-bit PCM with channels channels whose
+// n-th sample (counting across channels) has the value n, wrapping at 16
+// bits, so any dropped or repeated sample shows up.
+func rampPCM(channels, samples int) []byte {
+	pcm := make([]byte, 2*channels*samples)
+	for i := range channels * samples {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(i))
+	}
+	return pcm
+}
+
+func (d *mockDecoder) Open(fileName string) error {
+	d.opens++
+	d.pos = 0
+	d.closed = false
+	return nil
+}
+
+func (d *mockDecoder) Close() error {
+	d.closed = true
+	return nil
+}
+
+func (d *mockDecoder) GetFormat() (int, int, int) {
+	return d.rate, d.channels, d.bps
+}
+
+func (d *mockDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.closed {
+		return 0, fmt.Errorf("decoder closed")
+	}
+	if d.stalls > 0 {
+		d.stalls--
+		return 0, nil
+	}
+	frameSize := d.channels * d.bps / 8
+	if d.pos >= len(d.pcm) {
+		if d.endErr != nil {
+			return 0, d.endErr
+		}
+		return 0, io.EOF
+	}
+	n := min(samples, len(audio)/frameSize, (len(d.pcm)-d.pos)/frameSize)
+	if d.block > 0 {
+		n = min(n, d.block)
+	}
+	copy(audio, d.pcm[d.pos:d.pos+n*frameSize])
+	d.pos += n * frameSize
+	return n, nil
+}
+
+// readAll decodes dec to the end in calls of chunk sample frames and
+// returns the PCM.
+func readAll(dec decoder.AudioDecoder, chunk int) ([]byte, error) {
+	_, channels, bps := dec.GetFormat()
+	frameSize := channels * bps / 8
+	buf := make([]byte, chunk*frameSize)
+	var out []byte
+	for {
+		n, err := dec.DecodeSamples(chunk, buf)
+		out = append(out, buf[:n*frameSize]...)
+		if err == io.EOF || (err == nil && n == 0) {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
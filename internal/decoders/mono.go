@@ -0,0 +1,63 @@
+package decoders
+
+import (
+	"fmt"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/audioproc"
+)
+
+// MonoDownmix wraps an AudioDecoder and averages its channels into one, for
+// playback on mono devices such as a single Bluetooth speaker. It reports a
+// single channel from GetFormat, so the output stream is opened as mono.
+type MonoDownmix struct {
+	decoder.AudioDecoder
+
+	channels int
+	bps      int
+	buf      []byte
+}
+
+// NewMonoDownmix wraps dec to produce mono audio.
+func NewMonoDownmix(dec decoder.AudioDecoder) (*MonoDownmix, error) {
+	_, channels, bps := dec.GetFormat()
+	if channels <= 0 {
+		return nil, fmt.Errorf("invalid channel count: %d", channels)
+	}
+	switch bps {
+	case 8, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("unsupported bit depth: %d", bps)
+	}
+	return &MonoDownmix{AudioDecoder: dec, channels: channels, bps: bps}, nil
+}
+
+// GetFormat returns the wrapped decoder's format with one channel.
+func (d *MonoDownmix) GetFormat() (int, int, int) {
+	rate, _, bps := d.AudioDecoder.GetFormat()
+	return rate, 1, bps
+}
+
+// DecodeSamples decodes multi-channel audio and downmixes it into audio.
+func (d *MonoDownmix) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.channels == 1 {
+		return d.AudioDecoder.DecodeSamples(samples, audio)
+	}
+
+	bytesPerSample := d.bps / 8
+	samples = min(samples, len(audio)/bytesPerSample)
+	size := samples * d.channels * bytesPerSample
+	if cap(d.buf) < size {
+		d.buf = make([]byte, size)
+	}
+
+	n, err := d.AudioDecoder.DecodeSamples(samples, d.buf[:size])
+	if n > 0 {
+		mono, mixErr := audioproc.DownmixToMono(d.buf[:n*d.channels*bytesPerSample], d.bps, d.channels)
+		if mixErr != nil {
+			return 0, mixErr
+		}
+		copy(audio, mono)
+	}
+	return n, err
+}
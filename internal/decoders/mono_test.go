@@ -0,0 +1,82 @@
+package decoders
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestMonoDownmix(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels int
+		bps      int
+		in       []byte
+		want     []byte
+	}{
+		{"mono passes through", 1, 16, audiotest.PCM16(1, -2, 3), audiotest.PCM16(1, -2, 3)},
+		{"stereo", 2, 16, audiotest.PCM16(100, 300, -100, -300, 1, 2), audiotest.PCM16(200, -200, 2)},
+		{"opposite channels cancel", 2, 16, audiotest.PCM16(32767, -32767), audiotest.PCM16(0)},
+		{"full scale", 2, 16, audiotest.PCM16(-32768, -32768, 32767, 32767), audiotest.PCM16(-32768, 32767)},
+		{"5.1", 6, 16, audiotest.PCM16(6, 6, 6, 6, 6, 0), audiotest.PCM16(5)},
+		{"8-bit stereo", 2, 8, []byte{0x80, 0xA0, 0x00, 0x40}, []byte{0x90, 0x20}},
+		{"24-bit stereo", 2, 24, []byte{0x00, 0x10, 0x00, 0x00, 0x30, 0x00}, []byte{0x00, 0x20, 0x00}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newMockDecoder(48000, tt.channels, tt.bps, tt.in)
+			src.block = 1
+			dec, err := NewMonoDownmix(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rate, channels, bps := dec.GetFormat(); rate != 48000 || channels != 1 || bps != tt.bps {
+				t.Fatalf("GetFormat = %d, %d, %d, want 48000, 1, %d", rate, channels, bps, tt.bps)
+			}
+			got, err := readAll(dec, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonoDownmixSmallBuffer(t *testing.T) {
+	// The output buffer holds mono frames; the decoder must not read more
+	// stereo frames than it can return.
+	src := newMockDecoder(48000, 2, 16, audiotest.PCM16(2, 4, 6, 8, 10, 12))
+	dec, err := NewMonoDownmix(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	n, err := dec.DecodeSamples(100, buf)
+	if err != nil || n != 2 || !bytes.Equal(buf, audiotest.PCM16(3, 7)) {
+		t.Fatalf("DecodeSamples = %d, %v, % x, want 2 frames of 3, 7", n, err, buf[:2*n])
+	}
+	n, err = dec.DecodeSamples(100, buf)
+	if err != nil || n != 1 || !bytes.Equal(buf[:2], audiotest.PCM16(11)) {
+		t.Fatalf("DecodeSamples = %d, %v, want the last frame", n, err)
+	}
+}
+
+func TestMonoDownmixInvalidFormat(t *testing.T) {
+	tests := []struct {
+		name          string
+		channels, bps int
+	}{
+		{"no channels", 0, 16},
+		{"12-bit", 2, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMonoDownmix(newMockDecoder(48000, tt.channels, tt.bps, nil)); err == nil {
+				t.Fatal("NewMonoDownmix succeeded, want error")
+			}
+		})
+	}
+}
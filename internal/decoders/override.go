@@ -0,0 +1,32 @@
+package decoders
+
+import (
+	"fmt"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// SampleRateOverride wraps an AudioDecoder and reports a different sample
+// rate than the one in the file header. The decoded PCM is passed through
+// unchanged, so audio labelled with the wrong rate plays at the right speed.
+type SampleRateOverride struct {
+	decoder.AudioDecoder
+	sampleRate int
+}
+
+// NewSampleRateOverride wraps dec so that GetFormat reports sampleRate.
+// It must be applied before the decoder is handed to a player, since the
+// output stream is configured from GetFormat.
+func NewSampleRateOverride(dec decoder.AudioDecoder, sampleRate int) (*SampleRateOverride, error) {
+	if sampleRate <= 0 || sampleRate > 384000 {
+		return nil, fmt.Errorf("invalid sample rate: %d (valid range 1-384000)", sampleRate)
+	}
+	return &SampleRateOverride{AudioDecoder: dec, sampleRate: sampleRate}, nil
+}
+
+// GetFormat returns the overridden sample rate with the wrapped decoder's
+// channel count and bit depth.
+func (d *SampleRateOverride) GetFormat() (sampleRate, channels, bitsPerSample int) {
+	_, channels, bitsPerSample = d.AudioDecoder.GetFormat()
+	return d.sampleRate, channels, bitsPerSample
+}
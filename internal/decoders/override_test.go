@@ -0,0 +1,32 @@
+package decoders
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSampleRateOverride(t *testing.T) {
+	pcm := rampPCM(2, 100)
+	dec, err := NewSampleRateOverride(newMockDecoder(44100, 2, 16, pcm), 48000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate, channels, bps := dec.GetFormat(); rate != 48000 || channels != 2 || bps != 16 {
+		t.Fatalf("GetFormat = %d, %d, %d, want 48000, 2, 16", rate, channels, bps)
+	}
+	got, err := readAll(dec, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pcm) {
+		t.Fatal("decoded audio differs from the wrapped decoder's")
+	}
+}
+
+func TestNewSampleRateOverrideInvalidRate(t *testing.T) {
+	for _, rate := range []int{0, -1, 384001} {
+		if _, err := NewSampleRateOverride(newMockDecoder(44100, 2, 16, nil), rate); err == nil {
+			t.Errorf("NewSampleRateOverride(%d) succeeded, want error", rate)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package decoders
+
+import (
+	"io"
+	"sync"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// PausableDecoder wraps a decoder so decoding can be halted without
+// stopping playback: while paused, DecodeSamples blocks, so the player's
+// producer stops pulling from the source (e.g. a metered network stream)
+// and the output keeps playing what is already buffered.
+//
+// Pause, Resume and Close may be called from any goroutine. Close releases
+// a blocked DecodeSamples, which then returns io.EOF, so a paused player can
+// still be shut down. Close waits for a call already decoding to complete
+// before closing the wrapped decoder.
+type PausableDecoder struct {
+	decoder.AudioDecoder
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	paused bool
+	closed bool
+
+	// decodeMu serializes the wrapped decoder's DecodeSamples and Close.
+	decodeMu sync.Mutex
+}
+
+// NewPausableDecoder wraps dec, initially decoding.
+func NewPausableDecoder(dec decoder.AudioDecoder) *PausableDecoder {
+	d := &PausableDecoder{AudioDecoder: dec}
+	d.cond = sync.NewCond(&d.mu)
+	return d
+}
+
+// Pause makes the next DecodeSamples call block until Resume or Close. A
+// call already decoding completes.
+func (d *PausableDecoder) Pause() {
+	d.mu.Lock()
+	d.paused = true
+	d.mu.Unlock()
+}
+
+// Resume lets decoding continue.
+func (d *PausableDecoder) Resume() {
+	d.mu.Lock()
+	d.paused = false
+	d.mu.Unlock()
+	d.cond.Broadcast()
+}
+
+// Paused reports whether decoding is paused.
+func (d *PausableDecoder) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+// DecodeSamples waits while paused, then decodes from the wrapped decoder.
+func (d *PausableDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	d.mu.Lock()
+	for d.paused && !d.closed {
+		d.cond.Wait()
+	}
+	d.mu.Unlock()
+
+	d.decodeMu.Lock()
+	defer d.decodeMu.Unlock()
+
+	// Close may have run while this call waited for decodeMu.
+	d.mu.Lock()
+	closed := d.closed
+	d.mu.Unlock()
+	if closed {
+		return 0, io.EOF
+	}
+	return d.AudioDecoder.DecodeSamples(samples, audio)
+}
+
+// Close releases a blocked DecodeSamples and, once no call is decoding,
+// closes the wrapped decoder.
+func (d *PausableDecoder) Close() error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	d.cond.Broadcast()
+
+	d.decodeMu.Lock()
+	defer d.decodeMu.Unlock()
+	return d.AudioDecoder.Close()
+}
@@ -0,0 +1,110 @@
+package decoders
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPausableDecoderHaltsDecoding(t *testing.T) {
+	dec := NewPausableDecoder(newMockDecoder(8000, 1, 16, rampPCM(1, 1<<20)))
+	defer dec.Close()
+
+	// A producer decoding a block per millisecond, as the player's does while
+	// its ring buffer has room.
+	var decoded atomic.Int64
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 2*64)
+		for {
+			n, err := dec.DecodeSamples(64, buf)
+			if err != nil {
+				done <- err
+				return
+			}
+			decoded.Add(int64(n))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	waitFor(t, func() bool { return decoded.Load() > 0 })
+	dec.Pause()
+	if !dec.Paused() {
+		t.Fatal("Paused() = false after Pause")
+	}
+	// Let a call already decoding complete.
+	time.Sleep(10 * time.Millisecond)
+	halted := decoded.Load()
+	time.Sleep(20 * time.Millisecond)
+	if got := decoded.Load(); got != halted {
+		t.Fatalf("decoded %d samples while paused", got-halted)
+	}
+
+	dec.Resume()
+	waitFor(t, func() bool { return decoded.Load() > halted })
+
+	dec.Pause()
+	time.Sleep(10 * time.Millisecond)
+	dec.Close()
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Fatalf("DecodeSamples after Close = %v, want io.EOF", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close didn't release the paused DecodeSamples")
+	}
+}
+
+// slowDecoder takes a millisecond per DecodeSamples call, widening the
+// window for a concurrent Close.
+type slowDecoder struct {
+	*mockDecoder
+}
+
+func (d slowDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return d.mockDecoder.DecodeSamples(samples, audio)
+}
+
+func TestPausableDecoderCloseWhileDecoding(t *testing.T) {
+	for range 20 {
+		mock := newMockDecoder(8000, 1, 16, rampPCM(1, 1<<20))
+		dec := NewPausableDecoder(slowDecoder{mock})
+
+		done := make(chan error, 1)
+		go func() {
+			buf := make([]byte, 2*64)
+			for {
+				if _, err := dec.DecodeSamples(64, buf); err != nil {
+					done <- err
+					return
+				}
+			}
+		}()
+
+		time.Sleep(2 * time.Millisecond)
+		if err := dec.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// The mock fails a decode after Close; the wrapper must not reach it.
+		if err := <-done; err != io.EOF {
+			t.Fatalf("DecodeSamples racing Close = %v, want io.EOF", err)
+		}
+		if !mock.closed {
+			t.Fatal("wrapped decoder not closed")
+		}
+	}
+}
+
+// waitFor polls cond for up to a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("timed out")
+}
@@ -0,0 +1,123 @@
+package decoders
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// pcmReaderChunkSamples is the number of sample frames decoded per refill.
+const pcmReaderChunkSamples = 4096
+
+// PCMReader adapts an AudioDecoder to io.Reader, yielding the interleaved
+// little-endian PCM bytes produced by DecodeSamples. Reads of any size are
+// supported; decoded bytes that do not fit are kept for the next Read.
+//
+// PCMReader does not close the decoder.
+type PCMReader struct {
+	dec       decoder.AudioDecoder
+	frameSize int
+	buf       []byte
+	pending   []byte
+	err       error
+}
+
+// NewPCMReader returns a reader of the decoder's PCM output.
+func NewPCMReader(dec decoder.AudioDecoder) *PCMReader {
+	_, channels, bps := dec.GetFormat()
+	frameSize := channels * bps / 8
+
+	r := &PCMReader{dec: dec, frameSize: frameSize}
+	if frameSize <= 0 {
+		r.err = fmt.Errorf("invalid decoder format: %d channels, %d bits per sample", channels, bps)
+		return r
+	}
+	r.buf = make([]byte, pcmReaderChunkSamples*frameSize)
+	return r
+}
+
+// Read implements io.Reader. It returns io.EOF once the decoder is exhausted.
+func (r *PCMReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// fill decodes the next chunk into pending. Errors are deferred until the
+// decoded bytes have been returned.
+func (r *PCMReader) fill() {
+	n, err := r.dec.DecodeSamples(pcmReaderChunkSamples, r.buf)
+	r.pending = r.buf[:n*r.frameSize]
+
+	switch {
+	case err != nil && IsEOF(err):
+		r.err = io.EOF
+	case err != nil:
+		r.err = err
+	case n == 0:
+		r.err = io.EOF
+	}
+}
+
+// pcmWriteToChunkSamples is the number of sample frames decoded per write
+// in WriteTo. It is larger than the Read chunk since no caller buffer
+// limits it.
+const pcmWriteToChunkSamples = 65536
+
+// WriteTo implements io.WriterTo, so io.Copy decodes in large chunks and
+// writes them directly to w instead of going through its small copy buffer.
+// Bytes left over from earlier Read calls are written first.
+func (r *PCMReader) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+
+	if len(r.pending) > 0 {
+		n, err := w.Write(r.pending)
+		written += int64(n)
+		r.pending = r.pending[n:]
+		if err != nil {
+			return written, err
+		}
+	}
+
+	if r.err != nil {
+		if r.err == io.EOF {
+			return written, nil
+		}
+		return written, r.err
+	}
+
+	buf := make([]byte, pcmWriteToChunkSamples*r.frameSize)
+	for {
+		n, err := r.dec.DecodeSamples(pcmWriteToChunkSamples, buf)
+		if n > 0 {
+			m, werr := w.Write(buf[:n*r.frameSize])
+			written += int64(m)
+			if werr != nil {
+				return written, werr
+			}
+		}
+
+		switch {
+		case err != nil && IsEOF(err):
+			r.err = io.EOF
+			return written, nil
+		case err != nil:
+			r.err = err
+			return written, err
+		case n == 0:
+			r.err = io.EOF
+			return written, nil
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package decoders
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestPCMReaderRead(t *testing.T) {
+	// More than one decode chunk, so reads cross chunk boundaries.
+	const frames = 2*pcmReaderChunkSamples + 1234
+	pcm := rampPCM(2, frames)
+
+	tests := []struct {
+		name     string
+		block    int // frames per DecodeSamples, 0 for all requested
+		readSize int
+	}{
+		{"one byte", 0, 1},
+		{"part of a frame", 0, 3},
+		{"odd size", 0, 1021},
+		{"one chunk", 0, 4 * pcmReaderChunkSamples},
+		{"larger than a chunk", 0, 3*4*pcmReaderChunkSamples + 5},
+		{"short decodes", 777, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := newMockDecoder(44100, 2, 16, pcm)
+			dec.block = tt.block
+			r := NewPCMReader(dec)
+
+			var got []byte
+			p := make([]byte, tt.readSize)
+			for {
+				n, err := r.Read(p)
+				got = append(got, p[:n]...)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !bytes.Equal(got, pcm) {
+				t.Fatalf("read %d bytes, want %d", len(got), len(pcm))
+			}
+			if n, err := r.Read(p); n != 0 || err != io.EOF {
+				t.Fatalf("Read after end = %d, %v, want 0, io.EOF", n, err)
+			}
+		})
+	}
+}
+
+func TestPCMReaderDefersError(t *testing.T) {
+	pcm := rampPCM(1, 1000)
+	errBad := errors.New("bad frame")
+	dec := newMockDecoder(8000, 1, 16, pcm)
+	dec.endErr = errBad
+
+	got, err := io.ReadAll(NewPCMReader(dec))
+	if !errors.Is(err, errBad) {
+		t.Fatalf("err = %v, want %v", err, errBad)
+	}
+	if !bytes.Equal(got, pcm) {
+		t.Fatalf("read %d bytes before the error, want %d", len(got), len(pcm))
+	}
+}
+
+func TestPCMReaderInvalidFormat(t *testing.T) {
+	r := NewPCMReader(newMockDecoder(8000, 0, 16, nil))
+	if _, err := r.Read(make([]byte, 16)); err == nil || err == io.EOF {
+		t.Fatalf("Read = %v, want a format error", err)
+	}
+}
+
+func TestPCMReaderWriteTo(t *testing.T) {
+	const frames = 3*pcmWriteToChunkSamples + 999
+	pcm := rampPCM(2, frames)
+
+	tests := []struct {
+		name      string
+		block     int
+		readFirst int // bytes taken with Read before WriteTo
+	}{
+		{"whole stream", 0, 0},
+		{"short decodes", 5000, 0},
+		{"after a partial read", 0, 4*pcmReaderChunkSamples - 3},
+		{"after reading it all", 0, len(pcm)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A plain copy, through Read only.
+			plainDec := newMockDecoder(44100, 2, 16, pcm)
+			plainDec.block = tt.block
+			var plain bytes.Buffer
+			if _, err := io.Copy(&plain, struct{ io.Reader }{NewPCMReader(plainDec)}); err != nil {
+				t.Fatal(err)
+			}
+
+			dec := newMockDecoder(44100, 2, 16, pcm)
+			dec.block = tt.block
+			r := NewPCMReader(dec)
+			first := make([]byte, tt.readFirst)
+			if _, err := io.ReadFull(r, first); err != nil {
+				t.Fatal(err)
+			}
+			var rest bytes.Buffer
+			n, err := r.WriteTo(&rest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(rest.Len()) {
+				t.Fatalf("WriteTo = %d, wrote %d bytes", n, rest.Len())
+			}
+
+			got := append(first, rest.Bytes()...)
+			if !bytes.Equal(got, plain.Bytes()) {
+				t.Fatalf("Read and WriteTo gave %d bytes, a plain copy %d", len(got), plain.Len())
+			}
+			if !bytes.Equal(got, pcm) {
+				t.Fatal("output differs from the decoder's PCM")
+			}
+		})
+	}
+}
+
+// failingWriter accepts limit bytes, then fails.
+type failingWriter struct {
+	limit int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errWriteFailed
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestPCMReaderWriteToErrors(t *testing.T) {
+	pcm := rampPCM(2, 2*pcmWriteToChunkSamples)
+	errBad := errors.New("bad frame")
+
+	tests := []struct {
+		name    string
+		endErr  error
+		limit   int
+		want    int64
+		wantErr error
+	}{
+		{"decode error after the data", errBad, len(pcm), int64(len(pcm)), errBad},
+		{"write error", nil, 1000, 1000, errWriteFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := newMockDecoder(44100, 2, 16, pcm)
+			dec.endErr = tt.endErr
+			n, err := NewPCMReader(dec).WriteTo(&failingWriter{limit: tt.limit})
+			if n != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WriteTo = %d, %v, want %d, %v", n, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// BenchmarkPCMReaderCopy compares io.Copy through WriteTo with a copy
+// through Read and io.Copy's own buffer.
+func BenchmarkPCMReaderCopy(b *testing.B) {
+	pcm := rampPCM(2, 44100*10)
+	// Hide io.Discard's ReadFrom, which would pick its own buffer.
+	w := struct{ io.Writer }{io.Discard}
+
+	b.Run("WriteTo", func(b *testing.B) {
+		b.SetBytes(int64(len(pcm)))
+		for b.Loop() {
+			r := NewPCMReader(newMockDecoder(44100, 2, 16, pcm))
+			if _, err := io.Copy(w, r); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Read", func(b *testing.B) {
+		b.SetBytes(int64(len(pcm)))
+		for b.Loop() {
+			r := NewPCMReader(newMockDecoder(44100, 2, 16, pcm))
+			if _, err := io.Copy(w, struct{ io.Reader }{r}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
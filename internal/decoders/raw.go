@@ -0,0 +1,105 @@
+package decoders
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drgolem/audiokit/pkg/types"
+)
+
+// RawDecoder decodes headerless PCM files (.raw, .pcm). The file carries no
+// format information, so it is supplied by the caller. Samples are
+// interleaved little-endian signed integers, except 8-bit samples, which are
+// unsigned as in WAV.
+type RawDecoder struct {
+	format types.FrameFormat
+	file   *os.File
+	r      *bufio.Reader
+}
+
+// NewRawDecoder opens fileName as raw PCM of the given format.
+func NewRawDecoder(fileName string, format types.FrameFormat) (*RawDecoder, error) {
+	switch format.BitsPerSample {
+	case 8, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("unsupported raw PCM bit depth: %d", format.BitsPerSample)
+	}
+	if format.SampleRate <= 0 || format.Channels <= 0 {
+		return nil, fmt.Errorf("invalid raw PCM format: %s", format)
+	}
+
+	d := &RawDecoder{format: format}
+	if err := d.Open(fileName); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Open opens fileName, closing any file already open.
+func (d *RawDecoder) Open(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open raw PCM file: %w", err)
+	}
+	d.Close()
+	d.file = file
+	d.r = bufio.NewReader(file)
+	return nil
+}
+
+// Close closes the file.
+func (d *RawDecoder) Close() error {
+	d.r = nil
+	if d.file != nil {
+		err := d.file.Close()
+		d.file = nil
+		return err
+	}
+	return nil
+}
+
+// GetFormat returns the format given to NewRawDecoder.
+func (d *RawDecoder) GetFormat() (int, int, int) {
+	return d.format.SampleRate, d.format.Channels, d.format.BitsPerSample
+}
+
+// TotalSamples returns the number of whole sample frames in the file.
+func (d *RawDecoder) TotalSamples() (int64, error) {
+	if d.file == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+	info, err := d.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size() / int64(d.format.FrameSize()), nil
+}
+
+// DecodeSamples reads up to samples whole sample frames into audio. A
+// partial frame at the end of the file is dropped. Returns io.EOF once the
+// file is exhausted.
+func (d *RawDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.r == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+
+	frameSize := d.format.FrameSize()
+	samples = min(samples, len(audio)/frameSize)
+	if samples <= 0 {
+		return 0, nil
+	}
+
+	n, err := io.ReadFull(d.r, audio[:samples*frameSize])
+	got := n / frameSize
+	switch {
+	case err == io.ErrUnexpectedEOF:
+		return got, nil
+	case err == io.EOF:
+		return 0, io.EOF
+	case err != nil:
+		return got, err
+	}
+	return got, nil
+}
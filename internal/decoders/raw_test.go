@@ -0,0 +1,95 @@
+package decoders
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/drgolem/audiokit/pkg/types"
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestRawDecoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		format types.FrameFormat
+		file   []byte
+		want   []byte
+	}{
+		{
+			"16-bit stereo",
+			types.FrameFormat{SampleRate: 44100, Channels: 2, BitsPerSample: 16},
+			rampPCM(2, 300),
+			rampPCM(2, 300),
+		},
+		{
+			"trailing partial frame dropped",
+			types.FrameFormat{SampleRate: 44100, Channels: 2, BitsPerSample: 16},
+			append(rampPCM(2, 300), 1, 2, 3),
+			rampPCM(2, 300),
+		},
+		{
+			"8-bit unsigned",
+			types.FrameFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 8},
+			[]byte{0x00, 0x7F, 0x80, 0x81, 0xFF},
+			[]byte{0x00, 0x7F, 0x80, 0x81, 0xFF},
+		},
+		{
+			"24-bit mono",
+			types.FrameFormat{SampleRate: 48000, Channels: 1, BitsPerSample: 24},
+			append(audiotest.PCM24(1, -1, 1<<23-1, -1<<23), 0xAA),
+			audiotest.PCM24(1, -1, 1<<23-1, -1<<23),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := NewRawDecoder(audiotest.WriteFile(t, "test.raw", tt.file), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dec.Close()
+
+			rate, channels, bps := dec.GetFormat()
+			if rate != tt.format.SampleRate || channels != tt.format.Channels || bps != tt.format.BitsPerSample {
+				t.Fatalf("GetFormat = %d, %d, %d, want %s", rate, channels, bps, tt.format)
+			}
+			frames := int64(len(tt.want) / tt.format.FrameSize())
+			if total, err := dec.TotalSamples(); err != nil || total != frames {
+				t.Fatalf("TotalSamples = %d, %v, want %d", total, err, frames)
+			}
+
+			got, err := readAll(dec, 7)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("decoded % x, want % x", got, tt.want)
+			}
+			if n, err := dec.DecodeSamples(7, make([]byte, 7*tt.format.FrameSize())); n != 0 || err != io.EOF {
+				t.Fatalf("DecodeSamples after the end = %d, %v, want 0, io.EOF", n, err)
+			}
+		})
+	}
+}
+
+func TestNewRawDecoderErrors(t *testing.T) {
+	fileName := audiotest.WriteFile(t, "test.raw", rampPCM(1, 10))
+	tests := []struct {
+		name     string
+		fileName string
+		format   types.FrameFormat
+	}{
+		{"12-bit", fileName, types.FrameFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 12}},
+		{"no channels", fileName, types.FrameFormat{SampleRate: 8000, Channels: 0, BitsPerSample: 16}},
+		{"no rate", fileName, types.FrameFormat{SampleRate: 0, Channels: 1, BitsPerSample: 16}},
+		{"missing file", fileName + ".missing", types.FrameFormat{SampleRate: 8000, Channels: 1, BitsPerSample: 16}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if dec, err := NewRawDecoder(tt.fileName, tt.format); err == nil {
+				dec.Close()
+				t.Fatal("NewRawDecoder succeeded, want error")
+			}
+		})
+	}
+}
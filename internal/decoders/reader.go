@@ -0,0 +1,68 @@
+package decoders
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/musictools/internal/decoders/wav"
+)
+
+// OpenReader decodes audio read from r, such as a pipe, an HTTP body or a
+// zip entry, with ext (".wav", ".flac", ...) selecting the codec. WAV is
+// decoded straight from r, forward only if r can't seek. The other codecs
+// only open files, so r is first copied to a temporary file, which is
+// removed on Close. The returned decoder doesn't close r.
+func OpenReader(r io.Reader, ext string) (decoder.AudioDecoder, error) {
+	ext = strings.ToLower(ext)
+	if ext == ".wav" {
+		dec := wav.NewDecoder()
+		if err := dec.OpenReader(r); err != nil {
+			return nil, err
+		}
+		return dec, nil
+	}
+
+	if !NewRegistry().Supports(ext) {
+		return nil, fmt.Errorf("unsupported format: %q", ext)
+	}
+
+	tmp, err := os.CreateTemp("", "musictools-*"+ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to buffer input: %w", err)
+	}
+
+	dec, err := NewDecoder(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return &tempFileDecoder{AudioDecoder: dec, path: tmp.Name()}, nil
+}
+
+// tempFileDecoder is a decoder reading a temporary file, which it removes
+// on Close.
+type tempFileDecoder struct {
+	decoder.AudioDecoder
+
+	path string
+}
+
+// Close closes the wrapped decoder and removes its file.
+func (d *tempFileDecoder) Close() error {
+	err := d.AudioDecoder.Close()
+	if rmErr := os.Remove(d.path); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package decoders
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// Reset rewinds dec to the first sample so the same file can be played
+// again. Seekable decoders (FLAC, MP3, Vorbis, WAV) seek back to the start;
+// others are closed and reopened from fileName, which must be the file dec
+// was opened with.
+//
+// Reset must be called on the codec decoder itself, not on a wrapper such
+// as ErrorTracker, which hides the Seek method.
+func Reset(dec decoder.AudioDecoder, fileName string) error {
+	if s, ok := dec.(decoder.Seekable); ok {
+		if _, err := s.Seek(0, io.SeekStart); err == nil {
+			return nil
+		}
+		// Fall through to reopening: some decoders cannot seek once they
+		// have hit end of stream.
+	}
+
+	if err := dec.Close(); err != nil {
+		return fmt.Errorf("failed to close decoder for reset: %w", err)
+	}
+	if err := dec.Open(fileName); err != nil {
+		return fmt.Errorf("failed to reopen %s: %w", fileName, err)
+	}
+	return nil
+}
+
+// LoopDecoder plays a file over and over, rewinding its decoder with Reset
+// at the end of each pass instead of opening it again.
+type LoopDecoder struct {
+	decoder.AudioDecoder
+
+	fileName string
+	passes   int
+}
+
+// NewLoopDecoder loops dec, which must be the codec decoder opened on
+// fileName (see Reset).
+func NewLoopDecoder(dec decoder.AudioDecoder, fileName string) *LoopDecoder {
+	return &LoopDecoder{AudioDecoder: dec, fileName: fileName}
+}
+
+// Passes returns the number of completed passes through the file.
+func (d *LoopDecoder) Passes() int {
+	return d.passes
+}
+
+// DecodeSamples decodes from the file, rewinding it when it ends. A decode
+// error ends the loop, as does a file with no samples.
+func (d *LoopDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	n, err := d.AudioDecoder.DecodeSamples(samples, audio)
+	if n > 0 && err == io.EOF {
+		// Rewind on the next call.
+		return n, nil
+	}
+	if n > 0 || (err != nil && err != io.EOF) {
+		return n, err
+	}
+
+	if err := Reset(d.AudioDecoder, d.fileName); err != nil {
+		return 0, err
+	}
+	d.passes++
+	n, err = d.AudioDecoder.DecodeSamples(samples, audio)
+	if n == 0 && err == nil {
+		return 0, io.EOF
+	}
+	return n, err
+}
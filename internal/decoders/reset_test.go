@@ -0,0 +1,54 @@
+package decoders
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLoopDecoder(t *testing.T) {
+	pcm := rampPCM(1, 100)
+
+	tests := []struct {
+		name  string
+		block int
+		chunk int
+	}{
+		{"chunks span the rewind", 0, 64},
+		{"short reads", 9, 32},
+		{"whole passes", 0, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newMockDecoder(8000, 1, 16, pcm)
+			src.block = tt.block
+			src.Open("loop.raw")
+			loop := NewLoopDecoder(src, "loop.raw")
+
+			var got []byte
+			buf := make([]byte, 2*tt.chunk)
+			for len(got) < 3*len(pcm) {
+				n, err := loop.DecodeSamples(tt.chunk, buf)
+				if err != nil || n == 0 {
+					t.Fatalf("DecodeSamples = %d, %v after %d bytes", n, err, len(got))
+				}
+				got = append(got, buf[:2*n]...)
+			}
+
+			want := bytes.Repeat(pcm, 3)
+			if !bytes.Equal(got[:len(want)], want) {
+				t.Fatal("looped output is not the file repeated")
+			}
+			if loop.Passes() < 2 {
+				t.Fatalf("Passes() = %d, want at least 2", loop.Passes())
+			}
+		})
+	}
+}
+
+func TestLoopDecoderEmptyFileEnds(t *testing.T) {
+	src := newMockDecoder(8000, 1, 16, nil)
+	loop := NewLoopDecoder(src, "empty.raw")
+	if n, err := loop.DecodeSamples(16, make([]byte, 32)); n != 0 || err == nil {
+		t.Fatalf("DecodeSamples = %d, %v, want an error ending the loop", n, err)
+	}
+}
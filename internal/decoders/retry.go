@@ -0,0 +1,50 @@
+package decoders
+
+import (
+	"io"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// Backoff between retries of an empty read in RetryDecoder.
+const (
+	retryMinBackoff = time.Millisecond
+	retryMaxBackoff = 50 * time.Millisecond
+)
+
+// RetryDecoder wraps a decoder that can return (0, nil) while no data is
+// ready, such as one fed from a network stream. The player's producer and
+// the helpers in this package take an empty read as the end of the stream;
+// RetryDecoder instead retries with a growing backoff, and only reports
+// io.EOF once the wrapped decoder has produced nothing for the idle timeout.
+// Errors, including io.EOF, from the wrapped decoder are passed through.
+type RetryDecoder struct {
+	decoder.AudioDecoder
+
+	idleTimeout time.Duration
+}
+
+// NewRetryDecoder wraps dec to wait up to idleTimeout for data on each
+// call.
+func NewRetryDecoder(dec decoder.AudioDecoder, idleTimeout time.Duration) *RetryDecoder {
+	return &RetryDecoder{AudioDecoder: dec, idleTimeout: idleTimeout}
+}
+
+// DecodeSamples decodes from the wrapped decoder, retrying empty reads.
+func (d *RetryDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	deadline := time.Now().Add(d.idleTimeout)
+	backoff := retryMinBackoff
+
+	for {
+		n, err := d.AudioDecoder.DecodeSamples(samples, audio)
+		if n > 0 || err != nil {
+			return n, err
+		}
+		if !time.Now().Before(deadline) {
+			return 0, io.EOF
+		}
+		time.Sleep(min(backoff, time.Until(deadline)))
+		backoff = min(backoff*2, retryMaxBackoff)
+	}
+}
@@ -0,0 +1,58 @@
+package decoders
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRetryDecoderWaitsOutEmptyReads(t *testing.T) {
+	pcm := rampPCM(2, 100)
+	mock := newMockDecoder(44100, 2, 16, pcm)
+	mock.stalls = 5
+	mock.block = 30
+	dec := NewRetryDecoder(mock, time.Second)
+
+	// Without the retries, readAll would stop at the first empty read.
+	got, err := readAll(dec, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pcm) {
+		t.Fatalf("decoded %d bytes, want %d", len(got), len(pcm))
+	}
+	if mock.stalls != 0 {
+		t.Errorf("%d empty reads left, want all retried", mock.stalls)
+	}
+}
+
+func TestRetryDecoderIdleTimeout(t *testing.T) {
+	mock := newMockDecoder(44100, 2, 16, rampPCM(2, 100))
+	mock.stalls = 1 << 30
+	const idle = 30 * time.Millisecond
+	dec := NewRetryDecoder(mock, idle)
+
+	start := time.Now()
+	n, err := dec.DecodeSamples(64, make([]byte, 4*64))
+	elapsed := time.Since(start)
+	if n != 0 || err != io.EOF {
+		t.Fatalf("DecodeSamples = %d, %v, want 0, io.EOF", n, err)
+	}
+	if elapsed < idle || elapsed > idle+time.Second {
+		t.Errorf("gave up after %v, want about %v", elapsed, idle)
+	}
+}
+
+func TestRetryDecoderPassesErrors(t *testing.T) {
+	errBroken := errors.New("broken stream")
+	mock := newMockDecoder(44100, 2, 16, nil)
+	mock.stalls = 2
+	mock.endErr = errBroken
+	dec := NewRetryDecoder(mock, time.Second)
+
+	if n, err := dec.DecodeSamples(64, make([]byte, 4*64)); n != 0 || err != errBroken {
+		t.Fatalf("DecodeSamples = %d, %v, want 0, %v", n, err, errBroken)
+	}
+}
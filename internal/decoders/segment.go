@@ -0,0 +1,90 @@
+package decoders
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// SegmentDecoder wraps an AudioDecoder to play only the part between two
+// offsets, e.g. one track of an album stored as a single file.
+type SegmentDecoder struct {
+	decoder.AudioDecoder
+
+	remaining int64 // sample frames left in the segment, -1 for no end
+}
+
+// NewSegmentDecoder positions dec at start and returns a decoder that ends
+// at end. An end of 0 plays to the end of the stream. Offsets are rounded to
+// the nearest sample frame. Seekable decoders seek to start; others decode
+// and discard the audio before it.
+func NewSegmentDecoder(dec decoder.AudioDecoder, start, end time.Duration) (*SegmentDecoder, error) {
+	if start < 0 || end < 0 || (end > 0 && end <= start) {
+		return nil, fmt.Errorf("invalid segment %v - %v", start, end)
+	}
+	rate, channels, bps := dec.GetFormat()
+	frameSize := channels * bps / 8
+	if frameSize <= 0 {
+		return nil, fmt.Errorf("invalid decoder format: %d channels, %d bits per sample", channels, bps)
+	}
+
+	startSamples := durationToSamples(start, rate)
+	if err := skipSamples(dec, startSamples, frameSize); err != nil {
+		return nil, err
+	}
+
+	remaining := int64(-1)
+	if end > 0 {
+		remaining = durationToSamples(end, rate) - startSamples
+	}
+	return &SegmentDecoder{AudioDecoder: dec, remaining: remaining}, nil
+}
+
+// DecodeSamples decodes from the wrapped decoder, returning io.EOF once the
+// end of the segment is reached.
+func (d *SegmentDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.remaining == 0 {
+		return 0, io.EOF
+	}
+	if d.remaining > 0 {
+		samples = int(min(int64(samples), d.remaining))
+	}
+	n, err := d.AudioDecoder.DecodeSamples(samples, audio)
+	if d.remaining > 0 {
+		d.remaining -= int64(n)
+	}
+	return n, err
+}
+
+// skipSamples moves dec forward by n sample frames from its current
+// position.
+func skipSamples(dec decoder.AudioDecoder, n int64, frameSize int) error {
+	if n == 0 {
+		return nil
+	}
+	if s, ok := dec.(decoder.Seekable); ok {
+		if _, err := s.Seek(n, io.SeekCurrent); err != nil {
+			return fmt.Errorf("seek to sample %d failed: %w", n, err)
+		}
+		return nil
+	}
+
+	const chunk = 4096
+	buf := make([]byte, chunk*frameSize)
+	for skipped := int64(0); skipped < n; {
+		got, err := dec.DecodeSamples(int(min(chunk, n-skipped)), buf)
+		skipped += int64(got)
+		if skipped < n && (err != nil || got == 0) {
+			return fmt.Errorf("stream ended at sample %d before segment start %d", skipped, n)
+		}
+	}
+	return nil
+}
+
+// durationToSamples converts d to sample frames at rate, rounded to the
+// nearest frame.
+func durationToSamples(d time.Duration, rate int) int64 {
+	return (int64(d)*int64(rate) + int64(time.Second)/2) / int64(time.Second)
+}
@@ -0,0 +1,98 @@
+package decoders
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// seekableMock adds decoder.Seekable to mockDecoder.
+type seekableMock struct {
+	*mockDecoder
+}
+
+func (d seekableMock) Seek(offset int64, whence int) (int64, error) {
+	frameSize := int64(d.channels * d.bps / 8)
+	total := int64(len(d.pcm)) / frameSize
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(d.pos) / frameSize
+	case io.SeekEnd:
+		offset += total
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	offset = max(0, min(offset, total))
+	d.pos = int(offset * frameSize)
+	return offset, nil
+}
+
+func TestSegmentDecoder(t *testing.T) {
+	// At 1 kHz a sample frame is a millisecond.
+	const frames = 1000
+	pcm := rampPCM(1, frames)
+	ms := time.Millisecond
+
+	tests := []struct {
+		name       string
+		start, end time.Duration
+		from, to   int // sample frames played, -1 for an error
+	}{
+		{"whole stream", 0, 0, 0, frames},
+		{"middle", 100 * ms, 300 * ms, 100, 300},
+		{"to the end", 250 * ms, 0, 250, frames},
+		{"end past the stream", 900 * ms, 2 * time.Second, 900, frames},
+		{"offsets rounded down", 1400 * time.Microsecond, 10400 * time.Microsecond, 1, 10},
+		{"offsets rounded up", 1500 * time.Microsecond, 10500 * time.Microsecond, 2, 11},
+		{"negative start", -ms, 0, -1, -1},
+		{"end before start", 300 * ms, 200 * ms, -1, -1},
+		{"empty segment", 300 * ms, 300 * ms, -1, -1},
+	}
+	for _, tt := range tests {
+		for _, seekable := range []bool{false, true} {
+			name := tt.name
+			if seekable {
+				name += ", seekable"
+			}
+			t.Run(name, func(t *testing.T) {
+				mock := newMockDecoder(1000, 1, 16, pcm)
+				mock.block = 64
+				var src decoder.AudioDecoder = mock
+				if seekable {
+					src = seekableMock{mock}
+				}
+
+				seg, err := NewSegmentDecoder(src, tt.start, tt.end)
+				if tt.from < 0 {
+					if err == nil {
+						t.Fatal("NewSegmentDecoder succeeded, want error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := readAll(seg, 100)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := pcm[2*tt.from : 2*tt.to]; !bytes.Equal(got, want) {
+					t.Fatalf("played %d frames, want frames %d-%d", len(got)/2, tt.from, tt.to)
+				}
+			})
+		}
+	}
+}
+
+func TestSegmentDecoderStartPastEnd(t *testing.T) {
+	dec := newMockDecoder(1000, 1, 16, rampPCM(1, 100))
+	if _, err := NewSegmentDecoder(dec, 200*time.Millisecond, 0); err == nil {
+		t.Fatal("NewSegmentDecoder past the end of a stream succeeded, want error")
+	}
+}
@@ -0,0 +1,151 @@
+package decoders
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// SequenceDecoder plays a series of decoders back to back as one stream, so
+// the player sees no end of stream (and keeps its output stream open)
+// between them. This is what makes gapless album playback possible.
+//
+// The sequence has the format of its first decoder. When the current decoder
+// ends, the sequence moves on to the next queued decoder (see Queue and
+// QueueFile) or, with the queue empty, pulls one from a next function, which
+// should have it opened ahead of time so the switch doesn't wait on file I/O.
+// The sequence ends when there is none, or when the next decoder has a
+// different format; that decoder is not played and is left to the caller
+// via Pending.
+//
+// A decode error ends the current decoder like end of stream does, so one
+// damaged file does not stop the rest; wrap the decoders in an ErrorTracker
+// to find out about it.
+type SequenceDecoder struct {
+	next func() decoder.AudioDecoder
+
+	mu       sync.Mutex
+	cur      decoder.AudioDecoder
+	queue    []decoder.AudioDecoder
+	pending  decoder.AudioDecoder
+	rate     int
+	channels int
+	bps      int
+}
+
+// NewSequenceDecoder returns a sequence starting with first. next is called
+// from the decoding goroutine whenever the current decoder is exhausted and
+// may be nil for a sequence of one.
+func NewSequenceDecoder(first decoder.AudioDecoder, next func() decoder.AudioDecoder) *SequenceDecoder {
+	rate, channels, bps := first.GetFormat()
+	return &SequenceDecoder{
+		next:     next,
+		cur:      first,
+		rate:     rate,
+		channels: channels,
+		bps:      bps,
+	}
+}
+
+// Queue appends dec to play after the current decoder and any decoders
+// queued before it, ahead of those from the next function. It is safe to
+// call while the sequence is playing; the sequence takes ownership of dec.
+func (s *SequenceDecoder) Queue(dec decoder.AudioDecoder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, dec)
+}
+
+// QueueFile opens fileName and queues it. The file is opened, and its
+// decoder initialized, by the caller before the current decoder ends, so the
+// switch does not wait on file I/O.
+func (s *SequenceDecoder) QueueFile(fileName string) error {
+	dec, err := NewDecoder(fileName)
+	if err != nil {
+		return err
+	}
+	s.Queue(dec)
+	return nil
+}
+
+// Open is not supported; the sequence is built from already opened decoders.
+func (s *SequenceDecoder) Open(fileName string) error {
+	return errors.New("sequence decoder cannot open files")
+}
+
+// GetFormat returns the format shared by every decoder in the sequence.
+func (s *SequenceDecoder) GetFormat() (int, int, int) {
+	return s.rate, s.channels, s.bps
+}
+
+// DecodeSamples decodes from the current decoder, moving on to the next one
+// when it is exhausted. Returns io.EOF once the sequence has ended.
+func (s *SequenceDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.cur != nil {
+		n, err := s.cur.DecodeSamples(samples, audio)
+		if err != nil || n == 0 {
+			s.advance()
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+	return 0, io.EOF
+}
+
+// advance closes the current decoder and takes the next queued or pulled one.
+func (s *SequenceDecoder) advance() {
+	s.cur.Close()
+	s.cur = nil
+
+	var dec decoder.AudioDecoder
+	switch {
+	case len(s.queue) > 0:
+		dec = s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+	case s.next != nil:
+		dec = s.next()
+	}
+	if dec == nil {
+		return
+	}
+
+	rate, channels, bps := dec.GetFormat()
+	if rate != s.rate || channels != s.channels || bps != s.bps {
+		s.pending = dec
+		return
+	}
+	s.cur = dec
+}
+
+// Pending returns the decoder that ended the sequence because its format
+// differs, or nil. The caller owns it and must close it.
+func (s *SequenceDecoder) Pending() decoder.AudioDecoder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// Close closes the current and all queued decoders. A pending decoder is
+// left open.
+func (s *SequenceDecoder) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	if s.cur != nil {
+		errs = append(errs, s.cur.Close())
+		s.cur = nil
+	}
+	for _, dec := range s.queue {
+		errs = append(errs, dec.Close())
+	}
+	s.queue = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,152 @@
+package decoders
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+func TestSequenceDecoder(t *testing.T) {
+	pcm := rampPCM(2, 1000)
+	// part returns a stereo 16-bit mock playing frames [from, to) of pcm.
+	part := func(from, to, block int) *mockDecoder {
+		d := newMockDecoder(44100, 2, 16, pcm[4*from:4*to])
+		d.block = block
+		d.Open("")
+		return d
+	}
+
+	tests := []struct {
+		name      string
+		decoders  []*mockDecoder
+		want      []byte
+		wantPend  int // index of the decoder left pending, -1 for none
+		chunkSize int
+	}{
+		{
+			name:      "one",
+			decoders:  []*mockDecoder{part(0, 1000, 0)},
+			want:      pcm,
+			wantPend:  -1,
+			chunkSize: 256,
+		},
+		{
+			name:      "second follows the first immediately",
+			decoders:  []*mockDecoder{part(0, 333, 0), part(333, 1000, 0)},
+			want:      pcm,
+			wantPend:  -1,
+			chunkSize: 256,
+		},
+		{
+			name:      "short blocks",
+			decoders:  []*mockDecoder{part(0, 100, 7), part(100, 101, 0), part(101, 1000, 33)},
+			want:      pcm,
+			wantPend:  -1,
+			chunkSize: 64,
+		},
+		{
+			name: "format change ends the sequence",
+			decoders: []*mockDecoder{
+				part(0, 500, 0),
+				newMockDecoder(48000, 2, 16, pcm[2000:]),
+				part(500, 1000, 0),
+			},
+			want:      pcm[:2000],
+			wantPend:  1,
+			chunkSize: 256,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest := tt.decoders[1:]
+			next := func() decoder.AudioDecoder {
+				if len(rest) == 0 {
+					return nil
+				}
+				d := rest[0]
+				rest = rest[1:]
+				return d
+			}
+			seq := NewSequenceDecoder(tt.decoders[0], next)
+
+			got, err := readAll(seq, tt.chunkSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("decoded %d bytes, want %d matching the concatenated decoders", len(got), len(tt.want))
+			}
+
+			pending := seq.Pending()
+			switch {
+			case tt.wantPend < 0 && pending != nil:
+				t.Fatalf("Pending() = %v, want nil", pending)
+			case tt.wantPend >= 0 && pending != tt.decoders[tt.wantPend]:
+				t.Fatalf("Pending() is not decoder %d", tt.wantPend)
+			}
+			for i, d := range tt.decoders {
+				if i == tt.wantPend {
+					if d.closed {
+						t.Errorf("pending decoder %d was closed", i)
+					}
+					continue
+				}
+				if i < tt.wantPend || tt.wantPend < 0 {
+					if !d.closed {
+						t.Errorf("decoder %d was not closed", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceDecoderQueue(t *testing.T) {
+	pcm := rampPCM(2, 1000)
+	first := newMockDecoder(44100, 2, 16, pcm[:4*400])
+	queued := newMockDecoder(44100, 2, 16, pcm[4*400:4*700])
+	pulled := newMockDecoder(44100, 2, 16, pcm[4*700:])
+	first.block = 64
+
+	// The queued decoder plays ahead of those from the next function.
+	pulls := 0
+	seq := NewSequenceDecoder(first, func() decoder.AudioDecoder {
+		if pulls++; pulls > 1 {
+			return nil
+		}
+		return pulled
+	})
+
+	// Queue while the first decoder is playing.
+	buf := make([]byte, 4*100)
+	n, err := seq.DecodeSamples(100, buf)
+	if err != nil || n != 64 {
+		t.Fatalf("DecodeSamples = %d, %v, want 64, nil", n, err)
+	}
+	seq.Queue(queued)
+
+	rest, err := readAll(seq, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := append(buf[:4*n], rest...); !bytes.Equal(got, pcm) {
+		t.Fatalf("decoded %d bytes, want %d with the queued decoder right after the first", len(got), len(pcm))
+	}
+	if !first.closed || !queued.closed || !pulled.closed {
+		t.Errorf("closed = %v, %v, %v, want all closed", first.closed, queued.closed, pulled.closed)
+	}
+}
+
+func TestSequenceDecoderCloseClosesQueue(t *testing.T) {
+	first := newMockDecoder(44100, 2, 16, rampPCM(2, 10))
+	queued := newMockDecoder(44100, 2, 16, rampPCM(2, 10))
+	seq := NewSequenceDecoder(first, nil)
+	seq.Queue(queued)
+	if err := seq.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !first.closed || !queued.closed {
+		t.Errorf("closed = %v, %v, want both closed", first.closed, queued.closed)
+	}
+}
@@ -0,0 +1,48 @@
+package decoders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// SilencePrefix wraps an AudioDecoder and emits a stretch of digital
+// silence before the first decoded sample. Played at stream start it gives
+// output devices that click or glitch on their first buffers time to settle.
+type SilencePrefix struct {
+	decoder.AudioDecoder
+
+	remaining int // sample frames of silence left to emit
+}
+
+// NewSilencePrefix wraps dec to start with the given duration of silence,
+// rounded to whole sample frames at the decoder's sample rate.
+func NewSilencePrefix(dec decoder.AudioDecoder, d time.Duration) (*SilencePrefix, error) {
+	if d < 0 {
+		return nil, fmt.Errorf("silence duration must not be negative: %v", d)
+	}
+	rate, channels, bps := dec.GetFormat()
+	if channels*bps/8 <= 0 {
+		return nil, fmt.Errorf("invalid decoder format: %d channels, %d bits per sample", channels, bps)
+	}
+	return &SilencePrefix{
+		AudioDecoder: dec,
+		remaining:    int(int64(d) * int64(rate) / int64(time.Second)),
+	}, nil
+}
+
+// DecodeSamples emits the remaining silence, then decodes from the wrapped
+// decoder.
+func (d *SilencePrefix) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.remaining == 0 {
+		return d.AudioDecoder.DecodeSamples(samples, audio)
+	}
+
+	_, channels, bps := d.GetFormat()
+	frameSize := channels * bps / 8
+	n := min(samples, d.remaining, len(audio)/frameSize)
+	fillSilence(audio[:n*frameSize], bps)
+	d.remaining -= n
+	return n, nil
+}
@@ -0,0 +1,46 @@
+package decoders
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSilencePrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		bps     int
+		silence byte
+	}{
+		{"16-bit", 16, 0},
+		{"8-bit unsigned", 8, 0x80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frameSize := 2 * tt.bps / 8
+			audio := bytes.Repeat([]byte{0x11}, 100*frameSize)
+			// 10ms at 8 kHz is 80 sample frames.
+			dec, err := NewSilencePrefix(newMockDecoder(8000, 2, tt.bps, audio), 10*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := readAll(dec, 30)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := append(bytes.Repeat([]byte{tt.silence}, 80*frameSize), audio...)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("decoded %d bytes, want 80 frames of silence then the %d audio bytes", len(got), len(audio))
+			}
+		})
+	}
+}
+
+func TestNewSilencePrefixErrors(t *testing.T) {
+	if _, err := NewSilencePrefix(newMockDecoder(8000, 2, 16, nil), -time.Millisecond); err == nil {
+		t.Error("NewSilencePrefix accepted a negative duration")
+	}
+	if _, err := NewSilencePrefix(newMockDecoder(8000, 0, 16, nil), time.Millisecond); err == nil {
+		t.Error("NewSilencePrefix accepted a decoder with no channels")
+	}
+}
@@ -0,0 +1,22 @@
+package decoders
+
+import "github.com/drgolem/audiokit/pkg/decoder"
+
+// MetadataReader is implemented by decoders that read the tags of the file
+// they decode: WAV from its LIST/INFO chunk, FLAC from its Vorbis comments
+// and MP3 from its ID3v2 tag.
+type MetadataReader interface {
+	// Metadata returns the file's tags keyed by the metadata package's
+	// lowercase tag keys, or an empty map if the file has none.
+	Metadata() (map[string]string, error)
+}
+
+// Metadata returns the tags of the file dec is decoding, or an empty map if
+// dec doesn't implement MetadataReader. Wrappers don't pass the tags
+// through, so ask the decoder the file was opened with.
+func Metadata(dec decoder.AudioDecoder) (map[string]string, error) {
+	if m, ok := dec.(MetadataReader); ok {
+		return m.Metadata()
+	}
+	return map[string]string{}, nil
+}
@@ -0,0 +1,47 @@
+package decoders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/decoder"
+)
+
+// ThrottleDecoder wraps a decoder to produce at most a given number of
+// sample frames per second, for sinks that consume in real time but give no
+// backpressure of their own. Each call blocks until the samples decoded so
+// far are due, so the average rate over any window stays at the limit; a
+// caller that falls behind gets samples without waiting until it catches up.
+type ThrottleDecoder struct {
+	decoder.AudioDecoder
+
+	rate    float64
+	start   time.Time
+	decoded int64
+}
+
+// NewThrottleDecoder wraps dec to decode at most samplesPerSec sample frames
+// per second.
+func NewThrottleDecoder(dec decoder.AudioDecoder, samplesPerSec float64) (*ThrottleDecoder, error) {
+	if samplesPerSec <= 0 {
+		return nil, fmt.Errorf("invalid decode rate: %g samples/sec", samplesPerSec)
+	}
+	return &ThrottleDecoder{AudioDecoder: dec, rate: samplesPerSec}, nil
+}
+
+// DecodeSamples decodes from the wrapped decoder, then waits until the
+// decoded samples are due at the configured rate.
+func (d *ThrottleDecoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.start.IsZero() {
+		d.start = time.Now()
+	}
+
+	n, err := d.AudioDecoder.DecodeSamples(samples, audio)
+	d.decoded += int64(n)
+
+	due := d.start.Add(time.Duration(float64(d.decoded) / d.rate * float64(time.Second)))
+	if wait := time.Until(due); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
@@ -0,0 +1,63 @@
+package decoders
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleDecoderRate(t *testing.T) {
+	const rate = 20000
+	dec, err := NewThrottleDecoder(newMockDecoder(rate, 1, 16, rampPCM(1, 1<<16)), rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 4000 samples are due 200ms after the first call.
+	start := time.Now()
+	buf := make([]byte, 2*100)
+	decoded := 0
+	for decoded < 4000 {
+		n, err := dec.DecodeSamples(100, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded += n
+	}
+	elapsed := time.Since(start)
+	got := float64(decoded) / elapsed.Seconds()
+	if got > rate*1.02 || got < rate*0.7 {
+		t.Errorf("decoded %d samples in %v: %.0f samples/sec, want about %d", decoded, elapsed, got, rate)
+	}
+}
+
+func TestThrottleDecoderCatchesUp(t *testing.T) {
+	const rate = 20000
+	dec, err := NewThrottleDecoder(newMockDecoder(rate, 1, 16, rampPCM(1, 1<<16)), rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2*1000)
+	if _, err := dec.DecodeSamples(100, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// A caller 100ms behind is owed 2000 samples and gets them unthrottled.
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	for range 2 {
+		if _, err := dec.DecodeSamples(900, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("catching up took %v, want no wait", elapsed)
+	}
+}
+
+func TestNewThrottleDecoderInvalidRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		if _, err := NewThrottleDecoder(newMockDecoder(8000, 1, 16, nil), rate); err == nil {
+			t.Errorf("NewThrottleDecoder(%g) succeeded, want error", rate)
+		}
+	}
+}
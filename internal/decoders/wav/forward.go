@@ -0,0 +1,46 @@
+package wav
+
+import (
+	"errors"
+	"io"
+)
+
+// errForwardOnly is returned by forwardSeeker for seeks it can't do.
+var errForwardOnly = errors.New("stream can only seek forward")
+
+// forwardSeeker adapts a reader that can't seek to io.ReadSeeker, so the
+// header parser can run on it. It tracks the read position and seeks forward
+// by discarding input; seeking backward or from the end fails with
+// errForwardOnly.
+type forwardSeeker struct {
+	r   io.Reader
+	pos int64
+}
+
+func (f *forwardSeeker) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *forwardSeeker) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = f.pos + offset
+	default:
+		return f.pos, errForwardOnly
+	}
+	if target < f.pos {
+		return f.pos, errForwardOnly
+	}
+
+	n, err := io.CopyN(io.Discard, f.r, target-f.pos)
+	f.pos += n
+	if err != nil {
+		return f.pos, err
+	}
+	return f.pos, nil
+}
@@ -0,0 +1,76 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+	"github.com/drgolem/musictools/internal/decoders/codecerr"
+)
+
+// buildWAV returns a PCM WAV file holding data, with dataSize written as
+// the data chunk's size.
+func buildWAV(rate, channels, bps int, dataSize uint32, data []byte) []byte {
+	b := audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, channels, rate, bps)))
+	binary.LittleEndian.PutUint32(b[4:], uint32(36+len(data)))
+	b = binary.LittleEndian.AppendUint32(append(b, "data"...), dataSize)
+	return append(b, data...)
+}
+
+func TestOpenReaderStreams(t *testing.T) {
+	data := make([]byte, 4*500)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	tests := []struct {
+		name      string
+		dataSize  uint32
+		seekable  bool
+		wantTotal int64
+		wantErr   error
+	}{
+		{"sized, seekable", uint32(len(data)), true, 500, nil},
+		{"sized, forward only", uint32(len(data)), false, 500, nil},
+		{"unsized, seekable", 0, true, 500, nil},
+		{"unsized, forward only", math.MaxUint32, false, 0, codecerr.ErrUnknownLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r io.Reader = bytes.NewReader(buildWAV(8000, 2, 16, tt.dataSize, data))
+			if !tt.seekable {
+				r = struct{ io.Reader }{r}
+			}
+			d := NewDecoder()
+			if err := d.OpenReader(r); err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			total, err := d.TotalSamples()
+			if !errors.Is(err, tt.wantErr) || total != tt.wantTotal {
+				t.Fatalf("TotalSamples = %d, %v, want %d, %v", total, err, tt.wantTotal, tt.wantErr)
+			}
+
+			var got []byte
+			buf := make([]byte, 4*128)
+			for {
+				n, err := d.DecodeSamples(128, buf)
+				got = append(got, buf[:4*n]...)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("decoded %d bytes, want the %d bytes of the data chunk", len(got), len(data))
+			}
+		})
+	}
+}
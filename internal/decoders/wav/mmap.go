@@ -0,0 +1,57 @@
+package wav
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// NewMmapDecoder creates a WAV decoder that maps the whole file into memory
+// on Open, so DecodeSamples and Seek are served from memory without a system
+// call per read, and Range gives random access to the audio. On platforms
+// without mmap the file is read into memory instead.
+func NewMmapDecoder() *Decoder {
+	return &Decoder{mmap: true}
+}
+
+// openMapped maps fileName and parses its header from the mapping.
+func (d *Decoder) openMapped(fileName string) error {
+	data, unmap, err := mapFile(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to map WAV file: %w", err)
+	}
+
+	if err := d.init(bytes.NewReader(data)); err != nil {
+		unmap()
+		return err
+	}
+	d.mapped = data
+	d.unmap = unmap
+	return nil
+}
+
+// Range returns count sample frames starting at sample frame start, in the
+// output format, without moving the decode position. The range is clamped
+// to the end of the audio. For PCM files the returned slice aliases the
+// mapping and is only valid until Close; float files are converted into a
+// new slice. Only decoders from NewMmapDecoder support Range.
+func (d *Decoder) Range(start, count int64) ([]byte, error) {
+	if d.mapped == nil {
+		return nil, fmt.Errorf("range reads need a decoder from NewMmapDecoder")
+	}
+	// The header may promise more data than the file holds.
+	total := min(d.dataSize, int64(len(d.mapped))-d.dataStart) / int64(d.blockAlign)
+	if start < 0 || count < 0 || start > total {
+		return nil, fmt.Errorf("range %d+%d outside audio of %d samples", start, count, total)
+	}
+	count = min(count, total-start)
+
+	from := d.dataStart + start*int64(d.blockAlign)
+	raw := d.mapped[from : from+count*int64(d.blockAlign)]
+	if !d.float {
+		return raw, nil
+	}
+
+	out := make([]byte, count*int64(d.channels*d.bps/8))
+	d.convertFloat(raw, out)
+	return out, nil
+}
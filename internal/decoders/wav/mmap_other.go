@@ -0,0 +1,15 @@
+//go:build !unix
+
+package wav
+
+import "os"
+
+// mapFile reads fileName into memory, standing in for mmap on platforms
+// without it.
+func mapFile(fileName string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
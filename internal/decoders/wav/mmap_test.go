@@ -0,0 +1,134 @@
+//go:build unix
+
+package wav
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+// writeTestWAV writes a 16-bit stereo WAV of frames sample frames to a
+// temporary file and returns its name and data chunk.
+func writeTestWAV(t *testing.T, frames int) (string, []byte) {
+	t.Helper()
+	data := make([]byte, 4*frames)
+	for i := range data {
+		data[i] = byte(i*31 + i>>8)
+	}
+	file := audiotest.WAVFile(
+		audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 2, 44100, 16)),
+		audiotest.RIFFChunk("data", data),
+	)
+	return audiotest.WriteFile(t, "test.wav", file), data
+}
+
+func TestMmapRangeMatchesDecode(t *testing.T) {
+	const frames = 50000
+	fileName, _ := writeTestWAV(t, frames)
+
+	// The reference: a sequential decode with the file-backed decoder.
+	ref := NewDecoder()
+	if err := ref.Open(fileName); err != nil {
+		t.Fatal(err)
+	}
+	var want []byte
+	buf := make([]byte, 4*4096)
+	for {
+		n, err := ref.DecodeSamples(4096, buf)
+		want = append(want, buf[:4*n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ref.Close()
+
+	d := NewMmapDecoder()
+	if err := d.Open(fileName); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		start := rng.Int64N(frames)
+		count := rng.Int64N(8192)
+		got, err := d.Range(start, count)
+		if err != nil {
+			t.Fatalf("Range(%d, %d): %v", start, count, err)
+		}
+		end := min(start+count, frames)
+		if !bytes.Equal(got, want[4*start:4*end]) {
+			t.Fatalf("Range(%d, %d) differs from the sequential decode", start, count)
+		}
+	}
+
+	// Range doesn't move the decode position.
+	if pos := d.TellCurrentSample(); pos != 0 {
+		t.Fatalf("position after Range = %d, want 0", pos)
+	}
+	n, err := d.DecodeSamples(4096, buf)
+	if err != nil || !bytes.Equal(buf[:4*n], want[:4*n]) {
+		t.Fatalf("DecodeSamples after Range = %d, %v, or the wrong samples", n, err)
+	}
+}
+
+func TestMmapRangeBounds(t *testing.T) {
+	const frames = 1000
+	fileName, data := writeTestWAV(t, frames)
+
+	d := NewMmapDecoder()
+	if err := d.Open(fileName); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	tests := []struct {
+		name         string
+		start, count int64
+		want         []byte // nil for an error
+	}{
+		{"whole file", 0, frames, data},
+		{"clamped at the end", 990, 100, data[4*990:]},
+		{"empty at the end", frames, 10, []byte{}},
+		{"empty count", 10, 0, []byte{}},
+		{"negative start", -1, 10, nil},
+		{"negative count", 0, -1, nil},
+		{"past the end", frames + 1, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.Range(tt.start, tt.count)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("Range(%d, %d) succeeded, want error", tt.start, tt.count)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("Range(%d, %d) returned %d bytes, want %d", tt.start, tt.count, len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestRangeNeedsMmapDecoder(t *testing.T) {
+	fileName, _ := writeTestWAV(t, 100)
+	d := NewDecoder()
+	if err := d.Open(fileName); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if _, err := d.Range(0, 10); err == nil {
+		t.Fatal("Range on a file-backed decoder succeeded, want error")
+	}
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package wav
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps fileName read-only into memory.
+func mapFile(fileName string) ([]byte, func() error, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The mapping stays valid after the file is closed.
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+	if int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("file too large to map: %d bytes", size)
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
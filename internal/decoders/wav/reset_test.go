@@ -0,0 +1,67 @@
+package wav_test
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/decoders"
+	"github.com/drgolem/musictools/internal/decoders/wav"
+)
+
+func TestResetRedecodesFirstSamples(t *testing.T) {
+	// sine.wav is 400 samples of 16-bit mono.
+	fileName := filepath.Join("..", "..", "selftest", "fixtures", "sine.wav")
+
+	tests := []struct {
+		name string
+		skip int // sample frames decoded before the reset
+	}{
+		{"at start", 0},
+		{"mid-stream", 150},
+		{"at end of stream", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := wav.NewDecoder()
+			if err := dec.Open(fileName); err != nil {
+				t.Fatal(err)
+			}
+			defer dec.Close()
+
+			first := make([]byte, 2*64)
+			if n, err := dec.DecodeSamples(64, first); n != 64 || err != nil {
+				t.Fatalf("DecodeSamples = %d, %v", n, err)
+			}
+			if err := decoders.Reset(dec, fileName); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.skip > 0 {
+				if n, err := dec.DecodeSamples(tt.skip, make([]byte, 2*tt.skip)); n != tt.skip || err != nil {
+					t.Fatalf("DecodeSamples = %d, %v", n, err)
+				}
+				if tt.skip == 400 {
+					if _, err := dec.DecodeSamples(1, make([]byte, 2)); err != io.EOF {
+						t.Fatalf("DecodeSamples at end = %v, want io.EOF", err)
+					}
+				}
+			}
+			if err := decoders.Reset(dec, fileName); err != nil {
+				t.Fatal(err)
+			}
+			if pos := dec.TellCurrentSample(); pos != 0 {
+				t.Fatalf("position after Reset = %d, want 0", pos)
+			}
+
+			again := make([]byte, 2*64)
+			if n, err := dec.DecodeSamples(64, again); n != 64 || err != nil {
+				t.Fatalf("DecodeSamples after Reset = %d, %v", n, err)
+			}
+			if !bytes.Equal(again, first) {
+				t.Fatal("samples after Reset differ from the first samples")
+			}
+		})
+	}
+}
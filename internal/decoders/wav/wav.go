@@ -0,0 +1,391 @@
+// Package wav decodes RIFF/WAVE files, including the IEEE float and
+// WAVE_FORMAT_EXTENSIBLE variants that the go-wav based decoder rejects.
+//
+// Float audio is converted to 32-bit signed integer PCM on decode, since the
+// players only output integer formats; GetFormat reports the integer format.
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/drgolem/musictools/internal/decoders/codecerr"
+	"github.com/drgolem/musictools/internal/metadata"
+)
+
+// WAVE format tags.
+const (
+	formatPCM        = 0x0001
+	formatIEEEFloat  = 0x0003
+	formatExtensible = 0xFFFE
+)
+
+// Decoder decodes PCM and IEEE float WAV files.
+// Pure Go implementation — no CGo required.
+// Supports 8, 16, 24 and 32-bit PCM and 32/64-bit float (output as 32-bit PCM).
+// Implements decoder.AudioDecoder and decoder.Seekable.
+type Decoder struct {
+	file *os.File
+	src  io.ReadSeeker
+
+	rate     int
+	channels int
+	bps      int // output bits per sample
+
+	float      bool
+	srcBPS     int   // bits per sample in the file
+	blockAlign int   // bytes per sample frame in the file
+	dataStart  int64 // offset of the first data byte
+	dataSize   int64 // data chunk size in bytes
+	pos        int64 // bytes consumed from the data chunk
+	unsized    bool  // the data runs to the end of a forward-only stream
+
+	buf []byte
+
+	// Set for decoders from NewMmapDecoder: the mapped file and the function
+	// releasing it.
+	mmap   bool
+	mapped []byte
+	unmap  func() error
+}
+
+// NewDecoder creates a new WAV decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+// Open opens a WAV file and parses its header.
+func (d *Decoder) Open(fileName string) error {
+	if d.mmap {
+		return d.openMapped(fileName)
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open WAV file: %w", err)
+	}
+
+	if err := d.init(file); err != nil {
+		file.Close()
+		return err
+	}
+	d.file = file
+	return nil
+}
+
+// OpenReader decodes a WAV stream read from r, such as a pipe or an HTTP
+// body. A reader that can't seek is decoded forward only: Seek can then only
+// move forward, and a data chunk of unknown size runs until r ends. Close
+// doesn't close r.
+func (d *Decoder) OpenReader(r io.Reader) error {
+	src, ok := r.(io.ReadSeeker)
+	if !ok {
+		src = &forwardSeeker{r: r}
+	}
+	d.Close()
+	return d.init(src)
+}
+
+// init parses the RIFF header of src and positions it at the audio data.
+func (d *Decoder) init(src io.ReadSeeker) error {
+	var riff [12]byte
+	if _, err := io.ReadFull(src, riff[:]); err != nil {
+		return fmt.Errorf("failed to read RIFF header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return errors.New("not a RIFF/WAVE file")
+	}
+
+	haveFormat := false
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(src, header[:]); err != nil {
+			return fmt.Errorf("no data chunk found: %w", err)
+		}
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+
+		switch id {
+		case "fmt ":
+			if err := d.parseFormat(src, size); err != nil {
+				return err
+			}
+			haveFormat = true
+			continue
+
+		case "data":
+			if !haveFormat {
+				return errors.New("data chunk before fmt chunk")
+			}
+			start, err := src.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return fmt.Errorf("failed to locate data chunk: %w", err)
+			}
+			// Streaming writers leave the size at 0 or 0xFFFFFFFF; the data
+			// then runs to the end of the file.
+			if size == 0 || size == math.MaxUint32 {
+				end, err := src.Seek(0, io.SeekEnd)
+				if errors.Is(err, errForwardOnly) {
+					d.src = src
+					d.dataStart = start
+					d.dataSize = math.MaxInt64 - start
+					d.dataSize -= d.dataSize % int64(d.blockAlign)
+					d.unsized = true
+					d.pos = 0
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("failed to size data chunk: %w", err)
+				}
+				size = end - start
+				if _, err := src.Seek(start, io.SeekStart); err != nil {
+					return fmt.Errorf("failed to seek to data chunk: %w", err)
+				}
+			}
+			d.src = src
+			d.dataStart = start
+			d.dataSize = size - size%int64(d.blockAlign)
+			d.pos = 0
+			return nil
+		}
+
+		// Skip other chunks, which are padded to an even size.
+		if _, err := src.Seek(size+size%2, io.SeekCurrent); err != nil {
+			return fmt.Errorf("failed to skip %q chunk: %w", id, err)
+		}
+	}
+}
+
+// parseFormat reads a fmt chunk body of the given size.
+func (d *Decoder) parseFormat(r io.Reader, size int64) error {
+	if size < 16 || size > 1024 {
+		return fmt.Errorf("invalid fmt chunk size: %d", size)
+	}
+	body := make([]byte, size+size%2)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return fmt.Errorf("failed to read WAV format: %w", err)
+	}
+
+	format := binary.LittleEndian.Uint16(body[0:2])
+	channels := int(binary.LittleEndian.Uint16(body[2:4]))
+	rate := int(binary.LittleEndian.Uint32(body[4:8]))
+	blockAlign := int(binary.LittleEndian.Uint16(body[12:14]))
+	bps := int(binary.LittleEndian.Uint16(body[14:16]))
+
+	if format == formatExtensible {
+		if size < 40 {
+			return fmt.Errorf("truncated WAVE_FORMAT_EXTENSIBLE fmt chunk (%d bytes)", size)
+		}
+		// The first two bytes of the SubFormat GUID hold the format tag.
+		format = binary.LittleEndian.Uint16(body[24:26])
+	}
+
+	switch format {
+	case formatPCM:
+		switch bps {
+		case 8, 16, 24, 32:
+		default:
+			return fmt.Errorf("unsupported PCM bit depth: %d", bps)
+		}
+		d.float = false
+		d.bps = bps
+	case formatIEEEFloat:
+		switch bps {
+		case 32, 64:
+		default:
+			return fmt.Errorf("unsupported float bit depth: %d", bps)
+		}
+		d.float = true
+		d.bps = 32
+	default:
+		return fmt.Errorf("unsupported WAV format: %d (only PCM and IEEE float supported)", format)
+	}
+
+	if channels <= 0 || rate <= 0 {
+		return fmt.Errorf("invalid WAV format: %d channels, %d Hz", channels, rate)
+	}
+	if blockAlign != channels*bps/8 {
+		return fmt.Errorf("invalid WAV block align %d for %d channels of %d bits", blockAlign, channels, bps)
+	}
+
+	d.rate = rate
+	d.channels = channels
+	d.srcBPS = bps
+	d.blockAlign = blockAlign
+	return nil
+}
+
+// Close closes the underlying file or releases its mapping.
+func (d *Decoder) Close() error {
+	d.src = nil
+	d.unsized = false
+	if d.unmap != nil {
+		err := d.unmap()
+		d.mapped, d.unmap = nil, nil
+		return err
+	}
+	if d.file != nil {
+		err := d.file.Close()
+		d.file = nil
+		return err
+	}
+	return nil
+}
+
+// GetFormat returns the output format. Float files report 32-bit samples.
+func (d *Decoder) GetFormat() (sampleRate, channels, bitsPerSample int) {
+	return d.rate, d.channels, d.bps
+}
+
+// DecodeSamples decodes up to `samples` audio sample frames into the provided buffer,
+// which must hold samples * channels * (bitsPerSample/8) bytes.
+// Returns io.EOF once the data chunk is exhausted.
+func (d *Decoder) DecodeSamples(samples int, audio []byte) (int, error) {
+	if d.src == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+
+	outFrameSize := d.channels * d.bps / 8
+	frames := min(int64(samples), (d.dataSize-d.pos)/int64(d.blockAlign), int64(len(audio)/outFrameSize))
+	if frames <= 0 {
+		if d.pos >= d.dataSize {
+			return 0, io.EOF
+		}
+		return 0, nil
+	}
+
+	// Float samples are read aside and converted into audio; 64-bit float
+	// frames are larger than the output frames.
+	rawLen := frames * int64(d.blockAlign)
+	var raw []byte
+	if d.float {
+		if int64(cap(d.buf)) < rawLen {
+			d.buf = make([]byte, rawLen)
+		}
+		raw = d.buf[:rawLen]
+	} else {
+		raw = audio[:rawLen]
+	}
+
+	n, err := io.ReadFull(d.src, raw)
+	d.pos += int64(n)
+	got := n / d.blockAlign
+
+	if d.float {
+		d.convertFloat(raw[:got*d.blockAlign], audio)
+	}
+
+	if err != nil && d.unsized && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+		// The end of a stream of unknown length.
+		d.dataSize = d.pos - d.pos%int64(d.blockAlign)
+		if got == 0 {
+			return 0, io.EOF
+		}
+		return got, nil
+	}
+	if err != nil {
+		// The header promised more data than the file holds.
+		d.dataSize = d.pos
+		return got, fmt.Errorf("WAV data chunk truncated at byte %d", d.dataStart+d.pos)
+	}
+	return got, nil
+}
+
+// Seek moves to a sample frame offset relative to whence (io.SeekStart,
+// io.SeekCurrent or io.SeekEnd), computing the byte position in the data
+// chunk directly. The target is clamped to the data chunk. Returns the new
+// position in sample frames.
+func (d *Decoder) Seek(offset int64, whence int) (int64, error) {
+	if d.src == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+
+	total := d.dataSize / int64(d.blockAlign)
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = d.pos/int64(d.blockAlign) + offset
+	case io.SeekEnd:
+		target = total + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	target = max(0, min(target, total))
+
+	pos := target * int64(d.blockAlign)
+	if _, err := d.src.Seek(d.dataStart+pos, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek to sample %d: %w", target, err)
+	}
+	d.pos = pos
+	return target, nil
+}
+
+// TotalSamples returns the number of sample frames in the data chunk.
+func (d *Decoder) TotalSamples() (int64, error) {
+	if d.src == nil {
+		return 0, fmt.Errorf("decoder not initialized")
+	}
+	if d.unsized {
+		return 0, fmt.Errorf("WAV data runs to the end of the stream: %w", codecerr.ErrUnknownLength)
+	}
+	return d.dataSize / int64(d.blockAlign), nil
+}
+
+// Metadata returns the tags of the file's LIST/INFO chunk. Decoders opened
+// with OpenReader can't go back to read them and return an empty map.
+func (d *Decoder) Metadata() (map[string]string, error) {
+	switch {
+	case d.mapped != nil:
+		return metadata.ReadWAVInfo(bytes.NewReader(d.mapped))
+	case d.file != nil:
+		return metadata.ReadWAVInfo(io.NewSectionReader(d.file, 0, math.MaxInt64))
+	case d.src != nil:
+		return map[string]string{}, nil
+	}
+	return nil, fmt.Errorf("decoder not initialized")
+}
+
+// TellCurrentSample returns the current position in sample frames.
+func (d *Decoder) TellCurrentSample() int64 {
+	if d.blockAlign == 0 {
+		return 0
+	}
+	return d.pos / int64(d.blockAlign)
+}
+
+// convertFloat converts float samples in raw to 32-bit signed PCM in audio,
+// clamping to [-1, 1].
+func (d *Decoder) convertFloat(raw []byte, audio []byte) {
+	bytesPerSample := d.srcBPS / 8
+	for i := 0; i*bytesPerSample < len(raw); i++ {
+		var v float64
+		if bytesPerSample == 8 {
+			v = math.Float64frombits(binary.LittleEndian.Uint64(raw[i*8:]))
+		} else {
+			v = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:])))
+		}
+		binary.LittleEndian.PutUint32(audio[i*4:], uint32(floatToInt32(v)))
+	}
+}
+
+// floatToInt32 scales a [-1, 1] float sample to the int32 range.
+func floatToInt32(v float64) int32 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	v = math.Round(v * (1 << 31))
+	if v >= math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if v <= math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(v)
+}
@@ -0,0 +1,359 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+	"github.com/drgolem/musictools/internal/metadata"
+)
+
+// extensibleBody returns a WAVE_FORMAT_EXTENSIBLE fmt chunk body for the
+// format tag sub.
+func extensibleBody(sub uint16, channels, rate, bps int) []byte {
+	le := binary.LittleEndian
+	b := audiotest.WAVFormat(formatExtensible, channels, rate, bps)
+	b = le.AppendUint16(b, 22)          // extension size
+	b = le.AppendUint16(b, uint16(bps)) // valid bits
+	b = le.AppendUint32(b, 0)           // channel mask
+	b = le.AppendUint16(b, sub)         // SubFormat GUID
+	return append(b, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71)
+}
+
+func float32Bytes(vs ...float32) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(v))
+	}
+	return b
+}
+
+func float64Bytes(vs ...float64) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	return b
+}
+
+// openWAV opens a decoder on a temporary file holding data.
+func openWAV(t *testing.T, data []byte) (*Decoder, error) {
+	t.Helper()
+	d := NewDecoder()
+	if err := d.Open(audiotest.WriteFile(t, "test.wav", data)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func TestDecodeFormats(t *testing.T) {
+	pcm := []byte{0x01, 0x80, 0xFF, 0x7F, 0x00, 0x10, 0x20, 0x30, 0x40, 0x50, 0x60, 0x70}
+
+	tests := []struct {
+		name     string
+		fmtBody  []byte
+		data     []byte
+		channels int
+		bps      int
+		want     []byte
+	}{
+		{"8-bit mono", audiotest.WAVFormat(formatPCM, 1, 8000, 8), pcm, 1, 8, pcm},
+		{"16-bit stereo", audiotest.WAVFormat(formatPCM, 2, 44100, 16), pcm, 2, 16, pcm},
+		{"24-bit stereo", audiotest.WAVFormat(formatPCM, 2, 48000, 24), pcm, 2, 24, pcm},
+		{"32-bit mono", audiotest.WAVFormat(formatPCM, 1, 96000, 32), pcm, 1, 32, pcm},
+		{"extensible 24-bit", extensibleBody(formatPCM, 2, 48000, 24), pcm, 2, 24, pcm},
+		{
+			"32-bit float",
+			audiotest.WAVFormat(formatIEEEFloat, 1, 44100, 32),
+			float32Bytes(0, 0.5, -0.5, -1, 1.5, -2),
+			1, 32,
+			audiotest.PCM32(0, 1<<30, -1<<30, math.MinInt32, math.MaxInt32, math.MinInt32),
+		},
+		{
+			"64-bit float",
+			audiotest.WAVFormat(formatIEEEFloat, 2, 44100, 64),
+			float64Bytes(0.25, -0.25, 1, math.NaN()),
+			2, 32,
+			audiotest.PCM32(1<<29, -1<<29, math.MaxInt32, 0),
+		},
+		{
+			"extensible float",
+			extensibleBody(formatIEEEFloat, 2, 44100, 32),
+			float32Bytes(0.5, -0.5),
+			2, 32,
+			audiotest.PCM32(1<<30, -1<<30),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := audiotest.WAVFile(audiotest.RIFFChunk("fmt ", tt.fmtBody), audiotest.RIFFChunk("data", tt.data))
+			d, err := openWAV(t, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			rate, channels, bps := d.GetFormat()
+			wantRate := int(binary.LittleEndian.Uint32(tt.fmtBody[4:8]))
+			if rate != wantRate || channels != tt.channels || bps != tt.bps {
+				t.Fatalf("GetFormat = %d, %d, %d, want %d, %d, %d", rate, channels, bps, wantRate, tt.channels, tt.bps)
+			}
+
+			frameSize := channels * bps / 8
+			wantFrames := len(tt.want) / frameSize
+			if total, err := d.TotalSamples(); err != nil || total != int64(wantFrames) {
+				t.Fatalf("TotalSamples = %d, %v, want %d", total, err, wantFrames)
+			}
+
+			// Decode a frame at a time, so every call converts.
+			var got []byte
+			buf := make([]byte, frameSize)
+			for {
+				n, err := d.DecodeSamples(1, buf)
+				got = append(got, buf[:n*frameSize]...)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("decoded % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	pcm16 := audiotest.WAVFormat(formatPCM, 2, 44100, 16)
+	badAlign := audiotest.WAVFormat(formatPCM, 2, 44100, 16)
+	badAlign[12] = 3
+
+	tests := []struct {
+		name string
+		file []byte
+	}{
+		{"empty", nil},
+		{"not RIFF", append([]byte("RIFX\x00\x00\x00\x00WAVE"), audiotest.RIFFChunk("fmt ", pcm16)...)},
+		{"not WAVE", audiotest.RIFFChunk("RIFF", []byte("AVI "))},
+		{"no data chunk", audiotest.WAVFile(audiotest.RIFFChunk("fmt ", pcm16))},
+		{"data before fmt", audiotest.WAVFile(audiotest.RIFFChunk("data", []byte{0, 0}), audiotest.RIFFChunk("fmt ", pcm16))},
+		{"short fmt chunk", audiotest.WAVFile(audiotest.RIFFChunk("fmt ", pcm16[:14]), audiotest.RIFFChunk("data", nil))},
+		{"truncated extensible", audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatExtensible, 2, 44100, 16)), audiotest.RIFFChunk("data", nil))},
+		{"unsupported format", audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(0x0055, 2, 44100, 16)), audiotest.RIFFChunk("data", nil))},
+		{"unsupported PCM depth", audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 2, 44100, 12)), audiotest.RIFFChunk("data", nil))},
+		{"unsupported float depth", audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatIEEEFloat, 2, 44100, 16)), audiotest.RIFFChunk("data", nil))},
+		{"no channels", audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 0, 44100, 16)), audiotest.RIFFChunk("data", nil))},
+		{"bad block align", audiotest.WAVFile(audiotest.RIFFChunk("fmt ", badAlign), audiotest.RIFFChunk("data", nil))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d, err := openWAV(t, tt.file); err == nil {
+				d.Close()
+				t.Fatal("Open succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestSkipsOtherChunks(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	file := audiotest.WAVFile(
+		audiotest.RIFFChunk("JUNK", []byte{0xAA, 0xBB, 0xCC}), // odd size, padded
+		audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 2, 44100, 16)),
+		audiotest.RIFFChunk("LIST", []byte("INFO")),
+		audiotest.RIFFChunk("data", data),
+	)
+	d, err := openWAV(t, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	buf := make([]byte, 64)
+	n, err := d.DecodeSamples(16, buf)
+	if err != nil || !bytes.Equal(buf[:4*n], data) {
+		t.Fatalf("DecodeSamples = % x, %v, want % x", buf[:4*n], err, data)
+	}
+}
+
+func TestTruncatedDataChunk(t *testing.T) {
+	data := make([]byte, 400)
+	file := audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 2, 44100, 16)), audiotest.RIFFChunk("data", data))
+	// Claim twice the data the file holds.
+	dataSize := len(file) - len(data) - 4
+	binary.LittleEndian.PutUint32(file[dataSize:], uint32(2*len(data)))
+
+	d, err := openWAV(t, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	n, err := d.DecodeSamples(1000, make([]byte, 4000))
+	if n != 100 || err == nil || err == io.EOF {
+		t.Fatalf("DecodeSamples = %d, %v, want 100 samples and a truncation error", n, err)
+	}
+	if n, err := d.DecodeSamples(1000, make([]byte, 4000)); n != 0 || err != io.EOF {
+		t.Fatalf("DecodeSamples after the error = %d, %v, want 0, io.EOF", n, err)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	const frames = 100
+	data := make([]byte, 4*frames)
+	for i := range frames {
+		binary.LittleEndian.PutUint32(data[4*i:], uint32(i))
+	}
+	file := audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 2, 44100, 16)), audiotest.RIFFChunk("data", data))
+
+	tests := []struct {
+		name    string
+		offset  int64
+		whence  int
+		want    int64
+		wantErr bool
+	}{
+		{"start", 10, io.SeekStart, 10, false},
+		{"current", 5, io.SeekCurrent, 25, false},
+		{"current backward", -15, io.SeekCurrent, 5, false},
+		{"end", -1, io.SeekEnd, 99, false},
+		{"before start", -10, io.SeekStart, 0, false},
+		{"past end", 10, io.SeekEnd, 100, false},
+		{"invalid whence", 0, 3, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := openWAV(t, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			if _, err := d.DecodeSamples(20, make([]byte, 80)); err != nil {
+				t.Fatal(err)
+			}
+
+			pos, err := d.Seek(tt.offset, tt.whence)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Seek succeeded, want error")
+				}
+				return
+			}
+			if err != nil || pos != tt.want || d.TellCurrentSample() != tt.want {
+				t.Fatalf("Seek = %d, %v, position %d, want %d", pos, err, d.TellCurrentSample(), tt.want)
+			}
+
+			buf := make([]byte, 4)
+			n, err := d.DecodeSamples(1, buf)
+			if tt.want == frames {
+				if n != 0 || err != io.EOF {
+					t.Fatalf("DecodeSamples at end = %d, %v, want 0, io.EOF", n, err)
+				}
+				return
+			}
+			if n != 1 || binary.LittleEndian.Uint32(buf) != uint32(tt.want) {
+				t.Fatalf("decoded sample %d after Seek, want %d", binary.LittleEndian.Uint32(buf), tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	info := []byte("INFO")
+	info = append(info, audiotest.RIFFChunk("INAM", []byte("Title\x00"))...)
+	info = append(info, audiotest.RIFFChunk("IART", []byte("Artist\x00"))...)
+	file := audiotest.WAVFile(
+		audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 1, 8000, 16)),
+		audiotest.RIFFChunk("LIST", info),
+		audiotest.RIFFChunk("data", make([]byte, 20)),
+	)
+	fileName := audiotest.WriteFile(t, "tags.wav", file)
+
+	for _, tt := range []struct {
+		name string
+		dec  *Decoder
+	}{
+		{"file", NewDecoder()},
+		{"mapped", NewMmapDecoder()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dec.Open(fileName); err != nil {
+				t.Fatal(err)
+			}
+			defer tt.dec.Close()
+			tags, err := tt.dec.Metadata()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tags[metadata.Title] != "Title" || tags[metadata.Artist] != "Artist" {
+				t.Fatalf("Metadata = %v", tags)
+			}
+		})
+	}
+
+	// A stream read forward can't go back for its tags.
+	d := NewDecoder()
+	if err := d.OpenReader(struct{ io.Reader }{bytes.NewReader(file)}); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := d.Metadata(); err != nil || len(tags) != 0 {
+		t.Fatalf("Metadata of a stream = %v, %v, want empty", tags, err)
+	}
+}
+
+func FuzzOpen(f *testing.F) {
+	pcm := []byte{0x01, 0x80, 0xFF, 0x7F, 0x00, 0x10, 0x20, 0x30}
+	for _, file := range [][]byte{
+		audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 2, 44100, 16)), audiotest.RIFFChunk("data", pcm)),
+		audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 1, 8000, 8)), audiotest.RIFFChunk("data", pcm[:7])),
+		audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 2, 48000, 24)), audiotest.RIFFChunk("data", pcm)),
+		audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatIEEEFloat, 1, 44100, 32)), audiotest.RIFFChunk("data", float32Bytes(0.5, -2))),
+		audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatIEEEFloat, 1, 44100, 64)), audiotest.RIFFChunk("data", float64Bytes(0.25))),
+		audiotest.WAVFile(audiotest.RIFFChunk("fmt ", extensibleBody(formatPCM, 2, 48000, 24)), audiotest.RIFFChunk("data", pcm)),
+		audiotest.WAVFile(
+			audiotest.RIFFChunk("LIST", []byte("INFOINAM\x03\x00\x00\x00ab\x00")),
+			audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 1, 8000, 16)),
+			audiotest.RIFFChunk("data", pcm),
+		),
+		// A data chunk claiming more than the file holds.
+		audiotest.WAVFile(audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(formatPCM, 1, 8000, 16)), []byte("data\xff\xff\xff\x7f\x01\x02")),
+		audiotest.WAVFile(audiotest.RIFFChunk("fmt ", []byte{1, 0})),
+		[]byte("RIFF\x04\x00\x00\x00WAVE"),
+	} {
+		f.Add(file)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := NewDecoder()
+		if err := d.OpenReader(bytes.NewReader(data)); err != nil {
+			return
+		}
+		defer d.Close()
+
+		_, channels, bps := d.GetFormat()
+		frameSize := channels * bps / 8
+		if channels <= 0 || frameSize <= 0 {
+			t.Fatalf("opened with format of %d channels at %d bits", channels, bps)
+		}
+		buf := make([]byte, 16*frameSize)
+		decoded := 0
+		for range len(data) + 1 {
+			n, err := d.DecodeSamples(16, buf)
+			if n < 0 || n > 16 {
+				t.Fatalf("DecodeSamples returned %d samples for 16 requested", n)
+			}
+			decoded += n * frameSize
+			if err != nil || n == 0 {
+				break
+			}
+		}
+		if decoded > 2*len(data) {
+			t.Fatalf("decoded %d bytes from a %d-byte file", decoded, len(data))
+		}
+	})
+}
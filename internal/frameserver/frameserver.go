@@ -0,0 +1,218 @@
+// Package frameserver streams decoded audio between machines over TCP.
+//
+// The server decodes a file and writes its audio as a sequence of marshaled
+// audioframe.AudioFrame values; the client reads them back and presents them
+// as a stream.AudioPacketProvider, so a remote player can play them through
+// a stream.StreamDecoder like any local file.
+package frameserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/audioframe"
+	"github.com/drgolem/audiokit/pkg/decoder"
+	"github.com/drgolem/audiokit/pkg/decoder/stream"
+	"github.com/drgolem/musictools/internal/decoders"
+)
+
+// frameHeaderSize is the size of the header AudioFrame.Marshal writes before
+// the audio.
+const frameHeaderSize = 12
+
+// maxFrameAudio bounds the audio length a client accepts in a frame header:
+// 65535 samples of 10 channels at 64 bits, the most an AudioFrame can hold.
+const maxFrameAudio = 65535 * 10 * 8
+
+// Serve accepts connections on ln and streams dec to them as marshaled
+// AudioFrames of up to samplesPerFrame samples. Clients are served one at a
+// time: when a client disconnects, the next one picks up where it left off.
+// Serve returns nil once dec is exhausted, closing the last client's
+// connection, or the first accept or decode error.
+func Serve(ln net.Listener, dec decoder.AudioDecoder, samplesPerFrame int) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		slog.Info("Client connected", "remote", conn.RemoteAddr())
+
+		done, err := serveConn(conn, dec, samplesPerFrame)
+		conn.Close()
+		if err != nil {
+			return err
+		}
+		if done {
+			slog.Info("Stream finished", "remote", conn.RemoteAddr())
+			return nil
+		}
+		slog.Info("Client disconnected", "remote", conn.RemoteAddr())
+	}
+}
+
+// serveConn writes frames from dec to conn until dec is exhausted (done) or
+// the client goes away. A frame that fails to write is lost.
+func serveConn(conn net.Conn, dec decoder.AudioDecoder, samplesPerFrame int) (done bool, err error) {
+	w := bufio.NewWriter(conn)
+	for {
+		frame, err := decoders.DecodeFrame(dec, samplesPerFrame)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return true, w.Flush()
+			}
+			return false, err
+		}
+
+		if _, err := w.Write(frame.Marshal()); err != nil {
+			return false, nil
+		}
+		if err := w.Flush(); err != nil {
+			return false, nil
+		}
+	}
+}
+
+// Client reads the frames of a Serve stream. It implements
+// stream.AudioPacketProvider.
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	// pending is the frame being handed out by ReadAudioPacket; offset is
+	// the number of its audio bytes already returned.
+	pending audioframe.AudioFrame
+	offset  int
+}
+
+// Dial connects to a frame server at addr (host:port).
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to frame server: %w", err)
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient reads a frame stream from conn. The client owns conn.
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn, r: bufio.NewReader(conn)}
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ReadFrame reads the next frame from the stream. Returns io.EOF when the
+// server has finished the stream.
+func (c *Client) ReadFrame() (audioframe.AudioFrame, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return audioframe.AudioFrame{}, fmt.Errorf("truncated frame header: %w", err)
+		}
+		return audioframe.AudioFrame{}, err
+	}
+
+	audioLen := binary.LittleEndian.Uint32(header[8:12])
+	if audioLen > maxFrameAudio {
+		return audioframe.AudioFrame{}, fmt.Errorf("frame audio length %d exceeds limit %d", audioLen, maxFrameAudio)
+	}
+
+	buf := make([]byte, frameHeaderSize+int(audioLen))
+	copy(buf, header[:])
+	if _, err := io.ReadFull(c.r, buf[frameHeaderSize:]); err != nil {
+		return audioframe.AudioFrame{}, fmt.Errorf("truncated frame audio: %w", err)
+	}
+
+	var frame audioframe.AudioFrame
+	if err := frame.Unmarshal(buf); err != nil {
+		return audioframe.AudioFrame{}, err
+	}
+	frameSize := int(frame.Format.Channels) * int(frame.Format.BitsPerSample) / 8
+	if frameSize <= 0 || len(frame.Audio) != int(frame.SamplesCount)*frameSize {
+		return audioframe.AudioFrame{}, fmt.Errorf("inconsistent frame: %d samples of %d:%d:%d in %d bytes",
+			frame.SamplesCount, frame.Format.SampleRate, frame.Format.Channels, frame.Format.BitsPerSample, len(frame.Audio))
+	}
+	return frame, nil
+}
+
+// ReadAudioPacket returns up to samples samples of the stream, splitting
+// frames larger than that across calls. A cancelled ctx interrupts a read
+// blocked on the network.
+func (c *Client) ReadAudioPacket(ctx context.Context, samples int) (*stream.AudioPacket, error) {
+	if samples <= 0 {
+		return nil, fmt.Errorf("invalid packet size: %d samples", samples)
+	}
+
+	if c.offset >= len(c.pending.Audio) {
+		stop := context.AfterFunc(ctx, func() {
+			c.conn.SetReadDeadline(time.Now())
+		})
+		frame, err := c.ReadFrame()
+		if !stop() {
+			c.conn.SetReadDeadline(time.Time{})
+			if err != nil {
+				return nil, ctx.Err()
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.pending, c.offset = frame, 0
+	}
+
+	format := packetFormat(c.pending.Format)
+	frameSize := format.Channels * format.BytesPerSample
+	n := min(samples, (len(c.pending.Audio)-c.offset)/frameSize)
+
+	pkt := &stream.AudioPacket{
+		Format:       format,
+		SamplesCount: n,
+		Audio:        c.pending.Audio[c.offset : c.offset+n*frameSize],
+	}
+	c.offset += n * frameSize
+	return pkt, nil
+}
+
+// Decoder reads the first frame to learn the stream's format and returns a
+// decoder playing the stream from the start. Closing the decoder closes the
+// client.
+func (c *Client) Decoder(ctx context.Context) (decoder.AudioDecoder, error) {
+	if c.offset >= len(c.pending.Audio) {
+		frame, err := c.ReadFrame()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stream format: %w", err)
+		}
+		c.pending, c.offset = frame, 0
+	}
+
+	return &clientDecoder{StreamDecoder: stream.NewStreamDecoder(ctx, c, packetFormat(c.pending.Format)), client: c}, nil
+}
+
+// packetFormat converts a frame's format to the StreamDecoder's.
+func packetFormat(f audioframe.FrameFormat) stream.AudioFormat {
+	return stream.AudioFormat{
+		SampleRate:     int(f.SampleRate),
+		Channels:       int(f.Channels),
+		BytesPerSample: int(f.BitsPerSample) / 8,
+	}
+}
+
+// clientDecoder is a StreamDecoder that owns its client.
+type clientDecoder struct {
+	*stream.StreamDecoder
+	client *Client
+}
+
+// Close closes the client's connection.
+func (d *clientDecoder) Close() error {
+	return d.client.Close()
+}
@@ -0,0 +1,191 @@
+package frameserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/drgolem/audiokit/pkg/audioframe"
+	"github.com/drgolem/musictools/internal/audiotest"
+	"github.com/drgolem/musictools/internal/decoders/wav"
+)
+
+// rampPCM returns frames stereo 16-bit sample frames whose n-th sample has
+// the value n, so dropped or repeated samples show up.
+func rampPCM(frames int) []byte {
+	vs := make([]int16, 2*frames)
+	for i := range vs {
+		vs[i] = int16(i)
+	}
+	return audiotest.PCM16(vs...)
+}
+
+// testFrame returns a stereo 16-bit 44.1 kHz frame holding pcm.
+func testFrame(pcm []byte) audioframe.AudioFrame {
+	return audioframe.AudioFrame{
+		Format:       audioframe.FrameFormat{SampleRate: 44100, Channels: 2, BitsPerSample: 16},
+		SamplesCount: uint16(len(pcm) / 4),
+		Audio:        pcm,
+	}
+}
+
+func TestServeToDecoder(t *testing.T) {
+	pcm := rampPCM(1000)
+	dec := wav.NewDecoder()
+	err := dec.OpenReader(bytes.NewReader(audiotest.WAVFile(
+		audiotest.RIFFChunk("fmt ", audiotest.WAVFormat(1, 2, 44100, 16)),
+		audiotest.RIFFChunk("data", pcm),
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	served := make(chan error, 1)
+	go func() { served <- Serve(ln, dec, 300) }()
+
+	client, err := Dial(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := client.Decoder(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+
+	if rate, channels, bps := remote.GetFormat(); rate != 44100 || channels != 2 || bps != 16 {
+		t.Fatalf("GetFormat = %d, %d, %d, want 44100, 2, 16", rate, channels, bps)
+	}
+
+	// Read in packets smaller than the server's frames, so they are split.
+	var got []byte
+	buf := make([]byte, 4*128)
+	for {
+		n, err := remote.DecodeSamples(128, buf)
+		got = append(got, buf[:4*n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(got, pcm) {
+		t.Fatalf("received %d bytes, want the %d served", len(got), len(pcm))
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+}
+
+func TestReadAudioPacketSplitsFrames(t *testing.T) {
+	server, conn := net.Pipe()
+	client := NewClient(conn)
+	defer client.Close()
+
+	pcm := rampPCM(10)
+	go func() {
+		frame := testFrame(pcm)
+		server.Write(frame.Marshal())
+		server.Close()
+	}()
+
+	var got []byte
+	var counts []int
+	for {
+		pkt, err := client.ReadAudioPacket(context.Background(), 4)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkt.Format.SampleRate != 44100 || pkt.Format.Channels != 2 || pkt.Format.BytesPerSample != 2 {
+			t.Fatalf("packet format = %+v", pkt.Format)
+		}
+		counts = append(counts, pkt.SamplesCount)
+		got = append(got, pkt.Audio...)
+	}
+	if want := []int{4, 4, 2}; !slices.Equal(counts, want) {
+		t.Errorf("packet sizes = %v, want %v", counts, want)
+	}
+	if !bytes.Equal(got, pcm) {
+		t.Errorf("received % x, want % x", got, pcm)
+	}
+}
+
+func TestReadFrameErrors(t *testing.T) {
+	header := func(channels, bps uint8, samples uint16, audioLen uint32) []byte {
+		b := binary.LittleEndian.AppendUint32(nil, 44100)
+		b = append(b, channels, bps)
+		b = binary.LittleEndian.AppendUint16(b, samples)
+		return binary.LittleEndian.AppendUint32(b, audioLen)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"oversized audio length", header(2, 16, 1, maxFrameAudio+1)},
+		{"truncated header", header(2, 16, 1, 4)[:7]},
+		{"truncated audio", append(header(2, 16, 2, 8), 1, 2, 3)},
+		{"sample count disagrees with length", append(header(2, 16, 3, 8), make([]byte, 8)...)},
+		{"no channels", header(0, 16, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := net.Pipe()
+			client := NewClient(conn)
+			defer client.Close()
+			go func() {
+				server.Write(tt.data)
+				server.Close()
+			}()
+
+			if _, err := client.ReadFrame(); err == nil || err == io.EOF {
+				t.Fatalf("ReadFrame error = %v, want a frame error", err)
+			}
+		})
+	}
+}
+
+func TestReadAudioPacketCancel(t *testing.T) {
+	server, conn := net.Pipe()
+	defer server.Close()
+	client := NewClient(conn)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.ReadAudioPacket(ctx, 4)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ReadAudioPacket error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("ReadAudioPacket returned after %v", elapsed)
+	}
+
+	// The client stays usable once the deadline is cleared.
+	go func() {
+		frame := testFrame(rampPCM(2))
+		server.Write(frame.Marshal())
+	}()
+	pkt, err := client.ReadAudioPacket(context.Background(), 4)
+	if err != nil || pkt.SamplesCount != 2 {
+		t.Fatalf("ReadAudioPacket after cancel = %v, %v, want 2 samples", pkt, err)
+	}
+}
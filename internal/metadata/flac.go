@@ -0,0 +1,156 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FLAC metadata block types.
+const (
+	flacBlockStreamInfo    = 0
+	flacBlockVorbisComment = 4
+)
+
+// flacStreamInfoSize is the size of the STREAMINFO block body.
+const flacStreamInfoSize = 34
+
+// FLACStreamInfo holds the fields of a FLAC STREAMINFO block.
+type FLACStreamInfo struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+	TotalSamples  int64    // sample frames, 0 if unknown
+	MD5           [16]byte // MD5 of the unencoded audio, all zero if not set
+}
+
+// HasMD5 reports whether the encoder stored an audio MD5 signature.
+func (si *FLACStreamInfo) HasMD5() bool {
+	return si.MD5 != [16]byte{}
+}
+
+// readFLACMarker consumes the "fLaC" stream marker.
+func readFLACMarker(r io.Reader) error {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return fmt.Errorf("failed to read FLAC marker: %w", err)
+	}
+	if string(magic[:]) != "fLaC" {
+		return fmt.Errorf("not a FLAC file")
+	}
+	return nil
+}
+
+// ReadFLACStreamInfo reads the STREAMINFO block, which the FLAC format
+// requires to be the first metadata block.
+func ReadFLACStreamInfo(r io.Reader) (*FLACStreamInfo, error) {
+	if err := readFLACMarker(r); err != nil {
+		return nil, err
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("failed to read FLAC metadata block: %w", err)
+	}
+	blockType := header[0] & 0x7F
+	size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+	if blockType != flacBlockStreamInfo || size < flacStreamInfoSize {
+		return nil, fmt.Errorf("FLAC stream does not start with STREAMINFO")
+	}
+
+	var block [flacStreamInfoSize]byte
+	if _, err := io.ReadFull(r, block[:]); err != nil {
+		return nil, fmt.Errorf("failed to read STREAMINFO: %w", err)
+	}
+
+	// Bytes 10-17 pack sample rate (20 bits), channels-1 (3 bits),
+	// bits per sample-1 (5 bits) and total samples (36 bits).
+	packed := binary.BigEndian.Uint64(block[10:18])
+	si := &FLACStreamInfo{
+		SampleRate:    int(packed >> 44),
+		Channels:      int(packed>>41&0x7) + 1,
+		BitsPerSample: int(packed>>36&0x1F) + 1,
+		TotalSamples:  int64(packed & (1<<36 - 1)),
+	}
+	copy(si.MD5[:], block[18:34])
+	return si, nil
+}
+
+// ReadFLACComments reads the Vorbis comment block of a FLAC stream.
+// Field names are lowercased; when a field repeats, the first value is kept.
+func ReadFLACComments(r io.Reader) (map[string]string, error) {
+	tags := map[string]string{}
+
+	if err := readFLACMarker(r); err != nil {
+		return nil, err
+	}
+
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("failed to read FLAC metadata block: %w", err)
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType == flacBlockVorbisComment {
+			block := make([]byte, size)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return nil, fmt.Errorf("failed to read Vorbis comments: %w", err)
+			}
+			parseVorbisComments(block, tags)
+			return tags, nil
+		}
+
+		if _, err := io.CopyN(io.Discard, r, size); err != nil {
+			return nil, fmt.Errorf("failed to skip FLAC metadata block: %w", err)
+		}
+		if last {
+			return tags, nil
+		}
+	}
+}
+
+// parseVorbisComments decodes a Vorbis comment block (vendor string followed
+// by length-prefixed KEY=value entries, all little-endian) into tags.
+func parseVorbisComments(data []byte, tags map[string]string) {
+	readString := func() (string, bool) {
+		if len(data) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(data[0:4])
+		if uint64(n) > uint64(len(data)-4) {
+			return "", false
+		}
+		s := string(data[4 : 4+n])
+		data = data[4+n:]
+		return s, true
+	}
+
+	// Vendor string
+	if _, ok := readString(); !ok {
+		return
+	}
+	if len(data) < 4 {
+		return
+	}
+	count := binary.LittleEndian.Uint32(data[0:4])
+	data = data[4:]
+
+	for i := uint32(0); i < count; i++ {
+		entry, ok := readString()
+		if !ok {
+			return
+		}
+		key, value, found := strings.Cut(entry, "=")
+		if !found || value == "" {
+			continue
+		}
+		key = strings.ToLower(key)
+		if _, exists := tags[key]; !exists {
+			tags[key] = value
+		}
+	}
+}
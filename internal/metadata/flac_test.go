@@ -0,0 +1,148 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"maps"
+	"testing"
+)
+
+// flacBlock returns a FLAC metadata block with the given type and body.
+func flacBlock(blockType byte, last bool, body []byte) []byte {
+	if last {
+		blockType |= 0x80
+	}
+	n := len(body)
+	return append([]byte{blockType, byte(n >> 16), byte(n >> 8), byte(n)}, body...)
+}
+
+// streamInfoBody returns a STREAMINFO block body.
+func streamInfoBody(rate, channels, bps int, total int64, md5 [16]byte) []byte {
+	body := make([]byte, flacStreamInfoSize)
+	packed := uint64(rate)<<44 | uint64(channels-1)<<41 | uint64(bps-1)<<36 | uint64(total)
+	binary.BigEndian.PutUint64(body[10:18], packed)
+	copy(body[18:], md5[:])
+	return body
+}
+
+// vorbisCommentBody returns a Vorbis comment block body with the given
+// entries.
+func vorbisCommentBody(vendor string, entries ...string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(vendor)))
+	b = append(b, vendor...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(entries)))
+	for _, e := range entries {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(e)))
+		b = append(b, e...)
+	}
+	return b
+}
+
+// flacFile returns a FLAC stream made of the marker and blocks.
+func flacFile(blocks ...[]byte) []byte {
+	return append([]byte("fLaC"), bytes.Join(blocks, nil)...)
+}
+
+func TestReadFLACStreamInfo(t *testing.T) {
+	md5 := [16]byte{1, 2, 3}
+	tests := []struct {
+		name string
+		data []byte
+		want FLACStreamInfo
+	}{
+		{"CD audio", flacFile(flacBlock(0, true, streamInfoBody(44100, 2, 16, 1234567, [16]byte{}))),
+			FLACStreamInfo{SampleRate: 44100, Channels: 2, BitsPerSample: 16, TotalSamples: 1234567}},
+		{"hi-res with MD5", flacFile(flacBlock(0, false, streamInfoBody(192000, 6, 24, 1<<36-1, md5))),
+			FLACStreamInfo{SampleRate: 192000, Channels: 6, BitsPerSample: 24, TotalSamples: 1<<36 - 1, MD5: md5}},
+		{"unknown length", flacFile(flacBlock(0, true, streamInfoBody(8000, 1, 8, 0, [16]byte{}))),
+			FLACStreamInfo{SampleRate: 8000, Channels: 1, BitsPerSample: 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			si, err := ReadFLACStreamInfo(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *si != tt.want {
+				t.Fatalf("got %+v, want %+v", *si, tt.want)
+			}
+			if si.HasMD5() != (tt.want.MD5 != [16]byte{}) {
+				t.Errorf("HasMD5() = %v", si.HasMD5())
+			}
+		})
+	}
+}
+
+func TestReadFLACStreamInfoErrors(t *testing.T) {
+	info := streamInfoBody(44100, 2, 16, 0, [16]byte{})
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not FLAC", []byte("RIFF\x00\x00\x00\x00WAVE")},
+		{"no blocks", []byte("fLaC")},
+		{"comments first", flacFile(flacBlock(4, false, vorbisCommentBody("x")), flacBlock(0, true, info))},
+		{"short STREAMINFO", flacFile(flacBlock(0, true, info[:20]))},
+		{"truncated STREAMINFO", flacFile(flacBlock(0, true, info))[:30]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadFLACStreamInfo(bytes.NewReader(tt.data)); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
+
+func TestReadFLACComments(t *testing.T) {
+	info := flacBlock(0, false, make([]byte, 34))
+	// A block whose count claims more entries than it holds keeps the ones
+	// that are there.
+	truncated := vorbisCommentBody("v", "TITLE=x", "ARTIST=y")
+	truncated = truncated[:len(truncated)-len("ARTIST=y")]
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{"comments", flacFile(info, flacBlock(1, false, make([]byte, 10)), flacBlock(4, true,
+			vorbisCommentBody("libFLAC", "TITLE=Song", "Artist=Band", "ALBUM=A=B", "TITLE=Other", "EMPTY=", "NOEQUALS"))),
+			map[string]string{"title": "Song", "artist": "Band", "album": "A=B"}},
+		{"no comment block", flacFile(flacBlock(0, true, make([]byte, 34))), map[string]string{}},
+		{"empty comment block", flacFile(info, flacBlock(4, true, nil)), map[string]string{}},
+		{"truncated entries", flacFile(info, flacBlock(4, true, truncated)), map[string]string{"title": "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFLACComments(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFLACCommentsErrors(t *testing.T) {
+	info := flacBlock(0, false, make([]byte, 34))
+	full := flacFile(info, flacBlock(4, true, vorbisCommentBody("v", "TITLE=x")))
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not FLAC", []byte("ID3\x04")},
+		{"missing last block", flacFile(info)},
+		{"truncated skipped block", flacFile(info)[:20]},
+		{"truncated comments", full[:len(full)-2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadFLACComments(bytes.NewReader(tt.data)); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// id3Frames maps ID3v2.3/2.4 text frame identifiers to tag keys.
+var id3Frames = map[string]string{
+	"TIT2": Title,
+	"TPE1": Artist,
+	"TALB": Album,
+	"TCON": Genre,
+	"TDRC": Date, // v2.4
+	"TYER": Date, // v2.3
+	"TRCK": TrackNumber,
+}
+
+// id3v22Frames maps the three-letter ID3v2.2 frame identifiers to their
+// v2.3 equivalents.
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TAL": "TALB",
+	"TCO": "TCON",
+	"TYE": "TYER",
+	"TRK": "TRCK",
+	"COM": "COMM",
+	"TXX": "TXXX",
+}
+
+// ReadID3v2 reads the ID3v2 tag at the start of a stream, as written before
+// the audio of MP3 files. Versions 2.2, 2.3 and 2.4 are supported. Text
+// frames are mapped to the common tag keys, the first COMM frame to
+// Comment, and user-defined TXXX frames to their lowercased description
+// (e.g. "replaygain_track_gain"). When a field repeats, the first value is
+// kept. Returns an empty map if the stream has no ID3v2 tag.
+func ReadID3v2(r io.Reader) (map[string]string, error) {
+	tags := map[string]string{}
+
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return tags, nil
+		}
+		return nil, fmt.Errorf("failed to read ID3v2 header: %w", err)
+	}
+	if string(header[0:3]) != "ID3" {
+		return tags, nil
+	}
+	version := header[3]
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported ID3v2 version: 2.%d", version)
+	}
+	flags := header[5]
+	size := syncsafeInt(header[6:10])
+	if size > maxTagBlockSize {
+		return nil, fmt.Errorf("ID3v2 tag too large: %d bytes", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("failed to read ID3v2 tag: %w", err)
+	}
+
+	// Before v2.4, unsynchronisation applies to the whole tag; in v2.4 it
+	// is flagged per frame.
+	if flags&0x80 != 0 && version < 4 {
+		data = removeUnsync(data)
+	}
+	if flags&0x40 != 0 && version >= 3 {
+		data = skipID3ExtendedHeader(data, version)
+	}
+
+	parseID3Frames(data, version, tags)
+	return tags, nil
+}
+
+// syncsafeInt decodes a 28-bit integer stored 7 bits per byte.
+func syncsafeInt(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// removeUnsync undoes ID3v2 unsynchronisation, which inserts a zero byte
+// after every 0xFF.
+func removeUnsync(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// skipID3ExtendedHeader returns data past the extended header. Its size
+// excludes itself in v2.3 and is syncsafe and inclusive in v2.4.
+func skipID3ExtendedHeader(data []byte, version byte) []byte {
+	if len(data) < 4 {
+		return nil
+	}
+	var n int
+	if version == 3 {
+		n = 4 + int(binary.BigEndian.Uint32(data[0:4]))
+	} else {
+		n = syncsafeInt(data[0:4])
+	}
+	if n < 0 || n > len(data) {
+		return nil
+	}
+	return data[n:]
+}
+
+// parseID3Frames decodes the frames of an ID3v2 tag body into tags.
+func parseID3Frames(data []byte, version byte, tags map[string]string) {
+	headerSize := 10
+	if version == 2 {
+		headerSize = 6
+	}
+
+	for len(data) >= headerSize && data[0] != 0 {
+		var id string
+		var size int
+		var frameFlags uint16
+		switch version {
+		case 2:
+			id = id3v22Frames[string(data[0:3])]
+			size = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			id = string(data[0:4])
+			size = int(binary.BigEndian.Uint32(data[4:8]))
+			frameFlags = binary.BigEndian.Uint16(data[8:10])
+		default:
+			id = string(data[0:4])
+			size = syncsafeInt(data[4:8])
+			frameFlags = binary.BigEndian.Uint16(data[8:10])
+		}
+		data = data[headerSize:]
+		if size < 0 || size > len(data) {
+			return
+		}
+		body := data[:size]
+		data = data[size:]
+
+		// Compressed and encrypted frames are skipped (v2.3: 0x0080 and
+		// 0x0040; v2.4: 0x0008 and 0x0004).
+		if version == 3 && frameFlags&0x00c0 != 0 {
+			continue
+		}
+		if version == 4 {
+			if frameFlags&0x000c != 0 {
+				continue
+			}
+			if frameFlags&0x0001 != 0 { // data length indicator
+				if len(body) < 4 {
+					continue
+				}
+				body = body[4:]
+			}
+			if frameFlags&0x0002 != 0 {
+				body = removeUnsync(body)
+			}
+		}
+
+		key, value := id3FrameTag(id, body)
+		if key == "" || value == "" {
+			continue
+		}
+		if _, exists := tags[key]; !exists {
+			tags[key] = value
+		}
+	}
+}
+
+// id3FrameTag returns the tag key and value of a frame, or an empty key for
+// frames that carry no supported tag.
+func id3FrameTag(id string, body []byte) (string, string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+	enc, body := body[0], body[1:]
+
+	switch id {
+	case "COMM":
+		// Language code, then a description and the text.
+		if len(body) < 3 {
+			return "", ""
+		}
+		_, text := splitID3String(enc, body[3:])
+		return Comment, decodeID3Text(enc, text)
+	case "TXXX":
+		desc, text := splitID3String(enc, body)
+		return strings.ToLower(decodeID3Text(enc, desc)), decodeID3Text(enc, text)
+	}
+
+	key, ok := id3Frames[id]
+	if !ok {
+		return "", ""
+	}
+	return key, decodeID3Text(enc, body)
+}
+
+// splitID3String splits data at the first string terminator of encoding
+// enc, returning the string before it and the data after it.
+func splitID3String(enc byte, data []byte) ([]byte, []byte) {
+	if enc == 1 || enc == 2 {
+		// UTF-16: the terminator is a 16-bit zero at an even offset.
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:]
+			}
+		}
+		return data, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+	return data, nil
+}
+
+// decodeID3Text decodes a text field in encoding enc: 0 ISO-8859-1,
+// 1 UTF-16 with byte order mark, 2 UTF-16BE, 3 UTF-8. Of a v2.4
+// null-separated list, the first value is returned.
+func decodeID3Text(enc byte, data []byte) string {
+	data, _ = splitID3String(enc, data)
+
+	switch enc {
+	case 0:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.TrimSpace(string(runes))
+	case 1, 2:
+		bigEndian := enc == 2
+		if len(data) >= 2 {
+			switch {
+			case data[0] == 0xff && data[1] == 0xfe:
+				bigEndian, data = false, data[2:]
+			case data[0] == 0xfe && data[1] == 0xff:
+				bigEndian, data = true, data[2:]
+			}
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = binary.BigEndian.Uint16(data[2*i:])
+			} else {
+				units[i] = binary.LittleEndian.Uint16(data[2*i:])
+			}
+		}
+		return strings.TrimSpace(string(utf16.Decode(units)))
+	default:
+		return strings.TrimSpace(string(data))
+	}
+}
@@ -0,0 +1,180 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"maps"
+	"testing"
+)
+
+// syncsafe returns n as a 4-byte syncsafe integer.
+func syncsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+// id3Tag returns an ID3v2.version tag with the given header flags and body.
+func id3Tag(version, flags byte, body ...[]byte) []byte {
+	b := bytes.Join(body, nil)
+	return append(append([]byte{'I', 'D', '3', version, 0, flags}, syncsafe(len(b))...), b...)
+}
+
+// id3Frame returns a frame of an ID3v2.version tag.
+func id3Frame(version byte, id string, flags uint16, body []byte) []byte {
+	n := len(body)
+	switch version {
+	case 2:
+		return append([]byte{id[0], id[1], id[2], byte(n >> 16), byte(n >> 8), byte(n)}, body...)
+	case 3:
+		b := binary.BigEndian.AppendUint32([]byte(id), uint32(n))
+		return append(binary.BigEndian.AppendUint16(b, flags), body...)
+	default:
+		b := append([]byte(id), syncsafe(n)...)
+		return append(binary.BigEndian.AppendUint16(b, flags), body...)
+	}
+}
+
+// latin1 returns an ISO-8859-1 text frame body.
+func latin1(s string) []byte {
+	return append([]byte{0}, s...)
+}
+
+// utf16LE returns a UTF-16 text frame body with a little-endian BOM.
+func utf16LE(s string) []byte {
+	b := []byte{1, 0xff, 0xfe}
+	for _, r := range s {
+		b = binary.LittleEndian.AppendUint16(b, uint16(r))
+	}
+	return b
+}
+
+func TestReadID3v2(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{"no tag", []byte("\xff\xfb\x90\x00 audio"), map[string]string{}},
+		{"empty stream", nil, map[string]string{}},
+		{"v2.3 text frames", id3Tag(3, 0,
+			id3Frame(3, "TIT2", 0, latin1("Song")),
+			id3Frame(3, "TPE1", 0, utf16LE("Bänd")),
+			id3Frame(3, "TYER", 0, latin1("1999")),
+			id3Frame(3, "TIT2", 0, latin1("Second title")),
+			id3Frame(3, "APIC", 0, []byte{0, 1, 2, 3}),
+			make([]byte, 20), // padding
+		), map[string]string{Title: "Song", Artist: "Bänd", Date: "1999"}},
+		{"v2.4 UTF-8 and list", id3Tag(4, 0,
+			id3Frame(4, "TALB", 0, append([]byte{3}, "Älbum"...)),
+			id3Frame(4, "TCON", 0, append([]byte{3}, "Rock\x00Pop"...)),
+			id3Frame(4, "TDRC", 0, append([]byte{2, 0, '2', 0, '0'}, 0, '2', 0, '4')),
+		), map[string]string{Album: "Älbum", Genre: "Rock", Date: "2024"}},
+		{"v2.2", id3Tag(2, 0,
+			id3Frame(2, "TT2", 0, latin1("Old")),
+			id3Frame(2, "TRK", 0, latin1("4/12")),
+			id3Frame(2, "PIC", 0, []byte{0, 1}),
+		), map[string]string{Title: "Old", TrackNumber: "4/12"}},
+		{"COMM and TXXX", id3Tag(3, 0,
+			id3Frame(3, "COMM", 0, append(latin1("eng"), "desc\x00Nice"...)),
+			id3Frame(3, "TXXX", 0, append(latin1("REPLAYGAIN_TRACK_GAIN"), "\x00-6.48 dB"...)),
+			id3Frame(3, "TXXX", 0, append([]byte{1, 0xff, 0xfe, 'X', 0, 0, 0}, 0xff, 0xfe, 'y', 0)),
+		), map[string]string{Comment: "Nice", "replaygain_track_gain": "-6.48 dB", "x": "y"}},
+		// Frame sizes count the bytes after unsynchronisation is undone.
+		{"v2.3 unsynchronisation", id3Tag(3, 0x80, bytes.ReplaceAll(
+			id3Frame(3, "TIT2", 0, []byte{0, 'a', 0xff, 'b'}), []byte{0xff}, []byte{0xff, 0x00}),
+		), map[string]string{Title: "aÿb"}},
+		{"v2.3 extended header", id3Tag(3, 0x40,
+			[]byte{0, 0, 0, 6, 0, 0, 0, 0, 0, 0},
+			id3Frame(3, "TIT2", 0, latin1("Ext")),
+		), map[string]string{Title: "Ext"}},
+		{"v2.4 extended header", id3Tag(4, 0x40,
+			[]byte{0, 0, 0, 6, 1, 0},
+			id3Frame(4, "TIT2", 0, latin1("Ext")),
+		), map[string]string{Title: "Ext"}},
+		{"v2.3 compressed and encrypted frames skipped", id3Tag(3, 0,
+			id3Frame(3, "TIT2", 0x0080, latin1("zipped")),
+			id3Frame(3, "TPE1", 0x0040, latin1("secret")),
+			id3Frame(3, "TALB", 0, latin1("Plain")),
+		), map[string]string{Album: "Plain"}},
+		{"v2.4 frame flags", id3Tag(4, 0,
+			id3Frame(4, "TIT2", 0x0008, latin1("zipped")),
+			id3Frame(4, "TPE1", 0x0001, append([]byte{0, 0, 0, 5}, latin1("Band")...)),
+			id3Frame(4, "TALB", 0x0002, []byte{0, 'a', 0xff, 0x00, 'b'}),
+		), map[string]string{Artist: "Band", Album: "aÿb"}},
+		{"frame overruns tag", id3Tag(3, 0,
+			id3Frame(3, "TIT2", 0, latin1("Song")),
+			[]byte("TPE1\x00\x00\x01\x00\x00\x00x"),
+		), map[string]string{Title: "Song"}},
+		{"empty values skipped", id3Tag(3, 0,
+			id3Frame(3, "TIT2", 0, latin1("  ")),
+			id3Frame(3, "TPE1", 0, nil),
+			id3Frame(3, "COMM", 0, []byte{0, 'e'}),
+		), map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadID3v2(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadID3v2Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"v2.5", id3Tag(5, 0)},
+		{"v2.1", id3Tag(1, 0)},
+		{"too large", append([]byte("ID3\x03\x00\x00"), syncsafe(maxTagBlockSize+1)...)},
+		{"truncated", id3Tag(3, 0, id3Frame(3, "TIT2", 0, latin1("Song")))[:15]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadID3v2(bytes.NewReader(tt.data)); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
+
+func TestDecodeID3Text(t *testing.T) {
+	tests := []struct {
+		name string
+		enc  byte
+		data []byte
+		want string
+	}{
+		{"latin1", 0, []byte("caf\xe9"), "café"},
+		{"UTF-16 LE BOM", 1, []byte{0xff, 0xfe, 'h', 0, 'i', 0}, "hi"},
+		{"UTF-16 BE BOM", 1, []byte{0xfe, 0xff, 0, 'h', 0, 'i'}, "hi"},
+		{"UTF-16 without BOM", 1, []byte{'h', 0, 'i', 0}, "hi"},
+		{"UTF-16BE", 2, []byte{0, 'h', 0, 'i', 0, 0, 0, 'x'}, "hi"},
+		{"UTF-16 surrogate pair", 1, []byte{0xff, 0xfe, 0x3c, 0xd8, 0xb5, 0xdf}, "\U0001f3b5"},
+		{"UTF-8", 3, []byte(" naïve \x00second"), "naïve"},
+		{"odd UTF-16 length", 2, []byte{0, 'a', 0}, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeID3Text(tt.enc, tt.data); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncsafeInt(t *testing.T) {
+	for _, n := range []int{0, 1, 127, 128, 1 << 20, 1<<28 - 1} {
+		if got := syncsafeInt(syncsafe(n)); got != n {
+			t.Errorf("syncsafeInt(syncsafe(%d)) = %d", n, got)
+		}
+	}
+	// The top bit of each byte is ignored.
+	if got := syncsafeInt([]byte{0x80, 0x80, 0x81, 0xff}); got != 0xff {
+		t.Errorf("got %d, want 255", got)
+	}
+}
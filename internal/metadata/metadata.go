@@ -0,0 +1,50 @@
+// Package metadata reads and writes audio file tags (title, artist, album...).
+//
+// Tags are returned as a map keyed by lowercase field names ("title",
+// "artist", "album", ...) so that callers can move tags between container
+// formats without knowing each format's native identifiers.
+package metadata
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Common tag keys.
+const (
+	Title       = "title"
+	Artist      = "artist"
+	Album       = "album"
+	Genre       = "genre"
+	Date        = "date"
+	Comment     = "comment"
+	TrackNumber = "tracknumber"
+)
+
+// maxTagBlockSize bounds the size of a single tag block read from a file,
+// so that corrupt length fields cannot trigger huge allocations.
+const maxTagBlockSize = 16 << 20
+
+// ReadFile reads the tags of an audio file, selecting the parser by file
+// extension. Returns an empty map (not an error) when the file has no tags
+// or its format has no supported tag parser.
+func ReadFile(fileName string) (map[string]string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(fileName), err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".wav":
+		return ReadWAVInfo(f)
+	case ".flac", ".fla":
+		return ReadFLACComments(f)
+	case ".mp3":
+		return ReadID3v2(f)
+	default:
+		return map[string]string{}, nil
+	}
+}
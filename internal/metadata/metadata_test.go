@@ -0,0 +1,57 @@
+package metadata
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	wav := audiotest.WAVFile(EncodeWAVInfo(map[string]string{Title: "Wave"}))
+	flac := flacFile(flacBlock(4, true, vorbisCommentBody("v", "TITLE=Flac")))
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{"a.wav", wav, map[string]string{Title: "Wave"}},
+		{"b.WAV", wav, map[string]string{Title: "Wave"}},
+		{"c.flac", flac, map[string]string{Title: "Flac"}},
+		{"d.fla", flac, map[string]string{Title: "Flac"}},
+		{"e.ogg", flac, map[string]string{}},
+		{"noext", wav, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(name, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ReadFile(filepath.Join(dir, "missing.wav")); err == nil {
+		t.Error("ReadFile of a missing file succeeded")
+	}
+	name := filepath.Join(dir, "fake.flac")
+	if err := os.WriteFile(name, []byte("RIFF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(name); err == nil {
+		t.Error("ReadFile of a WAV named .flac succeeded")
+	}
+}
@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ReplayGain tag keys.
+const (
+	ReplayGainTrackGain = "replaygain_track_gain"
+	ReplayGainTrackPeak = "replaygain_track_peak"
+	ReplayGainAlbumGain = "replaygain_album_gain"
+	ReplayGainAlbumPeak = "replaygain_album_peak"
+)
+
+// ReplayGainMode selects which ReplayGain value to apply.
+type ReplayGainMode int
+
+const (
+	ReplayGainOff   ReplayGainMode = iota // ignore ReplayGain tags
+	ReplayGainTrack                       // level each track on its own
+	ReplayGainAlbum                       // keep level differences within an album
+)
+
+// ParseReplayGainMode parses "off", "track" or "album".
+func ParseReplayGainMode(s string) (ReplayGainMode, error) {
+	switch s {
+	case "off":
+		return ReplayGainOff, nil
+	case "track":
+		return ReplayGainTrack, nil
+	case "album":
+		return ReplayGainAlbum, nil
+	}
+	return ReplayGainOff, fmt.Errorf("unknown ReplayGain mode %q (want off, track or album)", s)
+}
+
+// String returns the mode's name as accepted by ParseReplayGainMode.
+func (m ReplayGainMode) String() string {
+	switch m {
+	case ReplayGainTrack:
+		return "track"
+	case ReplayGainAlbum:
+		return "album"
+	}
+	return "off"
+}
+
+// ReplayGain holds the ReplayGain values of a file. Gains are in dB; peaks
+// are linear sample peaks relative to full scale, 0 when not tagged.
+type ReplayGain struct {
+	TrackGain    float64
+	TrackPeak    float64
+	HasTrackGain bool
+
+	AlbumGain    float64
+	AlbumPeak    float64
+	HasAlbumGain bool
+}
+
+// ParseReplayGain reads the ReplayGain values from tags as returned by
+// ReadFile. Gains are written like "-6.48 dB"; a malformed value is an
+// error rather than being ignored, since a wrong level can be loud.
+func ParseReplayGain(tags map[string]string) (ReplayGain, error) {
+	var rg ReplayGain
+	var err error
+	if rg.TrackGain, rg.HasTrackGain, err = parseReplayGainValue(tags, ReplayGainTrackGain); err != nil {
+		return ReplayGain{}, err
+	}
+	if rg.TrackPeak, _, err = parseReplayGainValue(tags, ReplayGainTrackPeak); err != nil {
+		return ReplayGain{}, err
+	}
+	if rg.AlbumGain, rg.HasAlbumGain, err = parseReplayGainValue(tags, ReplayGainAlbumGain); err != nil {
+		return ReplayGain{}, err
+	}
+	if rg.AlbumPeak, _, err = parseReplayGainValue(tags, ReplayGainAlbumPeak); err != nil {
+		return ReplayGain{}, err
+	}
+	return rg, nil
+}
+
+// parseReplayGainValue parses the number in tags[key], dropping a trailing
+// "dB" unit.
+func parseReplayGainValue(tags map[string]string, key string) (float64, bool, error) {
+	s, ok := tags[key]
+	if !ok {
+		return 0, false, nil
+	}
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && strings.EqualFold(s[len(s)-2:], "db") {
+		s = strings.TrimSpace(s[:len(s)-2])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false, fmt.Errorf("invalid %s value %q", key, tags[key])
+	}
+	return v, true, nil
+}
+
+// Gain returns the linear gain for mode, reduced if needed so the tagged
+// peak doesn't clip. Album mode falls back to the track values for files
+// without album gain. ok is false if the file has no gain for the mode.
+func (rg ReplayGain) Gain(mode ReplayGainMode) (gain float64, ok bool) {
+	db, peak := rg.TrackGain, rg.TrackPeak
+	switch {
+	case mode == ReplayGainAlbum && rg.HasAlbumGain:
+		db, peak = rg.AlbumGain, rg.AlbumPeak
+	case mode == ReplayGainOff || !rg.HasTrackGain:
+		return 1, false
+	}
+
+	gain = math.Pow(10, db/20)
+	if peak > 0 && gain*peak > 1 {
+		gain = 1 / peak
+	}
+	return gain, true
+}
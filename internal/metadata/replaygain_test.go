@@ -0,0 +1,115 @@
+package metadata
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseReplayGainMode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ReplayGainMode
+	}{
+		{"off", ReplayGainOff},
+		{"track", ReplayGainTrack},
+		{"album", ReplayGainAlbum},
+	}
+	for _, tt := range tests {
+		got, err := ParseReplayGainMode(tt.in)
+		if err != nil {
+			t.Fatalf("ParseReplayGainMode(%q): %v", tt.in, err)
+		}
+		if got != tt.want || got.String() != tt.in {
+			t.Errorf("ParseReplayGainMode(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "Track", "radio"} {
+		if _, err := ParseReplayGainMode(in); err == nil {
+			t.Errorf("ParseReplayGainMode(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestParseReplayGain(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		want ReplayGain
+	}{
+		{"untagged", map[string]string{Title: "x"}, ReplayGain{}},
+		{"track only", map[string]string{
+			ReplayGainTrackGain: "-6.48 dB",
+			ReplayGainTrackPeak: "0.988",
+		}, ReplayGain{TrackGain: -6.48, TrackPeak: 0.988, HasTrackGain: true}},
+		{"all values", map[string]string{
+			ReplayGainTrackGain: "+1.5dB",
+			ReplayGainTrackPeak: "0.5",
+			ReplayGainAlbumGain: " -3 DB ",
+			ReplayGainAlbumPeak: "1.2",
+		}, ReplayGain{TrackGain: 1.5, TrackPeak: 0.5, HasTrackGain: true, AlbumGain: -3, AlbumPeak: 1.2, HasAlbumGain: true}},
+		{"no unit", map[string]string{ReplayGainAlbumGain: "2"}, ReplayGain{AlbumGain: 2, HasAlbumGain: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReplayGain(tt.tags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReplayGainErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+	}{
+		{"empty gain", map[string]string{ReplayGainTrackGain: ""}},
+		{"unit only", map[string]string{ReplayGainTrackGain: "dB"}},
+		{"text", map[string]string{ReplayGainAlbumGain: "loud"}},
+		{"NaN", map[string]string{ReplayGainTrackGain: "NaN dB"}},
+		{"infinite peak", map[string]string{ReplayGainTrackPeak: "Inf"}},
+		{"bad album peak", map[string]string{ReplayGainAlbumPeak: "0,9"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseReplayGain(tt.tags); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
+
+func TestReplayGainGain(t *testing.T) {
+	trackOnly := ReplayGain{TrackGain: -6.0206, HasTrackGain: true}
+	both := ReplayGain{TrackGain: -6.0206, HasTrackGain: true, AlbumGain: 6.0206, HasAlbumGain: true}
+	tests := []struct {
+		name   string
+		rg     ReplayGain
+		mode   ReplayGainMode
+		want   float64
+		wantOK bool
+	}{
+		{"off", both, ReplayGainOff, 1, false},
+		{"untagged", ReplayGain{}, ReplayGainTrack, 1, false},
+		{"untagged album", ReplayGain{}, ReplayGainAlbum, 1, false},
+		{"track", both, ReplayGainTrack, 0.5, true},
+		{"album", both, ReplayGainAlbum, 2, true},
+		{"album falls back to track", trackOnly, ReplayGainAlbum, 0.5, true},
+		{"album without track gain", ReplayGain{AlbumGain: -6.0206, HasAlbumGain: true}, ReplayGainAlbum, 0.5, true},
+		{"peak limits gain", ReplayGain{TrackGain: 6.0206, TrackPeak: 0.8, HasTrackGain: true}, ReplayGainTrack, 1.25, true},
+		{"peak below limit", ReplayGain{TrackGain: 6.0206, TrackPeak: 0.25, HasTrackGain: true}, ReplayGainTrack, 2, true},
+		{"album peak", ReplayGain{TrackGain: 0, HasTrackGain: true, AlbumGain: 6.0206, AlbumPeak: 1, HasAlbumGain: true}, ReplayGainAlbum, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.rg.Gain(tt.mode)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-4 {
+				t.Fatalf("got %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
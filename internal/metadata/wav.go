@@ -0,0 +1,139 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// wavInfoIDs maps tag keys to RIFF LIST/INFO chunk identifiers.
+var wavInfoIDs = map[string]string{
+	Title:       "INAM",
+	Artist:      "IART",
+	Album:       "IPRD",
+	Genre:       "IGNR",
+	Date:        "ICRD",
+	Comment:     "ICMT",
+	TrackNumber: "ITRK",
+}
+
+// wavInfoKey returns the tag key for a LIST/INFO chunk identifier.
+func wavInfoKey(id string) (string, bool) {
+	for key, infoID := range wavInfoIDs {
+		if infoID == id {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// ReadWAVInfo scans a RIFF/WAVE stream for a LIST/INFO chunk and returns its tags.
+// Unknown INFO identifiers are ignored.
+func ReadWAVInfo(r io.ReadSeeker) (map[string]string, error) {
+	tags := map[string]string{}
+
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("failed to read RIFF header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF/WAVE file")
+	}
+
+	for {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(r, chunkHeader[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return tags, nil
+			}
+			return nil, err
+		}
+		id := string(chunkHeader[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+		padded := size + size%2
+
+		if id != "LIST" || size < 4 || size > maxTagBlockSize {
+			if _, err := r.Seek(padded, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			// Truncated LIST chunk at end of file: keep what was found so far
+			return tags, nil
+		}
+		if string(chunk[0:4]) == "INFO" {
+			parseWAVInfo(chunk[4:], tags)
+		}
+		if size%2 == 1 {
+			if _, err := r.Seek(1, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// parseWAVInfo decodes the sub-chunks of a LIST/INFO chunk body into tags.
+func parseWAVInfo(data []byte, tags map[string]string) {
+	for len(data) >= 8 {
+		id := string(data[0:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		data = data[8:]
+		if size > len(data) {
+			return
+		}
+
+		value := strings.TrimRight(string(data[:size]), "\x00")
+		if key, ok := wavInfoKey(id); ok && value != "" {
+			tags[key] = value
+		}
+
+		size += size % 2
+		if size > len(data) {
+			return
+		}
+		data = data[size:]
+	}
+}
+
+// EncodeWAVInfo builds a complete RIFF LIST/INFO chunk (including its
+// 8-byte chunk header) for the given tags. Tags without a LIST/INFO
+// identifier are skipped. Returns nil if no tags can be encoded.
+func EncodeWAVInfo(tags map[string]string) []byte {
+	keys := make([]string, 0, len(tags))
+	for key, value := range tags {
+		if _, ok := wavInfoIDs[key]; ok && value != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	slices.Sort(keys)
+
+	var body bytes.Buffer
+	body.WriteString("INFO")
+	for _, key := range keys {
+		value := tags[key]
+		// Values are null-terminated and padded to an even length
+		size := len(value) + 1
+		body.WriteString(wavInfoIDs[key])
+		binary.Write(&body, binary.LittleEndian, uint32(size))
+		body.WriteString(value)
+		body.WriteByte(0)
+		if size%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+
+	chunk := make([]byte, 8, 8+body.Len())
+	copy(chunk[0:4], "LIST")
+	binary.LittleEndian.PutUint32(chunk[4:8], uint32(body.Len()))
+	return append(chunk, body.Bytes()...)
+}
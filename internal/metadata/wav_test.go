@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"bytes"
+	"maps"
+	"testing"
+
+	"github.com/drgolem/musictools/internal/audiotest"
+)
+
+// infoList returns a LIST/INFO chunk with the given sub-chunks.
+func infoList(subs ...[]byte) []byte {
+	return audiotest.RIFFChunk("LIST", append([]byte("INFO"), bytes.Join(subs, nil)...))
+}
+
+func TestReadWAVInfo(t *testing.T) {
+	fmtChunk := audiotest.RIFFChunk("fmt ", make([]byte, 16))
+	data := audiotest.RIFFChunk("data", make([]byte, 7))
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{"no tags", audiotest.WAVFile(fmtChunk, data), map[string]string{}},
+		{"after data", audiotest.WAVFile(fmtChunk, data, infoList(
+			audiotest.RIFFChunk("INAM", []byte("Song\x00")),
+			audiotest.RIFFChunk("IART", []byte("Band\x00\x00")),
+			audiotest.RIFFChunk("ITRK", []byte("3")),
+		)), map[string]string{Title: "Song", Artist: "Band", TrackNumber: "3"}},
+		{"before data", audiotest.WAVFile(fmtChunk, infoList(audiotest.RIFFChunk("IPRD", []byte("Album\x00"))), data),
+			map[string]string{Album: "Album"}},
+		{"unknown and empty IDs skipped", audiotest.WAVFile(infoList(
+			audiotest.RIFFChunk("ISFT", []byte("encoder\x00")),
+			audiotest.RIFFChunk("IGNR", []byte("\x00")),
+			audiotest.RIFFChunk("ICMT", []byte("note\x00")),
+		)), map[string]string{Comment: "note"}},
+		{"other LIST type", audiotest.WAVFile(audiotest.RIFFChunk("LIST", append([]byte("adtl"), audiotest.RIFFChunk("INAM", []byte("x\x00"))...))),
+			map[string]string{}},
+		{"truncated LIST", audiotest.WAVFile(infoList(audiotest.RIFFChunk("INAM", []byte("Song\x00"))))[:30], map[string]string{}},
+		{"sub-chunk overruns LIST", audiotest.WAVFile(audiotest.RIFFChunk("LIST", []byte("INFOINAM\xff\x00\x00\x00x"))), map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadWAVInfo(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadWAVInfoErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not WAVE", audiotest.RIFFChunk("RIFF", []byte("AVI "))},
+		{"not RIFF", []byte("FORM\x00\x00\x00\x04AIFF")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadWAVInfo(bytes.NewReader(tt.data)); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
+
+func TestEncodeWAVInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		want []byte
+	}{
+		{"nil", nil, nil},
+		{"nothing encodable", map[string]string{"composer": "X", Title: ""}, nil},
+		{"sorted and padded", map[string]string{Title: "Song", Artist: "Bands", "composer": "X"}, infoList(
+			audiotest.RIFFChunk("IART", []byte("Bands\x00")),
+			audiotest.RIFFChunk("INAM", []byte("Song\x00")),
+		)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EncodeWAVInfo(tt.tags)
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeWAVInfoRoundTrip(t *testing.T) {
+	tags := map[string]string{
+		Title:       "Title",
+		Artist:      "Artist",
+		Album:       "Album name",
+		Genre:       "Rock",
+		Date:        "1999",
+		Comment:     "odd",
+		TrackNumber: "12",
+	}
+	got, err := ReadWAVInfo(bytes.NewReader(audiotest.WAVFile(EncodeWAVInfo(tags))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(got, tags) {
+		t.Fatalf("got %v, want %v", got, tags)
+	}
+}
@@ -0,0 +1,140 @@
+// Package selftest checks that the decoder stack works by decoding tiny
+// audio samples embedded in the binary.
+package selftest
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/drgolem/musictools/internal/decoders"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Result is the outcome of a single self-test check.
+type Result struct {
+	Name   string
+	Detail string
+	Err    error
+}
+
+// Passed reports whether the check succeeded.
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// fixture describes an embedded sample and the format it must decode to.
+type fixture struct {
+	name          string
+	file          string
+	sampleRate    int
+	channels      int
+	bitsPerSample int
+	samples       int // expected sample frames, 0 = any non-zero count
+}
+
+// decoderFixtures lists the built-in samples: a 440Hz sine (50ms, 8kHz mono)
+// for WAV and FLAC, and four frames of MPEG-1 Layer III digital silence.
+var decoderFixtures = []fixture{
+	{name: "wav", file: "sine.wav", sampleRate: 8000, channels: 1, bitsPerSample: 16, samples: 400},
+	{name: "flac", file: "sine.flac", sampleRate: 8000, channels: 1, bitsPerSample: 16, samples: 400},
+	{name: "mp3", file: "silence.mp3", sampleRate: 32000, channels: 2, bitsPerSample: 16},
+}
+
+// CheckDecoders decodes every built-in sample through the decoder factory
+// and verifies the reported format and decoded sample count.
+func CheckDecoders() []Result {
+	results := make([]Result, 0, len(decoderFixtures))
+	for _, fx := range decoderFixtures {
+		results = append(results, checkDecoder(fx))
+	}
+	return results
+}
+
+func checkDecoder(fx fixture) Result {
+	res := Result{Name: "decoder/" + fx.name}
+
+	fileName, cleanup, err := extractFixture(fx.file)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer cleanup()
+
+	samples, err := decodeFixture(fileName, fx)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	res.Detail = fmt.Sprintf("%d:%d:%d, %d samples", fx.sampleRate, fx.channels, fx.bitsPerSample, samples)
+	return res
+}
+
+// decodeFixture opens fileName, checks its format and decodes it to the end,
+// returning the number of sample frames decoded.
+func decodeFixture(fileName string, fx fixture) (int, error) {
+	dec, err := decoders.NewDecoder(fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer dec.Close()
+
+	rate, channels, bps := dec.GetFormat()
+	if rate != fx.sampleRate || channels != fx.channels || bps != fx.bitsPerSample {
+		return 0, fmt.Errorf("format %d:%d:%d, expected %d:%d:%d",
+			rate, channels, bps, fx.sampleRate, fx.channels, fx.bitsPerSample)
+	}
+
+	const chunkSamples = 1024
+	buf := make([]byte, chunkSamples*channels*bps/8)
+	total := 0
+	for {
+		n, err := dec.DecodeSamples(chunkSamples, buf)
+		total += n
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return total, fmt.Errorf("decode failed after %d samples: %w", total, err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	if fx.samples > 0 && total != fx.samples {
+		return total, fmt.Errorf("decoded %d samples, expected %d", total, fx.samples)
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("no samples decoded")
+	}
+	return total, nil
+}
+
+// extractFixture copies an embedded sample to a temporary file, since the
+// decoders open files by name. The returned cleanup removes it.
+func extractFixture(name string) (string, func(), error) {
+	data, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		return "", nil, fmt.Errorf("missing fixture %s: %w", name, err)
+	}
+
+	dir, err := os.MkdirTemp("", "musictools-selftest-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	fileName := filepath.Join(dir, name)
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return fileName, cleanup, nil
+}
@@ -0,0 +1,19 @@
+//go:build cgo
+
+package selftest
+
+import "testing"
+
+func TestCheckDecoders(t *testing.T) {
+	results := CheckDecoders()
+	if len(results) != len(decoderFixtures) {
+		t.Fatalf("got %d results, want one per fixture (%d)", len(results), len(decoderFixtures))
+	}
+	for _, r := range results {
+		if !r.Passed() {
+			t.Errorf("%s: %v", r.Name, r.Err)
+			continue
+		}
+		t.Logf("%s: %s", r.Name, r.Detail)
+	}
+}
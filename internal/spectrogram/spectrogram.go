@@ -0,0 +1,84 @@
+// Package spectrogram renders the short-time spectrum of audio as an image.
+package spectrogram
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/drgolem/musictools/internal/audioproc"
+)
+
+// Options controls the analysis and the image size.
+type Options struct {
+	// WindowSize is the FFT length in samples, a power of two. The image
+	// has WindowSize/2 rows, one per frequency bin, lowest at the bottom.
+	WindowSize int
+	// Width is the number of columns. Windows are spaced evenly over the
+	// audio to fill it, overlapping when there is little audio.
+	Width int
+	// MinDB is the level, relative to full scale, drawn as black.
+	// Levels from MinDB to 0 dBFS span the color map.
+	MinDB float64
+}
+
+// DefaultOptions returns options suited to music at common sample rates.
+func DefaultOptions() Options {
+	return Options{WindowSize: 2048, Width: 1200, MinDB: -100}
+}
+
+// Render computes the spectrogram of mono samples in [-1, 1].
+func Render(samples []float64, opts Options) (image.Image, error) {
+	if opts.Width <= 0 {
+		return nil, fmt.Errorf("invalid spectrogram width: %d", opts.Width)
+	}
+	if opts.MinDB >= 0 {
+		return nil, fmt.Errorf("MinDB must be negative, got %g", opts.MinDB)
+	}
+	if len(samples) < opts.WindowSize {
+		return nil, fmt.Errorf("need at least %d samples, got %d", opts.WindowSize, len(samples))
+	}
+
+	rows := opts.WindowSize / 2
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, rows))
+	span := len(samples) - opts.WindowSize
+
+	for x := 0; x < opts.Width; x++ {
+		start := 0
+		if opts.Width > 1 {
+			start = span * x / (opts.Width - 1)
+		}
+		mags, err := audioproc.Spectrum(samples[start : start+opts.WindowSize])
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip the DC bin so the rows are bins 1..rows.
+		for bin := 1; bin <= rows; bin++ {
+			db := 20 * math.Log10(max(mags[bin], 1e-12))
+			level := (db - opts.MinDB) / -opts.MinDB
+			img.Set(x, rows-bin, heat(max(0, min(1, level))))
+		}
+	}
+	return img, nil
+}
+
+// heat maps a level in [0, 1] to a black-blue-red-yellow-white color.
+func heat(v float64) color.RGBA {
+	stops := []color.RGBA{
+		{0, 0, 0, 255},
+		{0, 0, 160, 255},
+		{200, 0, 60, 255},
+		{255, 200, 0, 255},
+		{255, 255, 255, 255},
+	}
+	pos := v * float64(len(stops)-1)
+	i := min(int(pos), len(stops)-2)
+	t := pos - float64(i)
+	a, b := stops[i], stops[i+1]
+	lerp := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 255}
+}
@@ -0,0 +1,110 @@
+package spectrogram
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+// sine returns n samples of a sine that completes cycles periods every
+// window samples.
+func sine(n, window, cycles int, amp float64) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = amp * math.Sin(2*math.Pi*float64(cycles*i)/float64(window))
+	}
+	return s
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []float64
+		opts    Options
+		bin     int // brightest bin in every column, 0 for silence
+	}{
+		{"sine", sine(4096, 256, 20, 1), Options{WindowSize: 256, Width: 10, MinDB: -100}, 20},
+		{"quiet sine", sine(1024, 128, 50, 0.01), Options{WindowSize: 128, Width: 4, MinDB: -80}, 50},
+		{"one column", sine(64, 64, 5, 1), Options{WindowSize: 64, Width: 1, MinDB: -60}, 5},
+		{"overlapping windows", sine(300, 256, 30, 1), Options{WindowSize: 256, Width: 50, MinDB: -100}, 30},
+		{"silence", make([]float64, 512), Options{WindowSize: 256, Width: 3, MinDB: -100}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Render(tt.samples, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows := tt.opts.WindowSize / 2
+			if b := img.Bounds(); b.Dx() != tt.opts.Width || b.Dy() != rows {
+				t.Fatalf("got %dx%d image, want %dx%d", b.Dx(), b.Dy(), tt.opts.Width, rows)
+			}
+			black := color.RGBAModel.Convert(heat(0))
+			for x := range tt.opts.Width {
+				for y := range rows {
+					c := img.At(x, y)
+					bin := rows - y
+					if tt.bin == 0 || (bin < tt.bin-1 || bin > tt.bin+1) {
+						if c != black {
+							t.Fatalf("column %d bin %d is %v, want black", x, bin, c)
+						}
+					}
+				}
+				if tt.bin != 0 && img.At(x, rows-tt.bin) == black {
+					t.Fatalf("column %d bin %d is black", x, tt.bin)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples int
+		opts    Options
+	}{
+		{"no width", 4096, Options{WindowSize: 256, Width: 0, MinDB: -100}},
+		{"positive MinDB", 4096, Options{WindowSize: 256, Width: 10, MinDB: 10}},
+		{"zero MinDB", 4096, Options{WindowSize: 256, Width: 10, MinDB: 0}},
+		{"too few samples", 100, Options{WindowSize: 256, Width: 10, MinDB: -100}},
+		{"window not a power of two", 4096, Options{WindowSize: 300, Width: 10, MinDB: -100}},
+		{"no window", 4096, Options{WindowSize: 0, Width: 10, MinDB: -100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Render(make([]float64, tt.samples), tt.opts); err == nil {
+				t.Fatal("got nil error")
+			}
+		})
+	}
+}
+
+func TestHeat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want color.RGBA
+	}{
+		{0, color.RGBA{0, 0, 0, 255}},
+		{0.125, color.RGBA{0, 0, 80, 255}},
+		{0.25, color.RGBA{0, 0, 160, 255}},
+		{0.5, color.RGBA{200, 0, 60, 255}},
+		{0.75, color.RGBA{255, 200, 0, 255}},
+		{1, color.RGBA{255, 255, 255, 255}},
+	}
+	for _, tt := range tests {
+		if got := heat(tt.v); got != tt.want {
+			t.Errorf("heat(%v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	opts := DefaultOptions()
+	if n := opts.WindowSize; n < 2 || n&(n-1) != 0 {
+		t.Errorf("WindowSize %d is not a power of two", n)
+	}
+	if opts.Width <= 0 || opts.MinDB >= 0 {
+		t.Errorf("invalid default options %+v", opts)
+	}
+}